@@ -93,6 +93,10 @@ func main() {
 	goalsRepo := repository.NewGoalsRepository(wrappedDB)
 	listeningRepo := repository.NewListeningRepository(wrappedDB)
 	conversationRepo := repository.NewConversationRepository(wrappedDB)
+	vocabQuizRepo := repository.NewVocabQuizRepository(wrappedDB)
+	grammarDrillRepo := repository.NewGrammarDrillRepository(wrappedDB)
+	grammarComparisonRepo := repository.NewGrammarComparisonRepository(wrappedDB)
+	grammarPrereqRepo := repository.NewGrammarPrerequisiteRepository(wrappedDB)
 
 	// Seed static data (kanji, listening exercises, conversation scenarios)
 	log.Println("Seeding static data...")
@@ -113,18 +117,30 @@ func main() {
 	}
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, cfg.JWT.Secret, cfg.JWT.ExpirationHours)
+	authService := services.NewAuthService(userRepo, cfg.JWT.Secret, cfg.JWT.ExpirationHours, cfg.JWT.WidgetExpirationDays)
 	vocabService := services.NewVocabService(vocabRepo, progressRepo, userRepo)
 	placementService := services.NewPlacementService(placementRepo, userRepo)
-	grammarService := services.NewGrammarService(grammarRepo, progressRepo, userRepo)
-	srsService := services.NewSRSService(srsRepo, vocabRepo, grammarRepo, userRepo)
-	conjService := services.NewConjugationService(conjRepo)
+	grammarService := services.NewGrammarService(grammarRepo, grammarComparisonRepo, grammarPrereqRepo, progressRepo, conjRepo, userRepo)
+	srsService := services.NewSRSService(srsRepo, vocabRepo, grammarRepo, kanjiRepo, userRepo)
+	deinflector := services.NewDeinflector(vocabRepo)
+	conjService := services.NewConjugationService(conjRepo, vocabRepo, deinflector)
 	ttsService := services.NewTTSService(ttsRepo)
 	jlptService := services.NewJLPTService(jlptRepo)
-	kanjiService := services.NewKanjiService(kanjiRepo)
+	kanjiService := services.NewKanjiService(kanjiRepo, vocabRepo, userRepo, srsService)
 	goalsService := services.NewGoalsService(goalsRepo)
 	listeningService := services.NewListeningService(listeningRepo)
-	conversationService := services.NewConversationService(conversationRepo)
+	grammarDetector := services.NewGrammarDetector(grammarRepo, deinflector)
+	conversationService := services.NewConversationService(conversationRepo, grammarDetector)
+	widgetService := services.NewWidgetService(vocabRepo, grammarRepo, progressRepo, userRepo)
+	vocabQuizService := services.NewVocabQuizService(vocabQuizRepo, vocabRepo, srsRepo, userRepo)
+	grammarDrillService := services.NewGrammarDrillService(grammarRepo, grammarDrillRepo, progressRepo, userRepo)
+
+	// Link words to the kanji they're written with, now the seeds are in
+	if linked, unaligned, err := kanjiService.LinkVocabulary(); err != nil {
+		log.Printf("Warning: failed to link vocabulary to kanji: %v", err)
+	} else {
+		log.Printf("Linked %d words to their kanji (%d couldn't be aligned with their reading)", linked, unaligned)
+	}
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
@@ -140,6 +156,10 @@ func main() {
 	goalsHandler := handlers.NewGoalsHandler(goalsService)
 	listeningHandler := handlers.NewListeningHandler(listeningService)
 	conversationHandler := handlers.NewConversationHandler(conversationService)
+	widgetHandler := handlers.NewWidgetHandler(widgetService, authService)
+	vocabQuizHandler := handlers.NewVocabQuizHandler(vocabQuizService)
+	grammarDrillHandler := handlers.NewGrammarDrillHandler(grammarDrillService)
+	grammarDetectHandler := handlers.NewGrammarDetectHandler(grammarDetector)
 
 	// Set up Gin router
 	if cfg.Server.Env == "production" {
@@ -185,6 +205,13 @@ func main() {
 			placement.GET("/result", middleware.AuthMiddleware(authService), placementHandler.GetUserTestResult)
 		}
 
+		// Widget feed (accepts long-lived widget tokens)
+		widget := v1.Group("/widget")
+		widget.Use(middleware.WidgetAuthMiddleware(authService))
+		{
+			widget.GET("/feed", widgetHandler.GetFeed) // Compact timeline for home-screen widgets
+		}
+
 		// Protected routes
 		protected := v1.Group("")
 		protected.Use(middleware.AuthMiddleware(authService))
@@ -201,6 +228,14 @@ func main() {
 			protected.GET("/vocab/level/:level", vocabHandler.GetVocabularyByLevel)
 			protected.GET("/vocab/search", vocabHandler.SearchVocabulary)
 
+			// Vocabulary quiz routes (multiple choice)
+			vocabQuiz := protected.Group("/quiz/vocab")
+			{
+				vocabQuiz.POST("/start", vocabQuizHandler.StartQuiz)     // Generate quiz for a level or SRS deck
+				vocabQuiz.POST("/answer", vocabQuizHandler.SubmitAnswer) // Submit answer (misses go to SRS)
+				vocabQuiz.GET("/:id", vocabQuizHandler.GetSession)       // Get quiz progress
+			}
+
 			// Progress routes
 			progress := protected.Group("/progress")
 			{
@@ -212,10 +247,23 @@ func main() {
 			grammar := protected.Group("/grammar")
 			{
 				grammar.GET("/daily", grammarHandler.GetDailyPattern)
+				grammar.GET("/curriculum/next", grammarHandler.GetCurriculumPattern)  // Next pattern with prerequisites met
+				grammar.GET("/curriculum/tree", grammarHandler.GetCurriculumTree)     // Locked/unlocked patterns for a level
 				grammar.GET("/:id", grammarHandler.GetPatternByID)
 				grammar.POST("/:id/skip", grammarHandler.SkipPattern)
+				grammar.POST("/:id/status", grammarHandler.SetPatternStatus)          // Mark learning/known/skipped
+				grammar.DELETE("/:id/status", grammarHandler.ResetPatternStatus)      // Reset a pattern to unseen
+				grammar.POST("/:id/revisit", grammarHandler.RevisitPattern)           // Move a pattern back to learning
+				grammar.GET("/learned", grammarHandler.GetLearnedPatterns)            // Marked patterns by level/status
 				grammar.GET("/compare/pairs", grammarHandler.GetComparisonPairs)   // Get comparison pairs
 				grammar.GET("/compare/detail", grammarHandler.ComparePatterns)        // Compare specific patterns
+				grammar.GET("/compare/:id", grammarHandler.GetComparison)             // Curated comparison by ID
+				grammar.GET("/compare/:id/quiz", grammarHandler.GetComparisonQuiz)    // Which pattern fits this context?
+				grammar.POST("/compare/:id/quiz", grammarHandler.SubmitComparisonAnswer) // Check a comparison quiz answer
+				grammar.GET("/drill", grammarDrillHandler.GetDrill)                   // Cloze drill from usage examples
+				grammar.POST("/drill/answer", grammarDrillHandler.SubmitAnswer)       // Check a cloze answer
+				grammar.GET("/drill/weak-points", grammarDrillHandler.GetWeakPoints)  // Patterns with low drill accuracy
+				grammar.POST("/detect", grammarDetectHandler.Detect)                  // Find grammar patterns in a sentence
 			}
 			protected.GET("/grammar/level/:level", grammarHandler.GetPatternsByLevel)
 			protected.GET("/grammar/search", grammarHandler.SearchGrammar)
@@ -233,10 +281,23 @@ func main() {
 			conjugation := protected.Group("/conjugation")
 			{
 				conjugation.GET("/start", conjHandler.StartSession)     // Start drill session
+				conjugation.GET("/chain/start", conjHandler.StartChainSession) // Start chained-forms drill
+				conjugation.GET("/chains", conjHandler.GetChains)               // Common form chains
+				conjugation.GET("/keigo/start", conjHandler.StartKeigoSession)  // Start sonkeigo/kenjougo drill
+				conjugation.GET("/keigo", conjHandler.GetKeigoReference)        // Keigo forms and special verbs
 				conjugation.POST("/answer", conjHandler.SubmitAnswer)    // Submit answer
+				conjugation.GET("/sessions", conjHandler.ListSessions)              // Sessions with status
+				conjugation.GET("/sessions/:id", conjHandler.GetSession)            // Session with remaining challenges
+				conjugation.POST("/sessions/:id/pause", conjHandler.PauseSession)   // Pause a practice session
+				conjugation.POST("/sessions/:id/resume", conjHandler.ResumeSession) // Resume where it was left
 				conjugation.GET("/progress", conjHandler.GetProgress)    // Get progress stats
+				conjugation.GET("/timed/start", conjHandler.StartTimedSession) // Start speed-run
+				conjugation.GET("/timed/:id", conjHandler.GetSpeedRun)         // Speed-run score and personal best
+				conjugation.GET("/speed", conjHandler.GetSpeed)                // Response times, trends, personal bests
 				conjugation.GET("/weak-points", conjHandler.GetWeakPoints) // Get weak points analysis
 				conjugation.POST("/weak-points/drill", conjHandler.StartWeakPointDrill) // Start weak point drill
+				conjugation.GET("/conjugate", conjHandler.Conjugate)       // Every form of a verb or adjective
+				conjugation.GET("/analyze", conjHandler.Analyze)           // Deinflect a conjugated word
 			}
 
 			// TTS (Text-to-Speech) routes
@@ -258,6 +319,7 @@ func main() {
 				jlpt.POST("/answer", jlptHandler.SubmitAnswer)         // Submit answer
 				jlpt.POST("/complete/:session_id", jlptHandler.CompleteTest) // Finish test
 				jlpt.GET("/progress/:session_id", jlptHandler.GetProgress)    // Get progress
+				jlpt.POST("/next-section/:session_id", jlptHandler.NextSection) // End the current section early
 				jlpt.GET("/history", jlptHandler.GetHistory)           // Get test history
 			}
 
@@ -266,11 +328,25 @@ func main() {
 			{
 				kanji.GET("/level/:level", kanjiHandler.GetKanjiByLevel)      // Get kanji by JLPT level
 				kanji.GET("/character/:char", kanjiHandler.GetKanjiByCharacter) // Get kanji details
+				kanji.GET("/character/:char/components", kanjiHandler.GetComponentTree) // Component tree
+				kanji.GET("/character/:char/related", kanjiHandler.GetRelatedKanji)     // Kanji sharing a component
+				kanji.GET("/character/:char/vocabulary", kanjiHandler.GetKanjiVocabulary) // Words using the kanji, by reading
+				kanji.GET("/radicals", kanjiHandler.GetRadicals)                         // Radicals to search by
+				kanji.GET("/search/components", kanjiHandler.SearchByComponents)         // Search by components and stroke count
 				kanji.POST("/practice/start", kanjiHandler.StartPracticeSession) // Start practice session
 				kanji.POST("/practice/compare", kanjiHandler.CompareStroke)     // Compare stroke
 				kanji.GET("/practice/:id", kanjiHandler.GetPracticeSession)     // Get session
+				kanji.GET("/practice/:id/report", kanjiHandler.GetStrokeReport) // Stroke-by-stroke error report
 				kanji.GET("/stats", kanjiHandler.GetUserStats)                   // Get user stats
+				kanji.GET("/stats/errors", kanjiHandler.GetStrokeErrorStats)     // Stroke errors and stroke-order rules broken
+				kanji.POST("/recognize", kanjiHandler.Recognize)                 // Recognise a drawn kanji
+				kanji.GET("/quiz/write", kanjiHandler.GetWriteQuiz)              // Start a write-from-memory quiz
+				kanji.POST("/quiz/write", kanjiHandler.CheckWriteQuiz)           // Grade it and update the kanji's SRS schedule
+				kanji.GET("/sheet", kanjiHandler.GetPracticeSheet)               // Printable practice sheet
 			}
+			// Public stroke order images (loaded by <img> tags)
+			v1.GET("/kanji/character/:char/svg", kanjiHandler.GetKanjiSVG)
+
 			// Admin: Seed kanji data
 			protected.POST("/kanji/seed", kanjiHandler.SeedKanjiData)
 
@@ -310,6 +386,10 @@ func main() {
 				nichijou.GET("/chat/history/:id", conversationHandler.GetSessionHistory) // Get session history
 				nichijou.GET("/stats", conversationHandler.GetUserStats)          // Get user stats
 			}
+
+			// Widget token issuance (requires a full session token)
+			protected.POST("/widget/token", widgetHandler.CreateToken)
+			protected.DELETE("/widget/token", widgetHandler.RevokeTokens)
 		}
 	}
 
@@ -339,6 +419,23 @@ func main() {
 		log.Println("Started periodic WAL checkpointing (every 5min)")
 	}
 
+	// Submit JLPT mock tests whose time has run out, so abandoned ones don't
+	// stay in progress forever
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			submitted, err := jlptService.ExpireSessions()
+			if err != nil {
+				log.Printf("JLPT test expiry error: %v", err)
+			}
+			if submitted > 0 {
+				log.Printf("Submitted %d JLPT tests whose time ran out", submitted)
+			}
+		}
+	}()
+	log.Println("Started JLPT test expiry (every 1min)")
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
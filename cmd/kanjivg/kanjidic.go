@@ -0,0 +1,158 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// kanjidicEntry is what we take from a KANJIDIC2 character entry
+type kanjidicEntry struct {
+	Literal string `xml:"literal"`
+	Misc    struct {
+		Grade       int `xml:"grade"`
+		StrokeCount int `xml:"stroke_count"`
+		JLPT        int `xml:"jlpt"` // Old four-level test, 4 (easiest) to 1
+	} `xml:"misc"`
+	Readings []struct {
+		Type  string `xml:"r_type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"reading_meaning>rmgroup>reading"`
+	Meanings []struct {
+		Lang  string `xml:"m_lang,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"reading_meaning>rmgroup>meaning"`
+}
+
+// isJoyo reports whether the character is on the Jōyō list: grades 1-6 are
+// taught in elementary school, grade 8 covers the rest of the list
+func (e *kanjidicEntry) isJoyo() bool {
+	return (e.Misc.Grade >= 1 && e.Misc.Grade <= 6) || e.Misc.Grade == 8
+}
+
+// jlptLevel maps the old JLPT level onto the current one. The old level 2
+// was split into N3 and N2; characters taught in elementary school go to N3.
+// Jōyō characters outside the old lists are N1.
+func (e *kanjidicEntry) jlptLevel() string {
+	switch e.Misc.JLPT {
+	case 4:
+		return "N5"
+	case 3:
+		return "N4"
+	case 2:
+		if e.Misc.Grade >= 1 && e.Misc.Grade <= 6 {
+			return "N3"
+		}
+		return "N2"
+	default:
+		return "N1"
+	}
+}
+
+// meaning joins the English meanings ("Day, sun, Japan")
+func (e *kanjidicEntry) meaning() string {
+	var meanings []string
+	for _, m := range e.Meanings {
+		if m.Lang == "" || m.Lang == "en" {
+			meanings = append(meanings, m.Value)
+		}
+	}
+	s := strings.Join(meanings, ", ")
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// readings lists the on and kun readings in hiragana, the way the app shows
+// them: on readings first, kun readings without their okurigana (あ.がる → あ)
+func (e *kanjidicEntry) readings() []string {
+	return e.readingsOf("ja_on", "ja_kun")
+}
+
+// onReadings lists the on readings alone, in hiragana
+func (e *kanjidicEntry) onReadings() []string {
+	return e.readingsOf("ja_on")
+}
+
+func (e *kanjidicEntry) readingsOf(kinds ...string) []string {
+	readings := []string{}
+	seen := make(map[string]bool)
+	for _, kind := range kinds {
+		for _, r := range e.Readings {
+			if r.Type != kind {
+				continue
+			}
+			v := strings.Trim(r.Value, "-")
+			if i := strings.Index(v, "."); i >= 0 {
+				v = v[:i]
+			}
+			v = katakanaToHiragana(v)
+			if v == "" || seen[v] {
+				continue
+			}
+			seen[v] = true
+			readings = append(readings, v)
+		}
+	}
+	return readings
+}
+
+func katakanaToHiragana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ァ' && r <= 'ヶ' {
+			return r - 'ァ' + 'ぁ'
+		}
+		return r
+	}, s)
+}
+
+// readKanjidic reads the Jōyō entries of a KANJIDIC2 file (kanjidic2.xml,
+// optionally gzipped), keyed by character
+func readKanjidic(path string) (map[string]*kanjidicEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	entries := make(map[string]*kanjidicEntry)
+	dec := xml.NewDecoder(r)
+	dec.Strict = false // The DTD declares entities the decoder doesn't know
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "character" {
+			continue
+		}
+		entry := &kanjidicEntry{}
+		if err := dec.DecodeElement(entry, &start); err != nil {
+			return nil, err
+		}
+		if entry.isJoyo() {
+			entries[entry.Literal] = entry
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"github.com/erwinwahyura/daily-kotoba/internal/services"
+)
+
+// Our canvas is 100x100; KanjiVG draws on a 109x109 view box
+const canvasSize = 100.0
+
+// kanjiVGFile is one character's stroke paths from a KanjiVG SVG file
+type kanjiVGFile struct {
+	Character string
+	Code      string // Hex code point, as in the file name (065e5)
+	Strokes   []models.Stroke
+}
+
+// svgElement is the generic shape of the SVG: groups nest, strokes are paths
+type svgElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr   `xml:",any,attr"`
+	Children []svgElement `xml:",any"`
+}
+
+func (e *svgElement) attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// readKanjiVGArchive reads every standard SVG from a KanjiVG release, either
+// unpacked (a directory holding kanji/*.svg) or the zip itself. Variant
+// files (065e5-Kaisho.svg) are skipped.
+func readKanjiVGArchive(path string) (map[string]*kanjiVGFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*kanjiVGFile)
+	read := func(name string, open func() (io.ReadCloser, error)) error {
+		code, ok := kanjiVGCode(name)
+		if !ok {
+			return nil
+		}
+		r, err := open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		f, err := parseKanjiVG(r, code)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		files[f.Character] = f
+		return nil
+	}
+
+	if info.IsDir() {
+		err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			return read(p, func() (io.ReadCloser, error) { return os.Open(p) })
+		})
+		return files, err
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither a directory nor a zip archive: %w", path, err)
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		if err := read(zf.Name, zf.Open); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// kanjiVGCode gets the code point from a KanjiVG file name (kanji/065e5.svg)
+func kanjiVGCode(name string) (string, bool) {
+	base := filepath.Base(name)
+	if !strings.HasSuffix(base, ".svg") {
+		return "", false
+	}
+	code := strings.TrimSuffix(base, ".svg")
+	if strings.Contains(code, "-") {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(code, 16, 32); err != nil {
+		return "", false
+	}
+	return code, true
+}
+
+// parseKanjiVG reads the stroke paths of one SVG file, in stroke order
+func parseKanjiVG(r io.Reader, code string) (*kanjiVGFile, error) {
+	var root svgElement
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, err
+	}
+	cp, _ := strconv.ParseUint(code, 16, 32)
+	f := &kanjiVGFile{Character: string(rune(cp)), Code: code}
+
+	scale := canvasSize / 109
+	if box := strings.Fields(root.attr("viewBox")); len(box) == 4 {
+		if w, err := strconv.ParseFloat(box[2], 64); err == nil && w > 0 {
+			scale = canvasSize / w
+		}
+	}
+
+	// Stroke paths are the ones with an ID ending in -sN; document order is
+	// stroke order
+	var walk func(e *svgElement) error
+	walk = func(e *svgElement) error {
+		if e.XMLName.Local == "path" && strings.Contains(e.attr("id"), "-s") {
+			points, err := parseStrokePath(e.attr("d"), scale)
+			if err != nil {
+				return fmt.Errorf("stroke %d: %w", len(f.Strokes)+1, err)
+			}
+			if len(points) < 2 {
+				return fmt.Errorf("stroke %d has no length", len(f.Strokes)+1)
+			}
+			f.Strokes = append(f.Strokes, models.Stroke{
+				StrokeNum:  len(f.Strokes) + 1,
+				Path:       points,
+				Direction:  services.StrokeDirection(points),
+				StartPoint: points[0],
+				EndPoint:   points[len(points)-1],
+			})
+		}
+		for i := range e.Children {
+			if err := walk(&e.Children[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(&root); err != nil {
+		return nil, err
+	}
+	if len(f.Strokes) == 0 {
+		return nil, fmt.Errorf("no strokes")
+	}
+	return f, nil
+}
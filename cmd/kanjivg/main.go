@@ -0,0 +1,140 @@
+// Command kanjivg imports stroke order data for the Jōyō kanji.
+//
+// Stroke paths come from a KanjiVG release (https://kanjivg.tagaini.net),
+// unpacked or as the zip; meanings, readings and the Jōyō list itself from
+// KANJIDIC2 (https://www.edrdg.org/wiki/index.php/KANJIDIC_Project).
+//
+//	go run ./cmd/kanjivg -kanjivg kanjivg-20240807-main.zip -kanjidic kanjidic2.xml.gz
+//
+// Characters already in the kanji table keep their ID and get their data
+// replaced, so the import can be run again with a newer release.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/config"
+	"github.com/erwinwahyura/daily-kotoba/internal/db"
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"github.com/erwinwahyura/daily-kotoba/internal/repository"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	kanjivgPath := flag.String("kanjivg", "", "KanjiVG release: a directory containing kanji/*.svg, or the zip")
+	kanjidicPath := flag.String("kanjidic", "", "KANJIDIC2 file (kanjidic2.xml or kanjidic2.xml.gz)")
+	dryRun := flag.Bool("dry-run", false, "Parse and report without writing to the database")
+	flag.Parse()
+
+	if *kanjivgPath == "" || *kanjidicPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	joyo, err := readKanjidic(*kanjidicPath)
+	if err != nil {
+		log.Fatalf("Failed to read KANJIDIC2: %v", err)
+	}
+	log.Printf("Read %d Jōyō kanji from KANJIDIC2", len(joyo))
+
+	strokes, err := readKanjiVGArchive(*kanjivgPath)
+	if err != nil {
+		log.Fatalf("Failed to read KanjiVG: %v", err)
+	}
+	log.Printf("Read %d characters from KanjiVG", len(strokes))
+
+	kanji, missing := buildKanji(joyo, strokes)
+	for _, m := range missing {
+		log.Printf("Skipping %s", m)
+	}
+	if *dryRun {
+		log.Printf("Dry run: %d kanji ready to import, %d skipped", len(kanji), len(missing))
+		return
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Connect to database
+	sqlDB, err := cfg.GetDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer sqlDB.Close()
+
+	if err := sqlDB.Ping(); err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+
+	wrappedDB := db.New(sqlDB, cfg.DB.Driver)
+	if cfg.DB.Driver == "sqlite" {
+		if err := wrappedDB.InitializeSQLite(); err != nil {
+			log.Fatalf("Failed to initialize SQLite: %v", err)
+		}
+	}
+
+	// The kanji table comes from the migrations
+	migrationsDir := os.Getenv("MIGRATIONS_DIR")
+	if migrationsDir == "" {
+		migrationsDir = "./migrations"
+	}
+	if err := wrappedDB.RunMigrations(migrationsDir); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	kanjiRepo := repository.NewKanjiRepository(wrappedDB)
+	for i := range kanji {
+		if err := kanjiRepo.UpsertKanji(&kanji[i]); err != nil {
+			log.Fatalf("Import stopped after %d kanji: %v", i, err)
+		}
+	}
+	log.Printf("Imported %d kanji (%d skipped)", len(kanji), len(missing))
+}
+
+// buildKanji pairs each Jōyō entry with its strokes, in a stable order. It
+// also returns why any character was left out.
+func buildKanji(joyo map[string]*kanjidicEntry, strokes map[string]*kanjiVGFile) ([]models.Kanji, []string) {
+	chars := make([]string, 0, len(joyo))
+	for c := range joyo {
+		chars = append(chars, c)
+	}
+	sort.Strings(chars)
+
+	var kanji []models.Kanji
+	var missing []string
+	now := time.Now()
+	for _, c := range chars {
+		entry := joyo[c]
+		f, ok := strokes[c]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%s: not in KanjiVG", c))
+			continue
+		}
+		if entry.Misc.StrokeCount > 0 && entry.Misc.StrokeCount != len(f.Strokes) {
+			// KanjiVG follows the textbook stroke order, so it wins; this
+			// is only worth a look
+			log.Printf("%s: KanjiVG has %d strokes, KANJIDIC2 %d", c, len(f.Strokes), entry.Misc.StrokeCount)
+		}
+		kanji = append(kanji, models.Kanji{
+			ID:          "kanji_" + f.Code,
+			Character:   c,
+			JLPTLevel:   entry.jlptLevel(),
+			Meaning:     entry.meaning(),
+			Readings:    entry.readings(),
+			OnReadings:  entry.onReadings(),
+			StrokeCount: len(f.Strokes),
+			StrokeOrder: f.Strokes,
+			CreatedAt:   now,
+		})
+	}
+	return kanji, missing
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// Each Bézier segment is flattened into this many line segments
+const curveSteps = 8
+
+// pathTokenizer splits SVG path data into commands and numbers. Numbers may
+// run together as KanjiVG writes them ("1.74-2.75", ".5.5").
+type pathTokenizer struct {
+	data string
+	pos  int
+}
+
+func (t *pathTokenizer) skipSeparators() {
+	for t.pos < len(t.data) {
+		switch t.data[t.pos] {
+		case ' ', ',', '\t', '\n', '\r':
+			t.pos++
+		default:
+			return
+		}
+	}
+}
+
+// command returns the next command letter, if the next token is one
+func (t *pathTokenizer) command() (byte, bool) {
+	t.skipSeparators()
+	if t.pos >= len(t.data) {
+		return 0, false
+	}
+	c := t.data[t.pos]
+	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		if c == 'e' || c == 'E' {
+			return 0, false
+		}
+		t.pos++
+		return c, true
+	}
+	return 0, false
+}
+
+// hasNumber reports whether another number follows before the next command
+func (t *pathTokenizer) hasNumber() bool {
+	t.skipSeparators()
+	if t.pos >= len(t.data) {
+		return false
+	}
+	c := t.data[t.pos]
+	return c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9')
+}
+
+func (t *pathTokenizer) number() (float64, error) {
+	t.skipSeparators()
+	start := t.pos
+	if t.pos < len(t.data) && (t.data[t.pos] == '-' || t.data[t.pos] == '+') {
+		t.pos++
+	}
+	seenDot, seenExp := false, false
+	for t.pos < len(t.data) {
+		c := t.data[t.pos]
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '.' && !seenDot && !seenExp:
+			seenDot = true
+		case (c == 'e' || c == 'E') && !seenExp:
+			seenExp = true
+			if t.pos+1 < len(t.data) && (t.data[t.pos+1] == '-' || t.data[t.pos+1] == '+') {
+				t.pos++
+			}
+		default:
+			return t.parse(start)
+		}
+		t.pos++
+	}
+	return t.parse(start)
+}
+
+func (t *pathTokenizer) parse(start int) (float64, error) {
+	v, err := strconv.ParseFloat(t.data[start:t.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad number %q at %d", t.data[start:t.pos], start)
+	}
+	return v, nil
+}
+
+func (t *pathTokenizer) numbers(n int) ([]float64, error) {
+	out := make([]float64, n)
+	for i := range out {
+		v, err := t.number()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// parseStrokePath flattens SVG path data (M, L, H, V, C, S, Q, T and Z, in
+// absolute and relative form) into a list of points, scaled by scale
+func parseStrokePath(data string, scale float64) ([]models.Point, error) {
+	t := &pathTokenizer{data: data}
+	var points []models.Point
+	var cur, start, ctrl models.Point // ctrl is the last control point, for S and T
+	var prev byte
+
+	add := func(p models.Point) {
+		points = append(points, p)
+	}
+
+	cmd, ok := t.command()
+	if !ok {
+		return nil, fmt.Errorf("path must start with a command")
+	}
+	for {
+		relative := cmd >= 'a' && cmd <= 'z'
+		abs := func(x, y float64) models.Point {
+			if relative {
+				return models.Point{X: cur.X + x, Y: cur.Y + y}
+			}
+			return models.Point{X: x, Y: y}
+		}
+
+		switch cmd {
+		case 'M', 'm':
+			v, err := t.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			cur = abs(v[0], v[1])
+			start = cur
+			add(cur)
+			cmd = 'L' + (cmd - 'M') // Further pairs are line-tos
+			ctrl, prev = cur, 'M'
+			if t.hasNumber() {
+				continue
+			}
+		case 'L', 'l':
+			v, err := t.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			cur = abs(v[0], v[1])
+			add(cur)
+			ctrl = cur
+		case 'H', 'h':
+			v, err := t.number()
+			if err != nil {
+				return nil, err
+			}
+			if relative {
+				v += cur.X
+			}
+			cur = models.Point{X: v, Y: cur.Y}
+			add(cur)
+			ctrl = cur
+		case 'V', 'v':
+			v, err := t.number()
+			if err != nil {
+				return nil, err
+			}
+			if relative {
+				v += cur.Y
+			}
+			cur = models.Point{X: cur.X, Y: v}
+			add(cur)
+			ctrl = cur
+		case 'C', 'c', 'S', 's':
+			var c1 models.Point
+			var rest []float64
+			var err error
+			if cmd == 'C' || cmd == 'c' {
+				var v []float64
+				if v, err = t.numbers(6); err != nil {
+					return nil, err
+				}
+				c1, rest = abs(v[0], v[1]), v[2:]
+			} else {
+				// S mirrors the previous cubic's second control point
+				c1 = cur
+				if prev == 'C' || prev == 'S' {
+					c1 = models.Point{X: 2*cur.X - ctrl.X, Y: 2*cur.Y - ctrl.Y}
+				}
+				if rest, err = t.numbers(4); err != nil {
+					return nil, err
+				}
+			}
+			c2, end := abs(rest[0], rest[1]), abs(rest[2], rest[3])
+			for i := 1; i <= curveSteps; i++ {
+				add(cubicPoint(cur, c1, c2, end, float64(i)/curveSteps))
+			}
+			cur, ctrl = end, c2
+		case 'Q', 'q', 'T', 't':
+			var c models.Point
+			var end models.Point
+			if cmd == 'Q' || cmd == 'q' {
+				v, err := t.numbers(4)
+				if err != nil {
+					return nil, err
+				}
+				c, end = abs(v[0], v[1]), abs(v[2], v[3])
+			} else {
+				c = cur
+				if prev == 'Q' || prev == 'T' {
+					c = models.Point{X: 2*cur.X - ctrl.X, Y: 2*cur.Y - ctrl.Y}
+				}
+				v, err := t.numbers(2)
+				if err != nil {
+					return nil, err
+				}
+				end = abs(v[0], v[1])
+			}
+			for i := 1; i <= curveSteps; i++ {
+				add(quadraticPoint(cur, c, end, float64(i)/curveSteps))
+			}
+			cur, ctrl = end, c
+		case 'Z', 'z':
+			cur, ctrl = start, start
+			add(cur)
+		default:
+			return nil, fmt.Errorf("unsupported path command %q", cmd)
+		}
+		prev = cmd &^ 0x20 // Upper case
+
+		if cmd != 'Z' && cmd != 'z' && t.hasNumber() {
+			continue // Implicit repeat of the same command
+		}
+		if cmd, ok = t.command(); !ok {
+			break
+		}
+	}
+	if t.skipSeparators(); t.pos < len(t.data) {
+		return nil, fmt.Errorf("unexpected %q at %d", t.data[t.pos], t.pos)
+	}
+
+	for i := range points {
+		points[i] = models.Point{X: round1(points[i].X * scale), Y: round1(points[i].Y * scale)}
+	}
+	return dedupePoints(points), nil
+}
+
+func cubicPoint(p0, p1, p2, p3 models.Point, t float64) models.Point {
+	u := 1 - t
+	a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+	return models.Point{
+		X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
+
+func quadraticPoint(p0, p1, p2 models.Point, t float64) models.Point {
+	u := 1 - t
+	a, b, c := u*u, 2*u*t, t*t
+	return models.Point{
+		X: a*p0.X + b*p1.X + c*p2.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y,
+	}
+}
+
+// dedupePoints drops points repeating the one before them
+func dedupePoints(points []models.Point) []models.Point {
+	out := points[:0]
+	for i, p := range points {
+		if i > 0 && p == out[len(out)-1] {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,122 @@
+// Command kradfile imports radicals and kanji component decomposition.
+//
+// Components come from KRADFILE and radicals, with their stroke counts, from
+// RADKFILE, both from the EDRDG radical files
+// (https://www.edrdg.org/krad/kradinf.html). The files can be the original
+// EUC-JP ones or UTF-8 conversions; KRADFILE2 (JIS X 0212 kanji) can be
+// given alongside KRADFILE.
+//
+//	go run ./cmd/kradfile -kradfile kradfile,kradfile2 -radkfile radkfilex
+//
+// Every import replaces the previous one.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/config"
+	"github.com/erwinwahyura/daily-kotoba/internal/db"
+	"github.com/erwinwahyura/daily-kotoba/internal/repository"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	kradPaths := flag.String("kradfile", "", "KRADFILE, or several separated by commas")
+	radkPath := flag.String("radkfile", "", "RADKFILE (or RADKFILEX)")
+	dryRun := flag.Bool("dry-run", false, "Parse and report without writing to the database")
+	flag.Parse()
+
+	if *kradPaths == "" || *radkPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	components := make(map[string][]string)
+	for _, path := range strings.Split(*kradPaths, ",") {
+		text, err := readEDRDGFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", path, err)
+		}
+		parsed, err := parseKradfile(text)
+		if err != nil {
+			log.Fatalf("Failed to parse %s: %v", path, err)
+		}
+		for char, parts := range parsed {
+			components[char] = parts
+		}
+		log.Printf("Read components of %d kanji from %s", len(parsed), path)
+	}
+
+	text, err := readEDRDGFile(*radkPath)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *radkPath, err)
+	}
+	radicals, err := parseRadkfile(text)
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", *radkPath, err)
+	}
+	log.Printf("Read %d radicals from %s", len(radicals), *radkPath)
+
+	// Every component should be a radical the search offers
+	known := make(map[string]bool, len(radicals))
+	for _, rad := range radicals {
+		known[rad.Character] = true
+	}
+	unknown := make(map[string]bool)
+	for _, parts := range components {
+		for _, p := range parts {
+			if !known[p] && !unknown[p] {
+				unknown[p] = true
+				log.Printf("Component %s is not in %s", p, *radkPath)
+			}
+		}
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d kanji and %d radicals ready to import", len(components), len(radicals))
+		return
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Connect to database
+	sqlDB, err := cfg.GetDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer sqlDB.Close()
+
+	if err := sqlDB.Ping(); err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+
+	wrappedDB := db.New(sqlDB, cfg.DB.Driver)
+	if cfg.DB.Driver == "sqlite" {
+		if err := wrappedDB.InitializeSQLite(); err != nil {
+			log.Fatalf("Failed to initialize SQLite: %v", err)
+		}
+	}
+
+	// The component tables come from the migrations
+	migrationsDir := os.Getenv("MIGRATIONS_DIR")
+	if migrationsDir == "" {
+		migrationsDir = "./migrations"
+	}
+	if err := wrappedDB.RunMigrations(migrationsDir); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	kanjiRepo := repository.NewKanjiRepository(wrappedDB)
+	if err := kanjiRepo.ReplaceComponents(radicals, components); err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	log.Printf("Imported components of %d kanji and %d radicals", len(components), len(radicals))
+}
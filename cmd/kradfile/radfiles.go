@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// readEDRDGFile reads a radical file as text. The EDRDG files are EUC-JP;
+// a file that is already valid UTF-8 is taken as it is.
+func readEDRDGFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	decoded, err := japanese.EUCJP.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("neither UTF-8 nor EUC-JP: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// parseKradfile reads the components of each kanji. Lines look like
+//
+//	語 : 口 五 言
+//
+// and comments start with #.
+func parseKradfile(text string) (map[string][]string, error) {
+	components := make(map[string][]string)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; scanner.Scan(); line++ {
+		row := strings.TrimSpace(scanner.Text())
+		if row == "" || strings.HasPrefix(row, "#") {
+			continue
+		}
+		char, parts, ok := strings.Cut(row, ":")
+		char = strings.TrimSpace(char)
+		if !ok || utf8.RuneCountInString(char) != 1 {
+			return nil, fmt.Errorf("line %d: expected \"kanji : components\"", line)
+		}
+
+		seen := make(map[string]bool)
+		for _, p := range strings.Fields(parts) {
+			if !seen[p] {
+				seen[p] = true
+				components[char] = append(components[char], p)
+			}
+		}
+	}
+	return components, scanner.Err()
+}
+
+// parseRadkfile reads the radicals and their stroke counts. Each radical
+// starts a block with a line like
+//
+//	$ 化 2 js01
+//
+// (the last field, an image name for radicals with no character of their
+// own, is optional); the lines after it list the kanji containing it.
+func parseRadkfile(text string) ([]models.Radical, error) {
+	var radicals []models.Radical
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; scanner.Scan(); line++ {
+		row := scanner.Text()
+		if !strings.HasPrefix(row, "$") {
+			continue
+		}
+		fields := strings.Fields(row)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected \"$ radical strokes\"", line)
+		}
+		strokes, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad stroke count %q", line, fields[2])
+		}
+		radicals = append(radicals, models.Radical{Character: fields[1], StrokeCount: strokes})
+	}
+	return radicals, scanner.Err()
+}
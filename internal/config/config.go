@@ -22,8 +22,9 @@ type ServerConfig struct {
 }
 
 type JWTConfig struct {
-	Secret          string
-	ExpirationHours int
+	Secret               string
+	ExpirationHours      int
+	WidgetExpirationDays int // long-lived, read-only widget tokens
 }
 
 type DBConfig struct {
@@ -48,8 +49,9 @@ func Load() (*Config, error) {
 			Env:  getEnv("ENV", "development"),
 		},
 		JWT: JWTConfig{
-			Secret:          getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-			ExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
+			Secret:               getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+			ExpirationHours:      getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
+			WidgetExpirationDays: getEnvAsInt("WIDGET_TOKEN_EXPIRATION_DAYS", 180),
 		},
 		DB: DBConfig{
 			Driver: dbDriver,
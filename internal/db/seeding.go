@@ -112,6 +112,10 @@ func LoadSeedJSON(path string) (*SeedData, error) {
 	seedType := "unknown"
 	if strings.Contains(name, "vocab") {
 		seedType = "vocabulary"
+	} else if strings.Contains(name, "comparison") {
+		seedType = "grammar_comparison"
+	} else if strings.Contains(name, "prerequisite") {
+		seedType = "grammar_prerequisite"
 	} else if strings.Contains(name, "grammar") {
 		seedType = "grammar"
 	} else if strings.Contains(name, "placement") {
@@ -253,6 +257,81 @@ func (db *DB) SeedGrammar(seedFile string) (int, error) {
 	return count, nil
 }
 
+// SeedGrammarComparisons inserts curated grammar comparisons from seed file.
+// Patterns referenced by pattern_a_id/pattern_b_id must already be seeded.
+func (db *DB) SeedGrammarComparisons(seedFile string) (int, error) {
+	return db.seedTable(seedFile, "grammar_comparisons", map[string]bool{
+		"aspects": true, "example_pairs": true, "boundary_rules": true,
+		"decision_tree": true, "common_errors": true, "quiz": true,
+	})
+}
+
+// SeedGrammarPrerequisites inserts grammar curriculum edges from seed file
+func (db *DB) SeedGrammarPrerequisites(seedFile string) (int, error) {
+	return db.seedTable(seedFile, "grammar_prerequisites", nil)
+}
+
+// seedTable inserts every record of a seed file into table, skipping duplicates.
+// jsonColumns are stored as JSON text.
+func (db *DB) seedTable(seedFile, table string, jsonColumns map[string]bool) (int, error) {
+	seedData, err := LoadSeedJSON(seedFile)
+	if err != nil {
+		return 0, err
+	}
+
+	applied, err := db.IsSeedApplied(seedData.Name)
+	if err != nil {
+		return 0, err
+	}
+	if applied {
+		return 0, nil
+	}
+
+	count := 0
+	for _, record := range seedData.Records {
+		columns := make([]string, 0)
+		placeholders := make([]string, 0)
+		values := make([]interface{}, 0)
+
+		for col, val := range record {
+			columns = append(columns, col)
+			placeholders = append(placeholders, db.Placeholder(len(values)+1))
+
+			if jsonColumns[col] {
+				jsonVal, err := db.JSONValue(val)
+				if err != nil {
+					return count, fmt.Errorf("failed to marshal JSON for %s: %w", col, err)
+				}
+				values = append(values, jsonVal)
+			} else {
+				values = append(values, val)
+			}
+		}
+
+		query := fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES (%s)",
+			table,
+			strings.Join(columns, ", "),
+			strings.Join(placeholders, ", "),
+		)
+
+		if _, err := db.Exec(query, values...); err != nil {
+			if !isDuplicateError(err, db.Driver) {
+				return count, fmt.Errorf("failed to insert %s record %v: %w", table, record["id"], err)
+			}
+		} else {
+			count++
+		}
+	}
+
+	checksum := fmt.Sprintf("records:%d", len(seedData.Records))
+	if err := db.MarkSeedApplied(seedData.Name, checksum, count); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
 // SeedPlacement inserts placement test questions from seed file
 func (db *DB) SeedPlacement(seedFile string) (int, error) {
 	seedData, err := LoadSeedJSON(seedFile)
@@ -361,6 +440,11 @@ func (db *DB) RunAutoSeeding(seedsDir string) error {
 		// Determine seed type and apply
 		if strings.Contains(name, "vocab") {
 			count, err = db.SeedVocabulary(path)
+		} else if strings.Contains(name, "comparison") {
+			// Checked before "grammar": comparison seeds are named *_grammar_comparisons
+			count, err = db.SeedGrammarComparisons(path)
+		} else if strings.Contains(name, "prerequisite") {
+			count, err = db.SeedGrammarPrerequisites(path)
 		} else if strings.Contains(name, "grammar") {
 			count, err = db.SeedGrammar(path)
 		} else if strings.Contains(name, "placement") {
@@ -468,8 +552,18 @@ func (db *DB) SeedJLPT(seedFile string) (int, error) {
 
 	count := 0
 	for _, record := range seedData.Records {
-		// Check if this is a test or question by checking for test_id field
-		if _, isQuestion := record["test_id"]; isQuestion {
+		// Check if this is a test, a section or a question by its fields
+		_, hasQuestion := record["question"]
+		if _, isSection := record["test_id"]; isSection && !hasQuestion {
+			// This is a timed section of a test
+			err := db.insertJLPTSection(record)
+			if err != nil && !isDuplicateError(err, db.Driver) {
+				return count, fmt.Errorf("failed to insert JLPT section: %w", err)
+			}
+			if err == nil {
+				count++
+			}
+		} else if _, isQuestion := record["test_id"]; isQuestion {
 			// This is a question
 			err := db.insertJLPTQuestion(record)
 			if err != nil && !isDuplicateError(err, db.Driver) {
@@ -504,9 +598,25 @@ func (db *DB) insertJLPTTest(record map[string]interface{}) error {
 	return err
 }
 
+func (db *DB) insertJLPTSection(record map[string]interface{}) error {
+	query := `INSERT INTO jlpt_test_sections (test_id, section_num, name, time_limit_minutes) VALUES ($1, $2, $3, $4)`
+	_, err := db.Exec(query, record["test_id"], record["section_num"], record["name"], record["time_limit_minutes"])
+	return err
+}
+
 func (db *DB) insertJLPTQuestion(record map[string]interface{}) error {
-	optionsJSON, _ := json.Marshal(record["options"])
-	query := `INSERT INTO jlpt_questions (id, test_id, question_num, type, question, question_reading, english_prompt, options, correct_index, explanation, point_value, skill_tested) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
-	_, err := db.Exec(query, record["id"], record["test_id"], record["question_num"], record["type"], record["question"], record["question_reading"], record["english_prompt"], string(optionsJSON), record["correct_index"], record["explanation"], record["point_value"], record["skill_tested"])
+	// LoadSeedJSON keeps arrays as their JSON text
+	optionsJSON, ok := record["options"].(string)
+	if !ok {
+		encoded, _ := json.Marshal(record["options"])
+		optionsJSON = string(encoded)
+	}
+	// Questions of tests timed as a whole are all in its one section
+	sectionNum := record["section_num"]
+	if sectionNum == nil {
+		sectionNum = 1
+	}
+	query := `INSERT INTO jlpt_questions (id, test_id, question_num, type, question, question_reading, english_prompt, options, correct_index, explanation, point_value, skill_tested, section_num) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	_, err := db.Exec(query, record["id"], record["test_id"], record["question_num"], record["type"], record["question"], record["question_reading"], record["english_prompt"], optionsJSON, record["correct_index"], record["explanation"], record["point_value"], record["skill_tested"], sectionNum)
 	return err
 }
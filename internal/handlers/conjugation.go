@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/erwinwahyura/daily-kotoba/internal/middleware"
 	"github.com/erwinwahyura/daily-kotoba/internal/services"
@@ -40,6 +43,216 @@ func (h *ConjugationHandler) StartSession(c *gin.Context) {
 	})
 }
 
+// StartChainSession starts a drill on a chain of forms, e.g. forms=causative,passive,nai,ta
+func (h *ConjugationHandler) StartChainSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	var forms []string
+	for _, f := range strings.Split(c.DefaultQuery("forms", "causative,passive"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			forms = append(forms, f)
+		}
+	}
+	maxLevel := c.DefaultQuery("max_level", "N5")
+
+	response, err := h.service.StartChainSession(userID, forms, maxLevel)
+	if err != nil {
+		utils.SendError(c, 400, "Failed to start chain session", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Chain session started", gin.H{
+		"session":    response.Session,
+		"challenges": response.Challenges,
+		"progress":   response.Progress,
+		"form_info":  response.FormInfo,
+	})
+}
+
+// StartKeigoSession starts a keigo drill, e.g. form=sonkeigo or form=kenjougo_polite
+func (h *ConjugationHandler) StartKeigoSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	form := c.DefaultQuery("form", "sonkeigo")
+	maxLevel := c.DefaultQuery("max_level", "N2")
+
+	response, err := h.service.StartKeigoSession(userID, form, maxLevel)
+	if err != nil {
+		utils.SendError(c, 400, "Failed to start keigo session", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Keigo session started", gin.H{
+		"session":    response.Session,
+		"challenges": response.Challenges,
+		"progress":   response.Progress,
+		"form_info":  response.FormInfo,
+	})
+}
+
+// GetKeigoReference lists the keigo forms and the verbs with special keigo
+func (h *ConjugationHandler) GetKeigoReference(c *gin.Context) {
+	utils.SendSuccess(c, 200, "Keigo reference retrieved", h.service.GetKeigoReference())
+}
+
+// StartTimedSession starts a speed-run, e.g. form=te&duration=60
+func (h *ConjugationHandler) StartTimedSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	duration, err := strconv.Atoi(c.DefaultQuery("duration", "60"))
+	if err != nil {
+		utils.SendError(c, 400, "Invalid duration", err)
+		return
+	}
+	form := c.DefaultQuery("form", "te")
+	maxLevel := c.DefaultQuery("max_level", "N5")
+
+	response, err := h.service.StartTimedSession(userID, form, duration, maxLevel)
+	if err != nil {
+		utils.SendError(c, 400, "Failed to start timed session", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Timed session started", gin.H{
+		"session":    response.Session,
+		"challenges": response.Challenges,
+		"progress":   response.Progress,
+		"form_info":  response.FormInfo,
+	})
+}
+
+// GetSpeedRun returns the score of a timed session
+func (h *ConjugationHandler) GetSpeedRun(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	run, err := h.service.GetSpeedRun(userID, c.Param("id"))
+	if err != nil {
+		utils.SendError(c, 404, "Timed session not found", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Speed run retrieved", run)
+}
+
+// GetSpeed shows response-time medians, trends and personal bests per form
+func (h *ConjugationHandler) GetSpeed(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	report, err := h.service.GetSpeedReport(userID, c.Query("form"), days)
+	if err != nil {
+		utils.SendError(c, 500, "Failed to get speed stats", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Speed stats retrieved", report)
+}
+
+// ListSessions lists the user's sessions, optionally by status
+// (active, paused, completed, expired)
+func (h *ConjugationHandler) ListSessions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	sessions, err := h.service.ListSessions(userID, c.Query("status"), limit)
+	if err != nil {
+		utils.SendError(c, 400, "Failed to list sessions", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Sessions retrieved", sessions)
+}
+
+// GetSession returns a session with its remaining challenges
+func (h *ConjugationHandler) GetSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	response, err := h.service.GetSession(userID, c.Param("id"))
+	if err != nil {
+		utils.SendError(c, 404, "Session not found", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Session retrieved", gin.H{
+		"session":    response.Session,
+		"challenges": response.Challenges,
+		"progress":   response.Progress,
+		"form_info":  response.FormInfo,
+	})
+}
+
+// PauseSession pauses a practice session so it doesn't expire for a week
+func (h *ConjugationHandler) PauseSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	session, err := h.service.PauseSession(userID, c.Param("id"))
+	if err != nil {
+		utils.SendError(c, 400, "Failed to pause session", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Session paused", session)
+}
+
+// ResumeSession reopens a paused session where it was left
+func (h *ConjugationHandler) ResumeSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	response, err := h.service.ResumeSession(userID, c.Param("id"))
+	if err != nil {
+		utils.SendError(c, 400, "Failed to resume session", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Session resumed", gin.H{
+		"session":    response.Session,
+		"challenges": response.Challenges,
+		"progress":   response.Progress,
+		"form_info":  response.FormInfo,
+	})
+}
+
+// GetChains lists the commonly drilled form chains
+func (h *ConjugationHandler) GetChains(c *gin.Context) {
+	utils.SendSuccess(c, 200, "Conjugation chains retrieved", h.service.GetChains())
+}
+
 // SubmitAnswer checks user's conjugation answer
 func (h *ConjugationHandler) SubmitAnswer(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
@@ -49,10 +262,11 @@ func (h *ConjugationHandler) SubmitAnswer(c *gin.Context) {
 	}
 
 	var req struct {
-		SessionID   string `json:"session_id" binding:"required"`
-		ChallengeID string `json:"challenge_id" binding:"required"`
-		Answer      string `json:"answer" binding:"required"`
-		TimeSpentMs int    `json:"time_spent_ms"`
+		SessionID   string   `json:"session_id" binding:"required"`
+		ChallengeID string   `json:"challenge_id" binding:"required"`
+		Answer      string   `json:"answer" binding:"required"`
+		StepAnswers []string `json:"step_answers"`
+		TimeSpentMs int      `json:"time_spent_ms"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -60,7 +274,7 @@ func (h *ConjugationHandler) SubmitAnswer(c *gin.Context) {
 		return
 	}
 
-	response, err := h.service.SubmitAnswer(userID, req.SessionID, req.ChallengeID, req.Answer, req.TimeSpentMs)
+	response, err := h.service.SubmitAnswer(userID, req.SessionID, req.ChallengeID, req.Answer, req.StepAnswers, req.TimeSpentMs)
 	if err != nil {
 		utils.SendError(c, 500, "Failed to submit answer", err)
 		return
@@ -124,3 +338,41 @@ func (h *ConjugationHandler) StartWeakPointDrill(c *gin.Context) {
 		"form_info":  response.FormInfo,
 	})
 }
+
+// Conjugate shows every form of a verb or adjective
+func (h *ConjugationHandler) Conjugate(c *gin.Context) {
+	word := c.Query("word")
+	if word == "" {
+		utils.SendError(c, 400, "word is required", nil)
+		return
+	}
+
+	table, err := h.service.Conjugate(word, c.Query("reading"), c.Query("group"))
+	if err != nil {
+		utils.SendError(c, 400, "Failed to conjugate word", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Conjugation table generated", table)
+}
+
+// Analyze breaks an inflected word down into its dictionary form and conjugation chain
+func (h *ConjugationHandler) Analyze(c *gin.Context) {
+	word := c.Query("word")
+	if word == "" {
+		utils.SendError(c, 400, "word is required", nil)
+		return
+	}
+
+	result, err := h.service.Analyze(word)
+	if err != nil {
+		utils.SendError(c, 500, "Failed to analyze word", err)
+		return
+	}
+	if len(result.Candidates) == 0 {
+		utils.SendError(c, 404, "No conjugation found for word", nil)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Word analyzed", result)
+}
@@ -17,7 +17,8 @@ func NewGrammarHandler(grammarService *services.GrammarService) *GrammarHandler
 	return &GrammarHandler{grammarService: grammarService}
 }
 
-// GetDailyPattern returns the current grammar pattern for the user
+// GetDailyPattern returns the current grammar pattern for the user.
+// ?mode=curriculum serves the next unlocked pattern instead of following index order.
 func (h *GrammarHandler) GetDailyPattern(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
 	if !exists {
@@ -25,6 +26,11 @@ func (h *GrammarHandler) GetDailyPattern(c *gin.Context) {
 		return
 	}
 
+	if c.Query("mode") == "curriculum" {
+		h.GetCurriculumPattern(c)
+		return
+	}
+
 	response, err := h.grammarService.GetDailyPattern(userID)
 	if err != nil {
 		utils.SendError(c, 500, "Failed to get grammar pattern", err)
@@ -34,6 +40,40 @@ func (h *GrammarHandler) GetDailyPattern(c *gin.Context) {
 	utils.SendSuccess(c, 200, "Grammar pattern retrieved successfully", response)
 }
 
+// GetCurriculumPattern returns the next pattern whose prerequisites are learned
+func (h *GrammarHandler) GetCurriculumPattern(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	response, err := h.grammarService.GetCurriculumPattern(userID, c.Query("level"))
+	if err != nil {
+		utils.SendError(c, 404, "No curriculum pattern available", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Curriculum pattern retrieved successfully", response)
+}
+
+// GetCurriculumTree returns a level's patterns with their lock state and prerequisites
+func (h *GrammarHandler) GetCurriculumTree(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	tree, err := h.grammarService.GetCurriculumTree(userID, c.Query("level"))
+	if err != nil {
+		utils.SendError(c, 400, "Failed to get curriculum", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Curriculum retrieved successfully", tree)
+}
+
 // GetPatternByID returns a specific grammar pattern
 func (h *GrammarHandler) GetPatternByID(c *gin.Context) {
 	patternID := c.Param("id")
@@ -92,7 +132,7 @@ func (h *GrammarHandler) SkipPattern(c *gin.Context) {
 	}
 
 	var req struct {
-		Status string `json:"status" binding:"required,oneof=studied skipped"`
+		Status string `json:"status" binding:"required,oneof=studied learning known skipped"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.SendError(c, 400, "Invalid request body", err)
@@ -108,7 +148,89 @@ func (h *GrammarHandler) SkipPattern(c *gin.Context) {
 	utils.SendSuccess(c, 200, "Moved to next pattern successfully", nextPattern)
 }
 
-// GetComparisonPairs returns grammar patterns grouped for side-by-side comparison
+// GrammarStatusRequest sets a pattern's status
+type GrammarStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=learning known skipped"`
+}
+
+// SetPatternStatus marks a pattern without advancing the daily pattern
+func (h *GrammarHandler) SetPatternStatus(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	var req GrammarStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, 400, "Invalid request body", err)
+		return
+	}
+
+	response, err := h.grammarService.SetPatternStatus(userID, c.Param("id"), req.Status)
+	if err != nil {
+		utils.SendError(c, 400, "Failed to update pattern status", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Pattern status updated successfully", response)
+}
+
+// ResetPatternStatus forgets the user's status for a pattern
+func (h *GrammarHandler) ResetPatternStatus(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	response, err := h.grammarService.ResetPattern(userID, c.Param("id"))
+	if err != nil {
+		utils.SendError(c, 404, "Failed to reset pattern", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Pattern reset successfully", response)
+}
+
+// RevisitPattern puts a pattern back into learning
+func (h *GrammarHandler) RevisitPattern(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	response, err := h.grammarService.RevisitPattern(userID, c.Param("id"))
+	if err != nil {
+		utils.SendError(c, 404, "Failed to revisit pattern", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Pattern marked for revisiting", response)
+}
+
+// GetLearnedPatterns lists the user's marked patterns, filtered by level and status
+func (h *GrammarHandler) GetLearnedPatterns(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	response, err := h.grammarService.GetLearnedPatterns(userID, c.Query("level"), c.Query("status"), page, limit)
+	if err != nil {
+		utils.SendError(c, 400, "Failed to get learned patterns", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Learned patterns retrieved successfully", response)
+}
+
+// GetComparisonPairs returns the curated pattern comparisons for a level
 func (h *GrammarHandler) GetComparisonPairs(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
 	if !exists {
@@ -129,7 +251,7 @@ func (h *GrammarHandler) GetComparisonPairs(c *gin.Context) {
 	})
 }
 
-// ComparePatterns returns detailed comparison between two specific patterns
+// ComparePatterns returns the curated comparison between two specific patterns
 func (h *GrammarHandler) ComparePatterns(c *gin.Context) {
 	patternA := c.Query("a")
 	patternB := c.Query("b")
@@ -141,13 +263,58 @@ func (h *GrammarHandler) ComparePatterns(c *gin.Context) {
 
 	comparison, err := h.grammarService.ComparePatterns(patternA, patternB)
 	if err != nil {
-		utils.SendError(c, 500, "Failed to compare patterns", err)
+		utils.SendError(c, 404, "No comparison available for these patterns", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Comparison retrieved successfully", comparison)
+}
+
+// GetComparison returns one curated comparison by ID
+func (h *GrammarHandler) GetComparison(c *gin.Context) {
+	comparison, err := h.grammarService.GetComparison(c.Param("id"))
+	if err != nil {
+		utils.SendError(c, 404, "Comparison not found", err)
 		return
 	}
 
 	utils.SendSuccess(c, 200, "Comparison retrieved successfully", comparison)
 }
 
+// GetComparisonQuiz returns "which pattern fits this context?" questions
+func (h *GrammarHandler) GetComparisonQuiz(c *gin.Context) {
+	quiz, err := h.grammarService.GetComparisonQuiz(c.Param("id"))
+	if err != nil {
+		utils.SendError(c, 404, "Comparison quiz not found", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Comparison quiz retrieved successfully", quiz)
+}
+
+// ComparisonQuizAnswerRequest is an answer to one comparison quiz question
+type ComparisonQuizAnswerRequest struct {
+	Index  *int   `json:"index" binding:"required,min=0"`
+	Answer string `json:"answer" binding:"required"` // A, B, or the pattern text
+}
+
+// SubmitComparisonAnswer checks an answer to a comparison quiz question
+func (h *GrammarHandler) SubmitComparisonAnswer(c *gin.Context) {
+	var req ComparisonQuizAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, 400, "Invalid request", err)
+		return
+	}
+
+	result, err := h.grammarService.CheckComparisonAnswer(c.Param("id"), *req.Index, req.Answer)
+	if err != nil {
+		utils.SendError(c, 400, "Failed to check answer", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Answer checked", result)
+}
+
 // SearchGrammar searches grammar patterns by query
 func (h *GrammarHandler) SearchGrammar(c *gin.Context) {
 	query := c.Query("q")
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/services"
+	"github.com/erwinwahyura/daily-kotoba/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GrammarDetectHandler handles grammar pattern detection requests
+type GrammarDetectHandler struct {
+	detector *services.GrammarDetector
+}
+
+// NewGrammarDetectHandler creates a new handler
+func NewGrammarDetectHandler(detector *services.GrammarDetector) *GrammarDetectHandler {
+	return &GrammarDetectHandler{
+		detector: detector,
+	}
+}
+
+// DetectGrammarRequest is a piece of text to analyze
+type DetectGrammarRequest struct {
+	Text  string `json:"text" binding:"required,max=2000"`
+	Level string `json:"level" binding:"omitempty,oneof=N5 N4 N3 N2 N1"`
+}
+
+// Detect finds the grammar patterns used in a sentence or passage
+func (h *GrammarDetectHandler) Detect(c *gin.Context) {
+	var req DetectGrammarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	result, err := h.detector.Analyze(req.Text, req.Level)
+	if err != nil {
+		utils.SendError(c, http.StatusInternalServerError, "Failed to detect grammar", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Grammar detected", result)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/middleware"
+	"github.com/erwinwahyura/daily-kotoba/internal/services"
+	"github.com/erwinwahyura/daily-kotoba/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GrammarDrillHandler handles grammar cloze drill requests
+type GrammarDrillHandler struct {
+	service *services.GrammarDrillService
+}
+
+// NewGrammarDrillHandler creates a new handler
+func NewGrammarDrillHandler(service *services.GrammarDrillService) *GrammarDrillHandler {
+	return &GrammarDrillHandler{
+		service: service,
+	}
+}
+
+// GetDrill returns a batch of cloze questions
+// Query: pattern_id, level, mode=typed|choice, count, weak=true
+func (h *GrammarDrillHandler) GetDrill(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	count, _ := strconv.Atoi(c.Query("count"))
+	weakOnly := c.Query("weak") == "true"
+
+	drill, err := h.service.GetDrill(userID, c.Query("pattern_id"), c.Query("level"), c.Query("mode"), count, weakOnly)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to build grammar drill", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Grammar drill generated", drill)
+}
+
+// GrammarDrillAnswerRequest represents a cloze answer submission
+type GrammarDrillAnswerRequest struct {
+	PatternID    string `json:"pattern_id" binding:"required"`
+	ExampleIndex int    `json:"example_index" binding:"min=0"`
+	Mode         string `json:"mode" binding:"omitempty,oneof=typed choice"`
+	Answer       string `json:"answer" binding:"required"`
+}
+
+// SubmitAnswer checks a cloze answer
+func (h *GrammarDrillHandler) SubmitAnswer(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req GrammarDrillAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	result, err := h.service.SubmitAnswer(userID, req.PatternID, req.ExampleIndex, req.Mode, req.Answer)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to submit answer", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Answer submitted", result)
+}
+
+// GetWeakPoints returns grammar patterns with low drill accuracy
+func (h *GrammarDrillHandler) GetWeakPoints(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	analysis, err := h.service.GetWeakPoints(userID)
+	if err != nil {
+		utils.SendError(c, http.StatusInternalServerError, "Failed to get weak points", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Weak points retrieved", analysis)
+}
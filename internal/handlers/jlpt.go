@@ -53,7 +53,7 @@ func (h *JLPTHandler) StartTest(c *gin.Context) {
 		return
 	}
 
-	session, questions, err := h.jlptService.StartTest(userID, req.Level, req.Section)
+	session, questions, sections, err := h.jlptService.StartTest(userID, req.Level, req.Section)
 	if err != nil {
 		utils.SendError(c, 500, "Failed to start test", err)
 		return
@@ -62,17 +62,18 @@ func (h *JLPTHandler) StartTest(c *gin.Context) {
 	utils.SendSuccess(c, 200, "Test started", gin.H{
 		"session":   session,
 		"questions": questions,
+		"sections":  sections,
 	})
 }
 
-// SubmitAnswer records an answer during a test
+// SubmitAnswer records an answer during a test; answers after the time is
+// up, or to a section that is over, are rejected
 func (h *JLPTHandler) SubmitAnswer(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
 	if !exists {
 		utils.SendError(c, 401, "User not authenticated", nil)
 		return
 	}
-	_ = userID
 
 	var req models.SubmitAnswerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -80,8 +81,8 @@ func (h *JLPTHandler) SubmitAnswer(c *gin.Context) {
 		return
 	}
 
-	if err := h.jlptService.SubmitAnswer(req.SessionID, req.QuestionID, req.AnswerIndex); err != nil {
-		utils.SendError(c, 500, "Failed to submit answer", err)
+	if err := h.jlptService.SubmitAnswer(userID, req.SessionID, req.QuestionID, req.AnswerIndex); err != nil {
+		utils.SendError(c, 400, "Failed to submit answer", err)
 		return
 	}
 
@@ -95,7 +96,6 @@ func (h *JLPTHandler) CompleteTest(c *gin.Context) {
 		utils.SendError(c, 401, "User not authenticated", nil)
 		return
 	}
-	_ = userID
 
 	sessionID := c.Param("session_id")
 	if sessionID == "" {
@@ -111,9 +111,9 @@ func (h *JLPTHandler) CompleteTest(c *gin.Context) {
 		req.Answers = nil
 	}
 
-	result, err := h.jlptService.CompleteTest(sessionID, req.Answers)
+	result, err := h.jlptService.CompleteTest(userID, sessionID, req.Answers)
 	if err != nil {
-		utils.SendError(c, 500, "Failed to complete test", err)
+		utils.SendError(c, 400, "Failed to complete test", err)
 		return
 	}
 
@@ -137,23 +137,42 @@ func (h *JLPTHandler) GetHistory(c *gin.Context) {
 	utils.SendSuccess(c, 200, "History retrieved", gin.H{"history": history})
 }
 
-// GetProgress returns current test progress
+// GetProgress returns current test progress and the time left
 func (h *JLPTHandler) GetProgress(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
 	sessionID := c.Param("session_id")
 	if sessionID == "" {
 		utils.SendError(c, 400, "Session ID is required", nil)
 		return
 	}
 
-	answered, total, err := h.jlptService.GetTestProgress(sessionID)
+	progress, err := h.jlptService.GetTestProgress(userID, sessionID)
 	if err != nil {
-		utils.SendError(c, 500, "Failed to get progress", err)
+		utils.SendError(c, 404, "Failed to get progress", err)
 		return
 	}
 
-	utils.SendSuccess(c, 200, "Progress retrieved", gin.H{
-		"answered": answered,
-		"total":    total,
-		"remaining": total - answered,
-	})
+	utils.SendSuccess(c, 200, "Progress retrieved", progress)
+}
+
+// NextSection ends the current section early and starts the next
+func (h *JLPTHandler) NextSection(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, 401, "User not authenticated", nil)
+		return
+	}
+
+	progress, err := h.jlptService.NextSection(userID, c.Param("session_id"))
+	if err != nil {
+		utils.SendError(c, 400, "Failed to start next section", err)
+		return
+	}
+
+	utils.SendSuccess(c, 200, "Next section started", progress)
 }
\ No newline at end of file
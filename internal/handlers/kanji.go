@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/erwinwahyura/daily-kotoba/internal/middleware"
 	"github.com/erwinwahyura/daily-kotoba/internal/models"
@@ -43,6 +46,7 @@ func (h *KanjiHandler) GetKanjiByCharacter(c *gin.Context) {
 		"jlpt_level":   kanji.JLPTLevel,
 		"meaning":      kanji.Meaning,
 		"readings":     kanji.Readings,
+		"on_readings":  kanji.OnReadings,
 		"stroke_count": kanji.StrokeCount,
 	}
 
@@ -87,6 +91,157 @@ func (h *KanjiHandler) GetKanjiByLevel(c *gin.Context) {
 	})
 }
 
+// GetRadicals lists the radicals kanji can be searched by
+func (h *KanjiHandler) GetRadicals(c *gin.Context) {
+	radicals, err := h.service.GetRadicals()
+	if err != nil {
+		utils.SendError(c, http.StatusInternalServerError, "Failed to retrieve radicals", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Radicals retrieved", gin.H{"radicals": radicals})
+}
+
+// SearchByComponents finds kanji by components (comma-separated) and stroke count
+func (h *KanjiHandler) SearchByComponents(c *gin.Context) {
+	var components []string
+	for _, part := range strings.Split(c.Query("components"), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			components = append(components, part)
+		}
+	}
+	minStrokes, _ := strconv.Atoi(c.Query("min_strokes"))
+	maxStrokes, _ := strconv.Atoi(c.Query("max_strokes"))
+	if strokes, err := strconv.Atoi(c.Query("strokes")); err == nil {
+		minStrokes, maxStrokes = strokes, strokes
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	result, err := h.service.SearchByComponents(components, minStrokes, maxStrokes, limit)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to search kanji", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Kanji found", result)
+}
+
+// GetComponentTree returns a kanji broken down into its components
+func (h *KanjiHandler) GetComponentTree(c *gin.Context) {
+	tree, err := h.service.GetComponentTree(c.Param("char"))
+	if err != nil {
+		utils.SendError(c, http.StatusNotFound, "Components not found", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Components retrieved", tree)
+}
+
+// GetRelatedKanji lists kanji sharing components with a kanji
+func (h *KanjiHandler) GetRelatedKanji(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	related, err := h.service.GetRelatedKanji(c.Param("char"), c.Query("component"), limit)
+	if err != nil {
+		utils.SendError(c, http.StatusNotFound, "Components not found", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Related kanji retrieved", gin.H{"kanji": related})
+}
+
+// GetKanjiVocabulary lists the words using a kanji, grouped by reading.
+// level caps the JLPT level (default the user's, "all" for every level);
+// known is all, only or exclude.
+func (h *KanjiHandler) GetKanjiVocabulary(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if _, err := h.service.GetKanjiByCharacter(c.Param("char")); err != nil {
+		utils.SendError(c, http.StatusNotFound, "Kanji not found", err)
+		return
+	}
+
+	breakdown, err := h.service.GetKanjiVocabulary(userID, c.Param("char"), c.Query("level"), c.Query("known"))
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to retrieve vocabulary", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Vocabulary retrieved", breakdown)
+}
+
+// svgCacheAge is how long clients may keep a rendered kanji (one day); the
+// stroke data only changes on import
+const svgCacheAge = "public, max-age=86400"
+
+// sheetCacheAge keeps practice sheets, which need signing in, out of
+// shared caches
+const sheetCacheAge = "private, max-age=86400"
+
+// GetKanjiSVG renders a kanji's stroke order as SVG, static and numbered or
+// animated stroke by stroke
+func (h *KanjiHandler) GetKanjiSVG(c *gin.Context) {
+	if _, err := h.service.GetKanjiByCharacter(c.Param("char")); err != nil {
+		utils.SendError(c, http.StatusNotFound, "Kanji not found", err)
+		return
+	}
+
+	size, _ := strconv.Atoi(c.Query("size"))
+	speed, _ := strconv.ParseFloat(c.Query("speed"), 64)
+	opts := models.KanjiSVGOptions{
+		Style:   c.Query("style"),
+		Size:    size,
+		Numbers: c.Query("numbers") != "false",
+		Grid:    c.Query("grid") == "true",
+		Loop:    c.Query("loop") == "true",
+		Speed:   speed,
+	}
+
+	svg, err := h.service.RenderKanjiSVG(c.Param("char"), opts)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to render kanji", err)
+		return
+	}
+
+	c.Header("Cache-Control", svgCacheAge)
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(svg))
+}
+
+// GetPracticeSheet renders a printable practice sheet for several kanji
+func (h *KanjiHandler) GetPracticeSheet(c *gin.Context) {
+	var chars []string
+	for _, r := range c.Query("chars") {
+		if !unicode.IsSpace(r) && r != ',' && r != '、' {
+			chars = append(chars, string(r))
+		}
+	}
+	columns, _ := strconv.Atoi(c.Query("columns"))
+	guides, err := strconv.Atoi(c.Query("guides"))
+	if err != nil {
+		guides = -1
+	}
+	cellSize, _ := strconv.Atoi(c.Query("cell_size"))
+
+	svg, err := h.service.RenderPracticeSheet(models.KanjiSheetOptions{
+		Characters: chars,
+		Columns:    columns,
+		Steps:      c.Query("steps") != "false",
+		Guides:     guides,
+		CellSize:   cellSize,
+	})
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to render practice sheet", err)
+		return
+	}
+
+	c.Header("Cache-Control", sheetCacheAge)
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(svg))
+}
+
 // StartPracticeRequest represents a practice session start request
 type StartPracticeRequest struct {
 	KanjiChar string `json:"kanji_char" binding:"required"`
@@ -123,19 +278,25 @@ func (h *KanjiHandler) StartPracticeSession(c *gin.Context) {
 // CompareStrokeRequest represents a stroke comparison request
 type CompareStrokeRequest struct {
 	SessionID string           `json:"session_id" binding:"required"`
-	StrokeNum int              `json:"stroke_num" binding:"required,min=1"`
+	StrokeNum int              `json:"stroke_num" binding:"omitempty,min=1"` // Ignored: the stroke drawn is inferred from the path
 	Path      []models.Point   `json:"path" binding:"required"`
 }
 
 // CompareStroke compares user's stroke with reference
 func (h *KanjiHandler) CompareStroke(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
 	var req CompareStrokeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.SendError(c, http.StatusBadRequest, "Invalid request", err)
 		return
 	}
 
-	result, err := h.service.CompareStroke(req.SessionID, req.StrokeNum, req.Path)
+	result, err := h.service.CompareStroke(userID, req.SessionID, req.Path)
 	if err != nil {
 		utils.SendError(c, http.StatusInternalServerError, "Failed to compare stroke", err)
 		return
@@ -144,6 +305,85 @@ func (h *KanjiHandler) CompareStroke(c *gin.Context) {
 	utils.SendSuccess(c, http.StatusOK, "Stroke compared", result)
 }
 
+// RecognizeRequest represents a handwriting recognition request
+type RecognizeRequest struct {
+	Strokes [][]models.Point `json:"strokes" binding:"required,min=1"`
+	Limit   int              `json:"limit"`
+}
+
+// Recognize ranks the kanji a set of drawn strokes could be
+func (h *KanjiHandler) Recognize(c *gin.Context) {
+	var req RecognizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	result, err := h.service.RecognizeKanji(req.Strokes, req.Limit)
+	if err != nil {
+		if err.Error() == "no strokes to recognise" {
+			utils.SendError(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		utils.SendError(c, http.StatusInternalServerError, "Failed to recognise kanji", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Kanji recognised", result)
+}
+
+// GetWriteQuiz starts a write-from-memory quiz, prompting with a meaning or
+// a word that uses the kanji
+func (h *KanjiHandler) GetWriteQuiz(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	kanjiChar := c.Query("kanji")
+	level := c.Query("level")
+	if kanjiChar == "" && level == "" {
+		level = "N5"
+	}
+	prompt := c.Query("prompt")
+	if prompt != "" && prompt != models.KanjiPromptMeaning && prompt != models.KanjiPromptVocabulary {
+		utils.SendError(c, http.StatusBadRequest, "prompt must be meaning or vocabulary", nil)
+		return
+	}
+
+	quiz, err := h.service.GetWriteQuiz(userID, kanjiChar, level, prompt)
+	if err != nil {
+		utils.SendError(c, http.StatusNotFound, "No kanji available for this quiz", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Write quiz retrieved", quiz)
+}
+
+// CheckWriteQuiz grades a kanji written from memory and updates its SRS schedule
+func (h *KanjiHandler) CheckWriteQuiz(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.KanjiRecallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	result, err := h.service.CheckWriteQuiz(userID, req.SessionID, req.Strokes)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to check answer", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Answer checked", result)
+}
+
 // GetPracticeSession retrieves practice session details
 func (h *KanjiHandler) GetPracticeSession(c *gin.Context) {
 	sessionID := c.Param("id")
@@ -165,14 +405,59 @@ func (h *KanjiHandler) GetPracticeSession(c *gin.Context) {
 		return
 	}
 
-	utils.SendSuccess(c, http.StatusOK, "Session retrieved", gin.H{
+	response := gin.H{
 		"session_id":   session.ID,
 		"kanji_char":   session.KanjiChar,
+		"mode":         session.Mode,
 		"strokes":      len(session.UserStrokes),
 		"accuracy":     session.Accuracy,
 		"status":       session.Status,
 		"completed_at": session.CompletedAt,
-	})
+	}
+	if session.Mode == models.KanjiModeRecall {
+		response["prompt"] = session.Prompt
+		response["recall_result"] = session.RecallResult
+		if session.Status != "completed" {
+			delete(response, "kanji_char")
+		}
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Session retrieved", response)
+}
+
+// GetStrokeReport returns the stroke-by-stroke error report of a trace session
+func (h *KanjiHandler) GetStrokeReport(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	report, err := h.service.GetStrokeReport(userID, c.Param("id"))
+	if err != nil {
+		utils.SendError(c, http.StatusNotFound, "Failed to build stroke report", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Stroke report retrieved", report)
+}
+
+// GetStrokeErrorStats returns the stroke errors a user makes and the
+// stroke-order rules they break most
+func (h *KanjiHandler) GetStrokeErrorStats(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	stats, err := h.service.GetStrokeErrorStats(userID)
+	if err != nil {
+		utils.SendError(c, http.StatusInternalServerError, "Failed to retrieve stroke error stats", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Stroke error stats retrieved", stats)
 }
 
 // GetUserStats returns user's kanji practice statistics
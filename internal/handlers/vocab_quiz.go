@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/middleware"
+	"github.com/erwinwahyura/daily-kotoba/internal/services"
+	"github.com/erwinwahyura/daily-kotoba/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// VocabQuizHandler handles multiple-choice vocabulary quiz requests
+type VocabQuizHandler struct {
+	service *services.VocabQuizService
+}
+
+// NewVocabQuizHandler creates a new handler
+func NewVocabQuizHandler(service *services.VocabQuizService) *VocabQuizHandler {
+	return &VocabQuizHandler{
+		service: service,
+	}
+}
+
+// StartVocabQuizRequest represents a quiz generation request
+type StartVocabQuizRequest struct {
+	Deck  string   `json:"deck" binding:"omitempty,oneof=level srs"`
+	Level string   `json:"level" binding:"omitempty,oneof=N5 N4 N3 N2 N1"`
+	Count int      `json:"count" binding:"omitempty,min=1,max=50"`
+	Types []string `json:"types" binding:"omitempty,dive,oneof=meaning_to_word word_to_meaning reading"`
+}
+
+// StartQuiz generates a new quiz
+func (h *VocabQuizHandler) StartQuiz(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req StartVocabQuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	quiz, err := h.service.StartQuiz(userID, req.Deck, req.Level, req.Count, req.Types)
+	if err != nil {
+		utils.SendError(c, http.StatusInternalServerError, "Failed to start quiz", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Quiz started", quiz)
+}
+
+// SubmitVocabQuizAnswerRequest represents an answer submission
+type SubmitVocabQuizAnswerRequest struct {
+	SessionID  string `json:"session_id" binding:"required"`
+	QuestionID string `json:"question_id" binding:"required"`
+	Answer     *int   `json:"answer" binding:"required,min=0"` // Selected option index
+}
+
+// SubmitAnswer grades an answer
+func (h *VocabQuizHandler) SubmitAnswer(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req SubmitVocabQuizAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	result, err := h.service.SubmitAnswer(userID, req.SessionID, req.QuestionID, *req.Answer)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to submit answer", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Answer submitted", result)
+}
+
+// GetSession returns quiz progress and answers
+func (h *VocabQuizHandler) GetSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	session, err := h.service.GetSession(userID, c.Param("id"))
+	if err != nil {
+		utils.SendError(c, http.StatusNotFound, "Quiz session not found", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Quiz session retrieved", session)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/middleware"
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"github.com/erwinwahyura/daily-kotoba/internal/services"
+	"github.com/erwinwahyura/daily-kotoba/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// WidgetHandler serves the home-screen widget feed and widget tokens
+type WidgetHandler struct {
+	service     *services.WidgetService
+	authService *services.AuthService
+}
+
+// NewWidgetHandler creates a new handler
+func NewWidgetHandler(service *services.WidgetService, authService *services.AuthService) *WidgetHandler {
+	return &WidgetHandler{
+		service:     service,
+		authService: authService,
+	}
+}
+
+// CreateToken issues a long-lived, widget-scoped token for the current user
+func (h *WidgetHandler) CreateToken(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	token, expiresAt, err := h.authService.GenerateWidgetToken(userID)
+	if err != nil {
+		utils.SendError(c, http.StatusInternalServerError, "Failed to create widget token", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusCreated, "Widget token created", models.WidgetTokenResponse{
+		Token:     token,
+		Scope:     services.WidgetTokenScope,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// RevokeTokens revokes every widget token of the current user, for a lost
+// device or a leaked token
+func (h *WidgetHandler) RevokeTokens(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	revoked, err := h.authService.RevokeWidgetTokens(userID)
+	if err != nil {
+		utils.SendError(c, http.StatusInternalServerError, "Failed to revoke widget tokens", err)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Widget tokens revoked", gin.H{"revoked": revoked})
+}
+
+// GetFeed returns the widget timeline
+// Query: size=small|medium|large, count=1-24, interval=minutes between items
+func (h *WidgetHandler) GetFeed(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.SendError(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	count, _ := strconv.Atoi(c.Query("count"))
+	interval, _ := strconv.Atoi(c.Query("interval"))
+
+	feed, err := h.service.GetFeed(userID, c.Query("size"), count, interval)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to get widget feed", err)
+		return
+	}
+
+	payload, err := json.Marshal(feed)
+	if err != nil {
+		utils.SendError(c, http.StatusInternalServerError, "Failed to encode widget feed", err)
+		return
+	}
+	sum := sha256.Sum256(payload)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	// Cache until the next slot begins; the timeline doesn't change before then
+	// unless the user studies, which the ETag revalidation picks up.
+	nextSlot := feed.GeneratedAt.Add(time.Duration(feed.IntervalMinutes) * time.Minute)
+	maxAge := int(time.Until(nextSlot).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+
+	if match := c.GetHeader("If-None-Match"); match != "" && match == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	utils.SendSuccess(c, http.StatusOK, "Widget feed retrieved", feed)
+}
@@ -36,6 +36,13 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		// Widget tokens are long-lived and read-only; keep them off the full API
+		if claims.Scope == services.WidgetTokenScope {
+			utils.SendError(c, 403, "Widget token cannot access this resource", nil)
+			c.Abort()
+			return
+		}
+
 		// Set user ID in context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
@@ -44,6 +51,40 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 	}
 }
 
+// WidgetAuthMiddleware accepts widget-scoped tokens from the Authorization
+// header. Tokens aren't taken from the query string, where they'd end up in
+// access logs and Referer headers. Regular session tokens are accepted too so
+// the app can preview the feed.
+func WidgetAuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			utils.SendError(c, 401, "Widget token required", nil)
+			c.Abort()
+			return
+		}
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			utils.SendError(c, 401, "Invalid authorization header format", nil)
+			c.Abort()
+			return
+		}
+		tokenString := parts[1]
+
+		claims, err := authService.ValidateToken(tokenString)
+		if err != nil {
+			utils.SendError(c, 401, "Invalid or expired token", err)
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("user_email", claims.Email)
+
+		c.Next()
+	}
+}
+
 func GetUserID(c *gin.Context) (string, bool) {
 	userID, exists := c.Get("user_id")
 	if !exists {
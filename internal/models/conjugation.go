@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // ConjugationChallenge represents a conjugation drill exercise
 type ConjugationChallenge struct {
@@ -16,6 +19,7 @@ type ConjugationChallenge struct {
 	JLPTLevel     string                `json:"jlpt_level" db:"jlpt_level"`
 	Category      string                `json:"category" db:"category"`           // verb, adjective, copula
 	CreatedAt     time.Time             `json:"created_at" db:"created_at"`
+	Steps         []ConjugationChainStep `json:"steps,omitempty" db:"-"`          // Chained challenges only
 }
 
 // ConjugationFormType represents different conjugation forms
@@ -48,6 +52,148 @@ func GetConjugationForms() []ConjugationFormType {
 	}
 }
 
+// ConjugationChainSeparator joins the forms of a chained drill into its target
+// form: causative>passive>nai>ta is the causative-passive negative past
+const ConjugationChainSeparator = ">"
+
+// ConjugationChainKey is the target form of a drill chaining forms in order
+func ConjugationChainKey(forms []string) string {
+	return strings.Join(forms, ConjugationChainSeparator)
+}
+
+// ConjugationChainForms splits a chained target form into its forms, or returns
+// nil for a single form
+func ConjugationChainForms(targetForm string) []string {
+	if !strings.Contains(targetForm, ConjugationChainSeparator) {
+		return nil
+	}
+	return strings.Split(targetForm, ConjugationChainSeparator)
+}
+
+// ConjugationChainType is a commonly drilled stack of forms
+type ConjugationChainType struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name"`
+	Description string   `json:"description"`
+	Level       string   `json:"level"`
+	Forms       []string `json:"forms"` // Applied in order to the dictionary form
+}
+
+func GetConjugationChains() []ConjugationChainType {
+	return []ConjugationChainType{
+		{Name: "nai_te", DisplayName: "なくて", Description: "Negative connective (not doing, and)", Level: "N4", Forms: []string{"nai", "te"}},
+		{Name: "nai_conditional", DisplayName: "なければ", Description: "Negative conditional (if not / must)", Level: "N4", Forms: []string{"nai", "conditional"}},
+		{Name: "potential_te", DisplayName: "可能形 + て形", Description: "Potential connective (can do, and)", Level: "N3", Forms: []string{"potential", "te"}},
+		{Name: "potential_polite_negative", DisplayName: "可能形 + ません", Description: "Polite cannot", Level: "N3", Forms: []string{"potential", "polite_negative"}},
+		{Name: "potential_nai_ta", DisplayName: "可能形 + なかった", Description: "Could not", Level: "N3", Forms: []string{"potential", "nai", "ta"}},
+		{Name: "passive_ta", DisplayName: "受身形 + た形", Description: "Was done", Level: "N3", Forms: []string{"passive", "ta"}},
+		{Name: "causative_te", DisplayName: "使役形 + て形", Description: "Make/let do, and", Level: "N3", Forms: []string{"causative", "te"}},
+		{Name: "causative_passive", DisplayName: "使役受身形", Description: "Be made to do", Level: "N3", Forms: []string{"causative", "passive"}},
+		{Name: "causative_passive_ta", DisplayName: "使役受身形 + た形", Description: "Was made to do", Level: "N3", Forms: []string{"causative", "passive", "ta"}},
+		{Name: "causative_passive_nai_ta", DisplayName: "使役受身形 + なかった", Description: "Wasn't made to do", Level: "N2", Forms: []string{"causative", "passive", "nai", "ta"}},
+	}
+}
+
+// Conjugation groups a word can belong to
+const (
+	ConjugationGodan       = "godan"     // Group 1
+	ConjugationIchidan     = "ichidan"   // Group 2
+	ConjugationIrregular   = "irregular" // する, 来る
+	ConjugationIAdjective  = "i-adjective"
+	ConjugationNaAdjective = "na-adjective"
+	ConjugationCopula      = "copula" // だ, です
+)
+
+// Conjugation tracks: families of forms mastered and unlocked on their own
+const (
+	ConjugationTrackVerb      = "verb"
+	ConjugationTrackAdjective = "adjective" // い- and な-adjectives and the copula
+	ConjugationTrackKeigo     = "keigo"
+)
+
+// GetAdjectiveForms lists the forms drilled for adjectives and the copula, in
+// learning order. They are tracked apart from the verb forms of the same name
+// (高かった is adj_past, not ta).
+func GetAdjectiveForms() []ConjugationFormType {
+	return []ConjugationFormType{
+		{Name: "adj_negative", DisplayName: "否定形 (くない/ではない)", Description: "Adjective negative (is not)", Level: "N5", Order: 21},
+		{Name: "adj_past", DisplayName: "過去形 (かった/だった)", Description: "Adjective past (was)", Level: "N5", Order: 22},
+		{Name: "adj_negative_past", DisplayName: "過去否定形 (くなかった/ではなかった)", Description: "Adjective negative past (was not)", Level: "N5", Order: 23},
+		{Name: "adj_te", DisplayName: "て形 (くて/で)", Description: "Adjective connective (is ..., and)", Level: "N5", Order: 24},
+		{Name: "adj_adverbial", DisplayName: "連用形 (く/に)", Description: "Adverbial (quickly, quietly)", Level: "N4", Order: 25},
+		{Name: "adj_conditional", DisplayName: "仮定形 (ければ/なら)", Description: "Adjective conditional (if it is)", Level: "N4", Order: 26},
+		{Name: "adj_nominal", DisplayName: "名詞形 (さ)", Description: "Noun of degree (height, quietness)", Level: "N3", Order: 27},
+	}
+}
+
+// ConjugationFormTrack names the track a form (or chain) belongs to
+func ConjugationFormTrack(form string) string {
+	if strings.HasPrefix(form, "adj_") {
+		return ConjugationTrackAdjective
+	}
+	for _, f := range GetKeigoForms() {
+		if f.Name == form {
+			return ConjugationTrackKeigo
+		}
+	}
+	return ConjugationTrackVerb
+}
+
+// GetPoliteVariantForms lists the polite forms generated alongside the drill forms
+func GetPoliteVariantForms() []ConjugationFormType {
+	return []ConjugationFormType{
+		{Name: "polite_negative", DisplayName: "ません形", Description: "Polite negative", Level: "N5", Order: 12},
+		{Name: "polite_past", DisplayName: "ました形", Description: "Polite past", Level: "N5", Order: 13},
+		{Name: "polite_past_negative", DisplayName: "ませんでした形", Description: "Polite negative past", Level: "N5", Order: 14},
+		{Name: "polite_volitional", DisplayName: "ましょう形", Description: "Polite volitional (let's)", Level: "N5", Order: 15},
+	}
+}
+
+// GetKeigoForms lists the keigo conversions drilled for verbs. "plain" goes the
+// other way, from a special keigo verb back to its everyday verb.
+func GetKeigoForms() []ConjugationFormType {
+	return []ConjugationFormType{
+		{Name: "sonkeigo", DisplayName: "尊敬語", Description: "Honorific (raises the other person's actions)", Level: "N2", Order: 16},
+		{Name: "sonkeigo_polite", DisplayName: "尊敬語 (ます)", Description: "Honorific, polite ending", Level: "N2", Order: 17},
+		{Name: "kenjougo", DisplayName: "謙譲語", Description: "Humble (lowers your own actions)", Level: "N2", Order: 18},
+		{Name: "kenjougo_polite", DisplayName: "謙譲語 (ます)", Description: "Humble, polite ending", Level: "N2", Order: 19},
+		{Name: "plain", DisplayName: "普通形", Description: "Everyday verb behind a keigo verb", Level: "N2", Order: 20},
+	}
+}
+
+// KeigoVerb lists the special keigo verbs that replace an everyday verb.
+// Verbs without an entry for a direction use お〜になる / お〜する.
+type KeigoVerb struct {
+	Plain    string   `json:"plain"`
+	Reading  string   `json:"reading"`
+	Sonkeigo []string `json:"sonkeigo,omitempty"`
+	Kenjougo []string `json:"kenjougo,omitempty"`
+}
+
+// KeigoReference is the keigo drill overview: forms and special verbs
+type KeigoReference struct {
+	Forms        []ConjugationFormType `json:"forms"`
+	SpecialVerbs []KeigoVerb           `json:"special_verbs"`
+}
+
+// ConjugatedForm is one form produced by the conjugation engine
+type ConjugatedForm struct {
+	Form         string   `json:"form"` // Matches ConjugationFormType.Name
+	Surface      string   `json:"surface"`
+	Reading      string   `json:"reading"`
+	Alternatives []string `json:"alternatives,omitempty"` // Equally correct spellings (じゃない for ではない)
+	Hint         string   `json:"hint"`
+}
+
+// ConjugationTable is every generated form of one verb or adjective
+type ConjugationTable struct {
+	BaseForm string           `json:"base_form"`
+	Reading  string           `json:"reading"`
+	Group    string           `json:"group"`
+	Category string           `json:"category"` // verb, adjective, copula
+	Forms    []ConjugatedForm `json:"forms"`
+}
+
 // ConjugationSession tracks a user's drill session
 type ConjugationSession struct {
 	ID              string    `json:"id" db:"id"`
@@ -64,8 +210,29 @@ type ConjugationSession struct {
 	CompletedForms  []string  `json:"completed_forms" db:"completed_forms"` // JSON array
 	IsWeakPointDrill bool     `json:"is_weak_point_drill,omitempty" db:"is_weak_point_drill"`
 	TargetWeakForm   string    `json:"target_weak_form,omitempty" db:"target_weak_form"`
+	Mode             string     `json:"mode" db:"mode"`                                 // practice or timed
+	TimeLimitSec     int        `json:"time_limit_sec,omitempty" db:"time_limit_sec"` // Timed mode only
+	EndsAt           *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	Status           string     `json:"status" db:"status"`                         // active, paused, completed or expired
+	ChallengeIDs     []string   `json:"challenge_ids,omitempty" db:"challenge_ids"` // JSON array, in the order they are drilled
+	MaxLevel         string     `json:"max_level,omitempty" db:"max_level"`
+	PausedAt         *time.Time `json:"paused_at,omitempty" db:"paused_at"`
 }
 
+// Conjugation session statuses
+const (
+	ConjugationSessionActive    = "active"
+	ConjugationSessionPaused    = "paused"
+	ConjugationSessionCompleted = "completed"
+	ConjugationSessionExpired   = "expired" // Left inactive too long
+)
+
+// Conjugation session modes
+const (
+	ConjugationModePractice = "practice"
+	ConjugationModeTimed    = "timed" // As many as possible before the clock runs out
+)
+
 // ConjugationAttempt records a single attempt
 type ConjugationAttempt struct {
 	ID           string    `json:"id" db:"id"`
@@ -76,10 +243,56 @@ type ConjugationAttempt struct {
 	BaseForm     string    `json:"base_form" db:"base_form"`
 	UserAnswer   string    `json:"user_answer" db:"user_answer"`
 	IsCorrect    bool      `json:"is_correct" db:"is_correct"`
+	ErrorCategory string   `json:"error_category,omitempty" db:"error_category"`
+	FailedStep   *int      `json:"failed_step,omitempty" db:"failed_step"` // First wrong step of a chained challenge
 	TimeSpentSec int       `json:"time_spent_sec" db:"time_spent_sec"`
+	TimeSpentMs  int       `json:"time_spent_ms" db:"time_spent_ms"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
+// Why a conjugation answer was wrong
+const (
+	ConjugationErrorGroup    = "wrong_group"     // Conjugated as the wrong verb group (帰ない for 帰らない)
+	ConjugationErrorStem     = "wrong_stem"      // Right ending on the wrong stem (書きない for 書かない)
+	ConjugationErrorEnding   = "wrong_ending"    // Right stem, wrong ending (食べで for 食べて)
+	ConjugationErrorForm     = "wrong_form"      // A different form altogether (食べた for 食べて)
+	ConjugationErrorEuphonic = "euphonic_change" // Sound change missed or misapplied (書きて for 書いて)
+	ConjugationErrorSuppletive = "missed_suppletive" // お〜になる on a verb with its own keigo (お行きになる for いらっしゃる)
+	ConjugationErrorDoubleKeigo = "double_keigo"   // Keigo stacked on keigo (お召し上がりになる)
+	ConjugationErrorOther    = "other"
+)
+
+// ConjugationChainStep is one transformation of a chained challenge
+type ConjugationChainStep struct {
+	Form     string `json:"form"`
+	From     string `json:"from"`     // The word this step conjugates
+	Expected string `json:"expected"` // 食べさせる
+	Reading  string `json:"reading"`  // たべさせる
+	Hint     string `json:"hint"`
+}
+
+// ConjugationStepResult is the verdict on one step of a chained answer. Steps
+// answered only through the final answer have no Answer and are judged from it.
+type ConjugationStepResult struct {
+	Step          int    `json:"step"` // 0-based
+	Form          string `json:"form"`
+	From          string `json:"from"`
+	Expected      string `json:"expected"`
+	Answer        string `json:"answer,omitempty"`
+	IsCorrect     bool   `json:"is_correct"`
+	ErrorCategory string `json:"error_category,omitempty"`
+	Explanation   string `json:"explanation,omitempty"`
+}
+
+// ChainTransition counts how often a user fails one step of a chained drill
+type ChainTransition struct {
+	Step        int     `json:"step"`
+	From        string  `json:"from"` // Form before the step, "dictionary" for the first
+	To          string  `json:"to"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"` // Percentage of the chain's attempts
+}
+
 // ConjugationChallengeResponse for API (single challenge - legacy)
 type ConjugationChallengeResponse struct {
 	Challenge   *ConjugationChallenge `json:"challenge"`
@@ -96,11 +309,22 @@ type ConjugationSessionResponse struct {
 	FormInfo   *ConjugationFormType      `json:"form_info"`
 }
 
+// ConjugationSessionSummary is one entry of a user's session list
+type ConjugationSessionSummary struct {
+	Session   *ConjugationSession  `json:"session"`
+	FormInfo  *ConjugationFormType `json:"form_info"`
+	Remaining int                  `json:"remaining"` // Challenges not answered yet
+	Accuracy  float64              `json:"accuracy"`
+	ExpiresAt *time.Time           `json:"expires_at,omitempty"` // Active and paused sessions only
+}
+
 // WeakForm represents a form with accuracy stats
 type WeakForm struct {
-	Form     string  `json:"form"`
-	Accuracy float64 `json:"accuracy"`
-	Total    int     `json:"total_attempts"`
+	Form     string         `json:"form"`
+	Accuracy float64        `json:"accuracy"`
+	Total    int            `json:"total_attempts"`
+	Errors   map[string]int `json:"errors,omitempty"` // Wrong answers by error category
+	Transitions []ChainTransition `json:"transitions,omitempty"` // Chained forms only, most failed first
 }
 
 // WeakPointsAnalysis represents user's weak areas
@@ -108,6 +332,7 @@ type WeakPointsAnalysis struct {
 	WeakForms   []WeakForm `json:"weak_forms"`
 	StrongForms []WeakForm `json:"strong_forms"`
 	TotalForms  int        `json:"total_forms_studied"`
+	ErrorCategories map[string]int `json:"error_categories"` // Wrong answers by category across all forms
 }
 
 // ConjugationProgress tracks overall progress
@@ -125,6 +350,18 @@ type ConjugationProgress struct {
 	DailyCompleted    int                `json:"daily_completed"`
 	IsWeakPointDrill  bool               `json:"is_weak_point_drill,omitempty"`
 	WeakFormAccuracy  float64            `json:"weak_form_accuracy,omitempty"`
+	FormAttempts      map[string]int     `json:"form_attempts,omitempty"`
+	Tracks            []ConjugationTrack `json:"tracks,omitempty"` // Verb and adjective forms unlock separately
+}
+
+// ConjugationTrack is a learner's way through one family of forms. The first
+// form is always unlocked; each completed form unlocks the next.
+type ConjugationTrack struct {
+	Name           string   `json:"name"` // verb or adjective
+	FormsUnlocked  []string `json:"forms_unlocked"`
+	FormsCompleted []string `json:"forms_completed"`
+	NextForm       string   `json:"next_form,omitempty"` // The unlocked form to work on
+	Mastery        float64  `json:"mastery"`             // Average accuracy over the track's forms
 }
 
 // ConjugationSubmitRequest for answer submission
@@ -132,6 +369,7 @@ type ConjugationSubmitRequest struct {
 	SessionID   string `json:"session_id" binding:"required"`
 	ChallengeID string `json:"challenge_id" binding:"required"`
 	Answer      string `json:"answer" binding:"required"`
+	StepAnswers []string `json:"step_answers"` // Intermediate results of a chained challenge, in order
 	TimeSpentMs int    `json:"time_spent_ms"`
 }
 
@@ -139,11 +377,76 @@ type ConjugationSubmitRequest struct {
 type ConjugationSubmitResponse struct {
 	IsCorrect       bool                   `json:"is_correct"`
 	CorrectAnswer   string                 `json:"correct_answer"`
+	ErrorCategory   string                 `json:"error_category,omitempty"`
 	Explanation     string                 `json:"explanation"`
+	Steps           []ConjugationStepResult `json:"steps,omitempty"`       // Chained challenges only
+	FailedStep      *int                   `json:"failed_step,omitempty"` // First step that went wrong
 	NextChallenge   *ConjugationChallenge   `json:"next_challenge,omitempty"`
 	NextFormInfo    *ConjugationFormType    `json:"next_form_info,omitempty"`
 	FormCompleted   bool                   `json:"form_completed"`
 	AllFormsCompleted bool                `json:"all_forms_completed"`
 	Progress        *ConjugationProgress   `json:"progress"`
 	SessionID       string                 `json:"session_id"`
+	ResponseMs      int                    `json:"response_ms"`                 // As timed by the server in timed mode
+	TimeRemainingMs *int                   `json:"time_remaining_ms,omitempty"` // Timed mode only
+	TimeUp          bool                   `json:"time_up,omitempty"`           // The answer came in after the clock ran out
+	SpeedRun        *SpeedRunResult        `json:"speed_run,omitempty"`         // Summary once a timed run is over
+}
+
+// SpeedRunResult summarises a timed session
+type SpeedRunResult struct {
+	SessionID       string    `json:"session_id"`
+	Form            string    `json:"form"`
+	TimeLimitSec    int       `json:"time_limit_sec"`
+	Score           int       `json:"score"` // Correct answers
+	Answered        int       `json:"answered"`
+	Accuracy        float64   `json:"accuracy"`
+	MedianMs        int       `json:"median_ms"` // Of correct answers
+	PersonalBest    int       `json:"personal_best"`
+	NewPersonalBest bool      `json:"new_personal_best"`
+	Finished        bool      `json:"finished"`
+	EndsAt          time.Time `json:"ends_at"`
+}
+
+// SpeedRunBest is a user's best timed run for a form and time limit
+type SpeedRunBest struct {
+	Form         string    `json:"form"`
+	TimeLimitSec int       `json:"time_limit_sec"`
+	Score        int       `json:"score"`
+	SessionID    string    `json:"session_id"`
+	AchievedAt   time.Time `json:"achieved_at"`
+}
+
+// SpeedTrendPoint is one day of response times for a form
+type SpeedTrendPoint struct {
+	Date     string  `json:"date"` // YYYY-MM-DD
+	MedianMs int     `json:"median_ms"`
+	Attempts int     `json:"attempts"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// FormSpeedStats measures how automatic a form has become: how fast correct
+// answers come, and how that has changed
+type FormSpeedStats struct {
+	Form           string            `json:"form"`
+	Attempts       int               `json:"attempts"`
+	MedianMs       int               `json:"median_ms"`        // Correct answers in the period
+	RecentMedianMs int               `json:"recent_median_ms"` // Last 20 correct answers
+	FastestMs      int               `json:"fastest_ms"`
+	BestRuns       []SpeedRunBest    `json:"best_runs,omitempty"`
+	Trend          []SpeedTrendPoint `json:"trend"`
+}
+
+// ConjugationSpeedReport is the speed side of conjugation progress
+type ConjugationSpeedReport struct {
+	Days  int              `json:"days"`
+	Forms []FormSpeedStats `json:"forms"`
+}
+
+// ResponseTime is one timed attempt, as read for speed stats
+type ResponseTime struct {
+	Form      string
+	IsCorrect bool
+	Ms        int
+	CreatedAt time.Time
 }
@@ -114,6 +114,8 @@ type ChatResponse struct {
 	AIResponse       ConversationMessage `json:"ai_response"`
 	NaturalnessScore int                 `json:"naturalness_score"`
 	Suggestions      []string            `json:"suggestions,omitempty"`
+	DetectedGrammar  []GrammarMatch      `json:"detected_grammar,omitempty"` // Patterns in the user's message
+	ResponseGrammar  []GrammarMatch      `json:"response_grammar,omitempty"` // Patterns in the AI reply
 }
 
 // StartChatRequest represents the request to start a new conversation
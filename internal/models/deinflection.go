@@ -0,0 +1,36 @@
+package models
+
+// DeinflectionStep is one transformation in a conjugation chain.
+// Steps run from the dictionary form towards the inflected word.
+type DeinflectionStep struct {
+	Form     string               `json:"form"` // Matches ConjugationFormType.Name
+	FormInfo *ConjugationFormType `json:"form_info,omitempty"`
+	Result   string               `json:"result"` // The word after this step, e.g. 食べさせる
+}
+
+// DeinflectionCandidate is one way to read an inflected word
+type DeinflectionCandidate struct {
+	BaseForm   string             `json:"base_form"`
+	Group      string             `json:"group"`
+	Chain      []DeinflectionStep `json:"chain"`
+	Vocabulary *Vocabulary        `json:"vocabulary,omitempty"`
+}
+
+// DeinflectionResult lists the dictionary forms an inflected word may come from
+type DeinflectionResult struct {
+	Word       string                  `json:"word"`
+	Candidates []DeinflectionCandidate `json:"candidates"`
+}
+
+// InflectedWord is a conjugated vocabulary word found in running text.
+// Start and End are rune offsets into the input, End exclusive.
+type InflectedWord struct {
+	Start        int                `json:"start"`
+	End          int                `json:"end"`
+	Text         string             `json:"text"`
+	BaseForm     string             `json:"base_form"`
+	Group        string             `json:"group"`
+	Chain        []DeinflectionStep `json:"chain"`
+	VocabularyID string             `json:"vocabulary_id"`
+	Meaning      string             `json:"meaning"`
+}
@@ -113,3 +113,45 @@ type GrammarListResponse struct {
 	Patterns   []GrammarPattern `json:"patterns"`
 	Pagination PaginationResponse `json:"pagination"`
 }
+
+// Per-pattern grammar statuses
+const (
+	GrammarStatusLearning = "learning"
+	GrammarStatusKnown    = "known"
+	GrammarStatusSkipped  = "skipped"
+)
+
+// UserGrammarStatus is a user's status for one grammar pattern
+type UserGrammarStatus struct {
+	ID           string     `json:"id" db:"id"`
+	UserID       string     `json:"user_id" db:"user_id"`
+	GrammarID    string     `json:"grammar_id" db:"grammar_id"`
+	Status       string     `json:"status" db:"status"` // learning, known, skipped
+	FirstSeenAt  time.Time  `json:"first_seen_at" db:"first_seen_at"`
+	MarkedAt     time.Time  `json:"marked_at" db:"marked_at"`
+	KnownAt      *time.Time `json:"known_at,omitempty" db:"known_at"`
+	RevisitCount int        `json:"revisit_count" db:"revisit_count"`
+}
+
+// LearnedGrammarPattern is a status entry with the pattern it refers to
+type LearnedGrammarPattern struct {
+	UserGrammarStatus
+	Pattern       string `json:"pattern"`
+	Meaning       string `json:"meaning"`
+	JLPTLevel     string `json:"jlpt_level"`
+	IndexPosition int    `json:"index_position"`
+}
+
+// LearnedGrammarResponse lists a user's grammar statuses
+type LearnedGrammarResponse struct {
+	Patterns       []LearnedGrammarPattern `json:"patterns"`
+	CountsByStatus map[string]int          `json:"counts_by_status"`
+	Pagination     PaginationResponse      `json:"pagination"`
+}
+
+// GrammarStatusResponse is returned after changing a pattern's status
+type GrammarStatusResponse struct {
+	Pattern  *GrammarPattern    `json:"pattern"`
+	Status   *UserGrammarStatus `json:"status,omitempty"` // nil after a reset
+	Progress *GrammarProgress   `json:"progress"`
+}
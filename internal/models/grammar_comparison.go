@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// Which side of a comparison a rule, node or quiz answer points at
+const (
+	ComparisonSideA    = "A"
+	ComparisonSideB    = "B"
+	ComparisonSideBoth = "both"
+)
+
+// GrammarComparison is a curated contrast between two confusable patterns
+type GrammarComparison struct {
+	ID            string               `json:"id" db:"id"`
+	PatternAID    string               `json:"pattern_a_id" db:"pattern_a_id"`
+	PatternBID    string               `json:"pattern_b_id" db:"pattern_b_id"`
+	JLPTLevel     string               `json:"jlpt_level" db:"jlpt_level"`
+	Title         string               `json:"title" db:"title"`
+	Summary       string               `json:"summary" db:"summary"`
+	Aspects       []ComparisonAspect   `json:"aspects" db:"aspects"`
+	ExamplePairs  []ContrastiveExample `json:"example_pairs" db:"example_pairs"`
+	BoundaryRules []ComparisonBoundary `json:"boundary_rules" db:"boundary_rules"`
+	DecisionTree  []ComparisonDecision `json:"decision_tree" db:"decision_tree"`
+	CommonErrors  []ComparisonError    `json:"common_errors" db:"common_errors"`
+	Quiz          []ComparisonQuizItem `json:"-" db:"quiz"`
+	QuizCount     int                  `json:"quiz_count" db:"-"`
+	PatternA      *GrammarPattern      `json:"pattern_a,omitempty" db:"-"`
+	PatternB      *GrammarPattern      `json:"pattern_b,omitempty" db:"-"`
+	CreatedAt     time.Time            `json:"created_at" db:"created_at"`
+}
+
+// ComparisonAspect contrasts how each pattern behaves on one dimension
+type ComparisonAspect struct {
+	Aspect   string `json:"aspect"` // e.g. "who decides", "certainty", "source"
+	PatternA string `json:"pattern_a"`
+	PatternB string `json:"pattern_b"`
+}
+
+// ContrastiveExample is a minimal pair: the same situation said with each pattern
+type ContrastiveExample struct {
+	Context   string `json:"context"`
+	SentenceA string `json:"sentence_a"`
+	MeaningA  string `json:"meaning_a"`
+	SentenceB string `json:"sentence_b"`
+	MeaningB  string `json:"meaning_b"`
+	Note      string `json:"note,omitempty"`
+}
+
+// ComparisonBoundary says which pattern a situation calls for
+type ComparisonBoundary struct {
+	Situation   string `json:"situation"`
+	UsePattern  string `json:"use_pattern"` // A, B, both
+	Explanation string `json:"explanation"`
+}
+
+// ComparisonDecision is one node of the "which pattern?" decision tree.
+// Inner nodes ask a yes/no question and point at the next node; leaves carry a Result.
+type ComparisonDecision struct {
+	ID       string `json:"id"`
+	Question string `json:"question,omitempty"`
+	Yes      string `json:"yes,omitempty"`    // Next node ID
+	No       string `json:"no,omitempty"`     // Next node ID
+	Result   string `json:"result,omitempty"` // A or B (leaf nodes only)
+	Note     string `json:"note,omitempty"`
+}
+
+// ComparisonError is a typical mistake when mixing the two patterns up
+type ComparisonError struct {
+	Error       string `json:"error"`
+	Correction  string `json:"correction"`
+	Explanation string `json:"explanation"`
+}
+
+// ComparisonQuizItem asks which pattern fits a context; Answer is A or B
+type ComparisonQuizItem struct {
+	Context     string `json:"context"`
+	Sentence    string `json:"sentence"` // Contains ＿＿＿ where the pattern goes
+	Meaning     string `json:"meaning"`
+	Answer      string `json:"answer"`
+	Explanation string `json:"explanation"`
+}
+
+// ComparisonQuizQuestion is a quiz item as shown to the user, without the answer
+type ComparisonQuizQuestion struct {
+	Index    int      `json:"index"`
+	Context  string   `json:"context"`
+	Sentence string   `json:"sentence"`
+	Meaning  string   `json:"meaning"`
+	Options  []string `json:"options"` // [pattern A, pattern B]
+}
+
+// ComparisonQuiz is the question set for one comparison
+type ComparisonQuiz struct {
+	ComparisonID string                   `json:"comparison_id"`
+	Title        string                   `json:"title"`
+	Questions    []ComparisonQuizQuestion `json:"questions"`
+}
+
+// ComparisonQuizResult is returned after answering a comparison quiz question
+type ComparisonQuizResult struct {
+	IsCorrect     bool   `json:"is_correct"`
+	CorrectAnswer string `json:"correct_answer"` // A or B
+	CorrectOption string `json:"correct_option"` // The pattern itself
+	Explanation   string `json:"explanation"`
+}
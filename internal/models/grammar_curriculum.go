@@ -0,0 +1,64 @@
+package models
+
+// Kinds of grammar prerequisites
+const (
+	PrerequisiteGrammar     = "grammar"
+	PrerequisiteVocabulary  = "vocabulary"
+	PrerequisiteConjugation = "conjugation"
+)
+
+// Curriculum node states
+const (
+	CurriculumLocked   = "locked"
+	CurriculumUnlocked = "unlocked"
+	CurriculumLearned  = "learned"
+)
+
+// GrammarPrerequisite is an edge in the grammar curriculum: GrammarID requires PrerequisiteID
+type GrammarPrerequisite struct {
+	ID               string `json:"id" db:"id"`
+	GrammarID        string `json:"grammar_id" db:"grammar_id"`
+	PrerequisiteType string `json:"prerequisite_type" db:"prerequisite_type"` // grammar, vocabulary, conjugation
+	PrerequisiteID   string `json:"prerequisite_id" db:"prerequisite_id"`
+	Label            string `json:"label" db:"label"`
+	Note             string `json:"note,omitempty" db:"note"`
+}
+
+// CurriculumPrerequisite is a prerequisite with the user's progress on it
+type CurriculumPrerequisite struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Label     string `json:"label"`
+	Note      string `json:"note,omitempty"`
+	Satisfied bool   `json:"satisfied"`
+}
+
+// CurriculumNode is one pattern in the curriculum tree
+type CurriculumNode struct {
+	PatternID     string                   `json:"pattern_id"`
+	Pattern       string                   `json:"pattern"`
+	Meaning       string                   `json:"meaning"`
+	JLPTLevel     string                   `json:"jlpt_level"`
+	IndexPosition int                      `json:"index_position"`
+	Status        string                   `json:"status,omitempty"` // learning, known, skipped
+	State         string                   `json:"state"`            // locked, unlocked, learned
+	Prerequisites []CurriculumPrerequisite `json:"prerequisites"`
+}
+
+// CurriculumTree is the locked/unlocked view of a level's patterns
+type CurriculumTree struct {
+	Level    string           `json:"level"`
+	Nodes    []CurriculumNode `json:"nodes"`
+	Learned  int              `json:"learned"`
+	Unlocked int              `json:"unlocked"`
+	Locked   int              `json:"locked"`
+}
+
+// CurriculumNextResponse is the next pattern to study in curriculum mode
+type CurriculumNextResponse struct {
+	Pattern       *GrammarPattern          `json:"pattern"`
+	Prerequisites []CurriculumPrerequisite `json:"prerequisites"`
+	Progress      *GrammarProgress         `json:"progress"`
+	Unlocked      int                      `json:"unlocked"`
+	Locked        int                      `json:"locked"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// GrammarMatch is one grammar pattern found in a piece of text.
+// Start and End are rune offsets into the input, End exclusive.
+type GrammarMatch struct {
+	PatternID string `json:"pattern_id"`
+	Pattern   string `json:"pattern"`
+	Meaning   string `json:"meaning"`
+	JLPTLevel string `json:"jlpt_level"`
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	Text      string `json:"text"` // Matched surface, e.g. ことにした
+	Form      string `json:"form"` // Inflection of the match (plain, past, te...)
+}
+
+// GrammarDetectResult lists the grammar patterns detected in a text
+type GrammarDetectResult struct {
+	Text     string          `json:"text"`
+	Matches  []GrammarMatch  `json:"matches"`
+	Patterns int             `json:"patterns"` // Distinct patterns matched
+	Words    []InflectedWord `json:"words"`    // Conjugated vocabulary, deinflected
+}
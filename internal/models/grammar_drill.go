@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Grammar drill answer modes
+const (
+	GrammarDrillTyped  = "typed"
+	GrammarDrillChoice = "choice"
+)
+
+// GrammarClozeBlank marks where the pattern was removed from a sentence
+const GrammarClozeBlank = "＿＿＿"
+
+// GrammarDrillQuestion is a usage example with the grammar pattern blanked out
+type GrammarDrillQuestion struct {
+	PatternID    string   `json:"pattern_id"`
+	Pattern      string   `json:"pattern,omitempty"` // Only for typed mode
+	ExampleIndex int      `json:"example_index"`
+	Sentence     string   `json:"sentence"`          // Contains ＿＿＿ where the pattern was
+	Meaning      string   `json:"meaning"`           // English translation of the sentence
+	Context      string   `json:"context,omitempty"` // Situation hint
+	Form         string   `json:"form"`              // Inflection of the blanked pattern (plain, polite, past...)
+	Mode         string   `json:"mode"`              // typed, choice
+	Options      []string `json:"options,omitempty"` // Only for choice mode
+}
+
+// GrammarDrillAttempt records one answered cloze
+type GrammarDrillAttempt struct {
+	ID            string    `json:"id" db:"id"`
+	UserID        string    `json:"user_id" db:"user_id"`
+	PatternID     string    `json:"pattern_id" db:"pattern_id"`
+	ExampleIndex  int       `json:"example_index" db:"example_index"`
+	Mode          string    `json:"mode" db:"mode"`
+	UserAnswer    string    `json:"user_answer" db:"user_answer"`
+	CorrectAnswer string    `json:"correct_answer" db:"correct_answer"`
+	IsCorrect     bool      `json:"is_correct" db:"is_correct"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// GrammarDrillResult is returned after answering a cloze
+type GrammarDrillResult struct {
+	IsCorrect     bool   `json:"is_correct"`
+	CorrectAnswer string `json:"correct_answer"`
+	FullSentence  string `json:"full_sentence"`
+	Explanation   string `json:"explanation"` // Why the pattern fits here
+}
+
+// GrammarDrillSet is a batch of cloze questions
+type GrammarDrillSet struct {
+	Questions []GrammarDrillQuestion `json:"questions"`
+	Mode      string                 `json:"mode"`
+	Level     string                 `json:"level,omitempty"`
+}
+
+// WeakPattern is a grammar pattern with drill accuracy stats
+type WeakPattern struct {
+	PatternID string  `json:"pattern_id"`
+	Pattern   string  `json:"pattern"`
+	Accuracy  float64 `json:"accuracy"`
+	Total     int     `json:"total_attempts"`
+}
+
+// GrammarWeakPointsAnalysis mirrors the conjugation weak point analysis
+type GrammarWeakPointsAnalysis struct {
+	WeakPatterns   []WeakPattern `json:"weak_patterns"`
+	StrongPatterns []WeakPattern `json:"strong_patterns"`
+	TotalPatterns  int           `json:"total_patterns_drilled"`
+}
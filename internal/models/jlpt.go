@@ -31,6 +31,18 @@ type JLPTQuestion struct {
 	Explanation  string   `json:"explanation" db:"explanation"` // Why this is correct
 	PointValue   int      `json:"point_value" db:"point_value"` // Usually 1
 	SkillTested  string   `json:"skill_tested" db:"skill_tested"` // vocab, grammar, kanji, etc.
+
+	SectionNum int `json:"section_num" db:"section_num"` // The timed section it belongs to
+}
+
+// JLPTTestSection is a separately timed part of a mock test, as on the real
+// exam (言語知識, 読解, 聴解). Sections are taken in order; a test without
+// any is timed as a single section.
+type JLPTTestSection struct {
+	TestID     string `json:"test_id" db:"test_id"`
+	SectionNum int    `json:"section_num" db:"section_num"`
+	Name       string `json:"name" db:"name"`
+	TimeLimit  int    `json:"time_limit_minutes" db:"time_limit_minutes"`
 }
 
 // UserTestSession tracks a user's test attempt
@@ -46,6 +58,55 @@ type UserTestSession struct {
 	Score           int                    `json:"score" db:"score"`
 	CorrectCount    int                    `json:"correct_count" db:"correct_count"`
 	Status          string                 `json:"status" db:"status"` // in_progress, completed, abandoned
+
+	CurrentSection   int        `json:"current_section" db:"current_section"`
+	SectionStartedAt *time.Time `json:"section_started_at,omitempty" db:"section_started_at"`
+	SectionEndsAt    *time.Time `json:"section_ends_at,omitempty" db:"section_ends_at"`
+	EndsAt           *time.Time `json:"ends_at,omitempty" db:"ends_at"`     // When the last section runs out
+	AutoSubmitted    bool       `json:"auto_submitted" db:"auto_submitted"` // Submitted by the server when time ran out
+
+	ScaledScore *int  `json:"scaled_score,omitempty" db:"scaled_score"` // Out of the tested sections' maximum; nil before scaled scoring
+	Passed      *bool `json:"passed,omitempty" db:"passed"`
+}
+
+// Test session statuses
+const (
+	TestSessionInProgress = "in_progress"
+	TestSessionCompleted  = "completed"
+	TestSessionAbandoned  = "abandoned"
+)
+
+// Where a section stands in a session
+const (
+	TestSectionDone     = "done" // Finished early or run out; its answers are final
+	TestSectionCurrent  = "current"
+	TestSectionUpcoming = "upcoming"
+)
+
+// TestProgress is how far a session has got and how much time it has left
+type TestProgress struct {
+	SessionID           string                `json:"session_id"`
+	Status              string                `json:"status"`
+	Answered            int                   `json:"answered"`
+	Total               int                   `json:"total"`
+	Remaining           int                   `json:"remaining"`     // Questions not answered yet
+	RemainingSec        int                   `json:"remaining_sec"` // Until the test is submitted; 0 once it has been
+	CurrentSection      int                   `json:"current_section"`
+	SectionRemainingSec int                   `json:"section_remaining_sec"`
+	EndsAt              *time.Time            `json:"ends_at,omitempty"`
+	SectionEndsAt       *time.Time            `json:"section_ends_at,omitempty"`
+	AutoSubmitted       bool                  `json:"auto_submitted"`
+	Sections            []TestSectionProgress `json:"sections"`
+}
+
+// TestSectionProgress is one section of a session's progress
+type TestSectionProgress struct {
+	SectionNum int    `json:"section_num"`
+	Name       string `json:"name"`
+	TimeLimit  int    `json:"time_limit_minutes"`
+	Status     string `json:"status"` // done, current, upcoming
+	Answered   int    `json:"answered"`
+	Total      int    `json:"total"`
 }
 
 // TestResult represents the final results
@@ -62,6 +123,60 @@ type TestResult struct {
 	TimeLimit       int                    `json:"time_limit_minutes"`
 	SectionBreakdown map[string]SectionScore `json:"section_breakdown,omitempty"`
 	ReviewQuestions []ReviewItem           `json:"review_questions,omitempty"`
+
+	AutoSubmitted bool `json:"auto_submitted"` // Submitted by the server when time ran out
+
+	ScoreReport *JLPTScoreReport `json:"score_report,omitempty"` // Passed comes from here for the JLPT levels
+}
+
+// JLPT scoring sections. N1-N3 score language knowledge, reading and
+// listening out of 60 each; N4 and N5 score language knowledge together
+// with reading out of 120, and listening out of 60.
+const (
+	JLPTScoreLanguage        = "language_knowledge"         // 言語知識（文字・語彙・文法）
+	JLPTScoreReading         = "reading"                    // 読解
+	JLPTScoreLanguageReading = "language_knowledge_reading" // 言語知識（文字・語彙・文法）・読解
+	JLPTScoreListening       = "listening"                  // 聴解
+)
+
+// JLPTScoreReport sets out a result like the official score report: a
+// scaled score per scoring section, the total out of 180, and reference
+// grades for vocabulary and grammar
+type JLPTScoreReport struct {
+	Level          string               `json:"level"`
+	Sections       []JLPTSectionScore   `json:"sections"`
+	TotalScore     int                  `json:"total_score"`
+	MaxScore       int                  `json:"max_score"` // 180, less any sections the test doesn't cover
+	PassMark       int                  `json:"pass_mark"`
+	Passed         bool                 `json:"passed"`
+	Partial        bool                 `json:"partial"` // Some sections untested; the pass mark is scaled to those that were
+	FailedSections []string             `json:"failed_sections"`
+	Reference      []JLPTReferenceGrade `json:"reference"`
+}
+
+// JLPTSectionScore is one scoring section of a score report
+type JLPTSectionScore struct {
+	Section     string   `json:"section"` // language_knowledge, reading, language_knowledge_reading, listening
+	Name        string   `json:"name"`    // As on the official report
+	Skills      []string `json:"skills"`  // The skill_tested values scored here
+	Tested      bool     `json:"tested"`
+	Correct     int      `json:"correct"`
+	Total       int      `json:"total"`
+	RawScore    int      `json:"raw_score"` // Points
+	RawMax      int      `json:"raw_max"`
+	ScaledScore int      `json:"scaled_score"`
+	MaxScore    int      `json:"max_score"`
+	PassMark    int      `json:"pass_mark"` // Sectional minimum
+	Passed      bool     `json:"passed"`
+}
+
+// JLPTReferenceGrade is the official report's reference information: A
+// for 67% correct or more, B for 34% or more, C below
+type JLPTReferenceGrade struct {
+	Name    string `json:"name"` // 文字・語彙, 文法
+	Grade   string `json:"grade"`
+	Correct int    `json:"correct"`
+	Total   int    `json:"total"`
 }
 
 // SectionScore tracks per-section performance
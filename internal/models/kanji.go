@@ -14,6 +14,8 @@ type Kanji struct {
 	StrokeCount int      `json:"stroke_count" db:"stroke_count"`
 	StrokeOrder []Stroke `json:"stroke_order" db:"stroke_order"` // JSON array of stroke data
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+
+	OnReadings []string `json:"on_readings" db:"on_readings"` // The Readings that are on readings; the rest are kun
 }
 
 // Stroke represents a single stroke path
@@ -42,6 +44,60 @@ type KanjiPracticeSession struct {
 	UserStrokes   []UserStroke `json:"user_strokes" db:"user_strokes"` // JSON
 	Accuracy      float64   `json:"accuracy" db:"accuracy"`       // 0-100
 	Status        string    `json:"status" db:"status"`             // in_progress, completed
+
+	Mode         string             `json:"mode" db:"mode"`                             // trace, recall
+	Prompt       *KanjiRecallPrompt `json:"prompt,omitempty" db:"prompt"`               // Recall only
+	RecallResult *KanjiRecallResult `json:"recall_result,omitempty" db:"recall_result"` // Recall only, once graded
+	SRSQuality   *int               `json:"srs_quality,omitempty" db:"srs_quality"`
+}
+
+// Kanji practice modes: tracing over a guide stroke by stroke, or writing
+// the whole character from memory
+const (
+	KanjiModeTrace  = "trace"
+	KanjiModeRecall = "recall"
+)
+
+// What a recall session shows in place of the kanji
+const (
+	KanjiPromptMeaning    = "meaning"
+	KanjiPromptVocabulary = "vocabulary"
+)
+
+// KanjiRecallPrompt is what the learner sees in a recall session. The kanji
+// itself is never part of it.
+type KanjiRecallPrompt struct {
+	Type        string   `json:"type"` // meaning, vocabulary
+	Meaning     string   `json:"meaning"`
+	Readings    []string `json:"readings"`
+	Word        string   `json:"word,omitempty"` // A word using the kanji, with the kanji hidden (＿曜日)
+	WordReading string   `json:"word_reading,omitempty"`
+	WordMeaning string   `json:"word_meaning,omitempty"`
+}
+
+// KanjiRecallRequest submits a whole character written from memory
+type KanjiRecallRequest struct {
+	SessionID string    `json:"session_id" binding:"required"`
+	Strokes   [][]Point `json:"strokes" binding:"required,min=1"`
+}
+
+// KanjiRecallResult grades a character written from memory as a whole
+type KanjiRecallResult struct {
+	Correct            bool             `json:"correct"` // The recogniser read it as the right kanji
+	Character          string           `json:"character"`
+	RecognizedAs       string           `json:"recognized_as"`
+	Rank               int              `json:"rank"` // Where the right kanji ranked, 0 if it didn't
+	StrokeCount        int              `json:"stroke_count"`
+	StrokesDrawn       int              `json:"strokes_drawn"`
+	StrokeCountCorrect bool             `json:"stroke_count_correct"`
+	OrderCorrect       bool             `json:"order_correct"`
+	OrderErrors        int              `json:"order_errors"`     // Strokes drawn out of turn
+	ReversedStrokes    int              `json:"reversed_strokes"` // Strokes drawn from the wrong end
+	Accuracy           float64          `json:"accuracy"`         // Mean stroke accuracy, 0-100
+	Quality            int              `json:"quality"`          // SM-2 grade given to the kanji, 0-5
+	NextReview         string           `json:"next_review"`
+	Feedback           string           `json:"feedback"`
+	Candidates         []KanjiCandidate `json:"candidates"`
 }
 
 // UserStroke captures user's drawn stroke
@@ -50,6 +106,67 @@ type UserStroke struct {
 	Path        []Point   `json:"path"`
 	Duration    int       `json:"duration_ms"`
 	Timestamp   time.Time `json:"timestamp"`
+
+	Matched   []int              `json:"matched,omitempty"`   // Reference strokes it was taken as (two when merged)
+	Continues bool               `json:"continues,omitempty"` // Second piece of a stroke drawn in two
+	Accuracy  float64            `json:"accuracy"`
+	Errors    []KanjiStrokeError `json:"errors,omitempty"`
+}
+
+// Stroke errors a drawn stroke can be classified with
+const (
+	StrokeErrorOrder    = "wrong_order" // Drawn before a stroke that comes first
+	StrokeErrorReversed = "reversed"    // Drawn from the wrong end
+	StrokeErrorMerged   = "merged"      // Two strokes drawn as one (口 with ㇕ and the bottom in one go)
+	StrokeErrorSplit    = "split"       // One stroke drawn in two pieces
+	StrokeErrorMissing  = "missing"     // Never drawn
+)
+
+// KanjiStrokeError is one stroke error, with the stroke-order rule it breaks
+// when it is a wrong order
+type KanjiStrokeError struct {
+	Type      string `json:"type"`
+	StrokeNum int    `json:"stroke_num"`     // Reference stroke
+	Rule      string `json:"rule,omitempty"` // Stroke-order rule broken, for wrong_order
+	Message   string `json:"message"`
+}
+
+// KanjiStrokeRecord is a drawn stroke as the session understood it
+type KanjiStrokeRecord struct {
+	Index     int                `json:"index"`   // 1-based, in drawing order
+	Matched   []int              `json:"matched"` // Reference strokes; empty for a stray stroke
+	Continues bool               `json:"continues,omitempty"`
+	Accuracy  float64            `json:"accuracy"`
+	Errors    []KanjiStrokeError `json:"errors"`
+}
+
+// KanjiStrokeReport is the stroke-by-stroke error report of a practice session
+type KanjiStrokeReport struct {
+	SessionID    string              `json:"session_id"`
+	KanjiChar    string              `json:"kanji_char"`
+	Complete     bool                `json:"complete"`
+	StrokeCount  int                 `json:"stroke_count"`
+	StrokesDrawn int                 `json:"strokes_drawn"`
+	OrderCorrect bool                `json:"order_correct"`
+	Strokes      []KanjiStrokeRecord `json:"strokes"`
+	Errors       []KanjiStrokeError  `json:"errors"`
+	ErrorTypes   map[string]int      `json:"error_types"`
+	Missing      []int               `json:"missing"` // Only once the session is complete
+}
+
+// StrokeOrderRuleStat is how often a user breaks one stroke-order rule
+type StrokeOrderRuleStat struct {
+	Rule        string   `json:"rule"`
+	Description string   `json:"description"`
+	Count       int      `json:"count"`
+	Kanji       []string `json:"kanji"` // Characters it was broken on, most often first
+}
+
+// KanjiStrokeErrorStats aggregates a user's stroke errors over completed sessions
+type KanjiStrokeErrorStats struct {
+	SessionsReviewed int                   `json:"sessions_reviewed"`
+	ErrorTypes       map[string]int        `json:"error_types"`
+	Rules            []StrokeOrderRuleStat `json:"rules"` // Most broken first
 }
 
 // KanjiCompareRequest for stroke comparison
@@ -65,6 +182,116 @@ type KanjiCompareResult struct {
 	Feedback      string  `json:"feedback"`       // "Good!", "Try again", etc.
 	Direction     string  `json:"direction"`      // correct, wrong_direction
 	OrderCorrect  bool    `json:"order_correct"`  // Is this the expected stroke?
+	Reversed       bool            `json:"reversed"`        // Drawn from the wrong end
+	ShapeScore     float64         `json:"shape_score"`     // 0-100, shape alone
+	CurvatureScore float64         `json:"curvature_score"` // 0-100, hooks and curves
+	Deviation      float64         `json:"deviation"`       // Mean distance from the reference, canvas units
+	Segments       []StrokeSegment `json:"segments"`        // Where along the stroke it went wrong
+
+	StrokeDrawn    int                `json:"stroke_drawn"`    // Reference stroke(s) the path was taken as
+	ExpectedStroke int                `json:"expected_stroke"` // The stroke that was due
+	Merged         []int              `json:"merged,omitempty"` // Both strokes, when two were drawn as one
+	Errors         []KanjiStrokeError `json:"errors"`
+	Complete       bool               `json:"complete"`
+}
+
+// StrokeSegment is how far a drawn stroke strays from one stretch of the
+// reference stroke, so the client can highlight it
+type StrokeSegment struct {
+	Index     int     `json:"index"`
+	Start     Point   `json:"start"` // On the reference stroke
+	End       Point   `json:"end"`
+	Deviation float64 `json:"deviation"` // Mean distance in canvas units
+	OffTrack  bool    `json:"off_track"`
+}
+
+// Radical is a component kanji can be looked up by, as listed in RADKFILE.
+// Some radicals are stood in for by a kanji containing them (化 for ⺅).
+type Radical struct {
+	Character   string `json:"character"`
+	StrokeCount int    `json:"stroke_count"`
+}
+
+// KanjiComponentMatch is a kanji found through its components
+type KanjiComponentMatch struct {
+	Character   string   `json:"character"`
+	Meaning     string   `json:"meaning"`
+	Readings    []string `json:"readings"`
+	JLPTLevel   string   `json:"jlpt_level"`
+	StrokeCount int      `json:"stroke_count"`
+	Components  []string `json:"components"`
+	Shared      []string `json:"shared,omitempty"` // Components in common with the kanji being studied
+}
+
+// KanjiComponentSearch is the result of a search by components
+type KanjiComponentSearch struct {
+	Components []string              `json:"components"`
+	Kanji      []KanjiComponentMatch `json:"kanji"`
+	TotalCount int                   `json:"total_count"`
+	Narrowing  []string              `json:"narrowing"` // Components that can be added and still match something
+}
+
+// KanjiComponentNode is a node of a kanji's component tree
+type KanjiComponentNode struct {
+	Character   string                `json:"character"`
+	StrokeCount int                   `json:"stroke_count,omitempty"`
+	Meaning     string                `json:"meaning,omitempty"` // When the component is a kanji in its own right
+	IsRadical   bool                  `json:"is_radical"`
+	Components  []*KanjiComponentNode `json:"components"`
+}
+
+// Kinds of kanji reading in a word
+const (
+	KanjiReadingOn        = "on"
+	KanjiReadingKun       = "kun"
+	KanjiReadingIrregular = "irregular" // Read as a whole word, not kanji by kanji (今日 きょう)
+)
+
+// KanjiVocabLink is a kanji's place in a vocabulary word and how it is read there
+type KanjiVocabLink struct {
+	KanjiID      string `json:"kanji_id"`
+	VocabularyID string `json:"vocabulary_id"`
+	Position     int    `json:"position"`     // Character offset in the word
+	Reading      string `json:"reading"`      // As it sounds in the word (びと in 恋人)
+	BaseReading  string `json:"base_reading"` // The kanji's reading it comes from (ひと); empty when irregular
+	ReadingType  string `json:"reading_type"` // on, kun or irregular
+}
+
+// KanjiVocabWord is a word using a kanji
+type KanjiVocabWord struct {
+	VocabID      string `json:"vocab_id"`
+	Word         string `json:"word"`
+	Reading      string `json:"reading"`
+	Meaning      string `json:"meaning"`
+	JLPTLevel    string `json:"jlpt_level"`
+	KanjiReading string `json:"kanji_reading"`    // How the kanji sounds in the word
+	Status       string `json:"status,omitempty"` // The user's status for the word: learning, known, skipped
+	Known        bool   `json:"known"`
+
+	BaseReading string `json:"-"`
+	ReadingType string `json:"-"`
+}
+
+// KanjiReadingUsage is one reading of a kanji and the words using it
+type KanjiReadingUsage struct {
+	Reading    string           `json:"reading"` // Empty for the irregular group
+	Type       string           `json:"type"`    // on, kun or irregular
+	WordCount  int              `json:"word_count"`
+	KnownCount int              `json:"known_count"`
+	Words      []KanjiVocabWord `json:"words"`
+}
+
+// KanjiVocabularyBreakdown is the vocabulary using a kanji, grouped by reading
+type KanjiVocabularyBreakdown struct {
+	Character   string              `json:"character"`
+	Meaning     string              `json:"meaning"`
+	OnReadings  []string            `json:"on_readings"`
+	KunReadings []string            `json:"kun_readings"`
+	Levels      []string            `json:"levels"` // JLPT levels included
+	Known       string              `json:"known"`  // Filter applied: all, only or exclude
+	TotalWords  int                 `json:"total_words"`
+	KnownWords  int                 `json:"known_words"`
+	Readings    []KanjiReadingUsage `json:"readings"`
 }
 
 // KanjiListResponse for listing kanji
@@ -72,4 +299,58 @@ type KanjiListResponse struct {
 	Kanji       []Kanji `json:"kanji"`
 	TotalCount  int     `json:"total_count"`
 	Level       string  `json:"level"`
-}
\ No newline at end of file
+}
+
+// KanjiCandidate is a character the recogniser thinks was drawn
+type KanjiCandidate struct {
+	Character        string   `json:"character"`
+	Meaning          string   `json:"meaning"`
+	Readings         []string `json:"readings"`
+	JLPTLevel        string   `json:"jlpt_level"`
+	StrokeCount      int      `json:"stroke_count"`
+	Score            float64  `json:"score"`              // 0-100
+	ShapeScore       float64  `json:"shape_score"`        // Strokes in the right places, in any order
+	DirectionScore   float64  `json:"direction_score"`    // Sequence of stroke directions
+	StrokeCountScore float64  `json:"stroke_count_score"` // How close the number of strokes is
+}
+
+// KanjiRecognizeResult ranks the characters a drawing could be
+type KanjiRecognizeResult struct {
+	StrokeCount int              `json:"stroke_count"` // Strokes drawn
+	Candidates  []KanjiCandidate `json:"candidates"`
+}
+
+// KanjiWriteQuiz is a write-from-memory quiz: a recall session and its
+// prompt. The kanji is not part of it.
+type KanjiWriteQuiz struct {
+	SessionID string             `json:"session_id"`
+	Prompt    *KanjiRecallPrompt `json:"prompt"`
+	JLPTLevel string             `json:"jlpt_level"`
+}
+
+// Stroke diagram styles
+const (
+	KanjiSVGStatic   = "static"   // Every stroke drawn, numbered in order
+	KanjiSVGAnimated = "animated" // Strokes drawn one after another
+)
+
+// KanjiSVGOptions controls how a kanji's strokes are rendered
+type KanjiSVGOptions struct {
+	Style   string  // static, animated
+	Size    int     // Width and height in pixels
+	Numbers bool    // Number each stroke at its start
+	Grid    bool    // Dashed guide lines through the middle
+	Loop    bool    // Animated: start again once drawn
+	Speed   float64 // Animated: canvas units drawn per second
+}
+
+// KanjiSheetOptions lays out a practice sheet: for each character a model
+// with numbered strokes, the strokes added one at a time, faded copies to
+// trace over, and empty squares to fill the row
+type KanjiSheetOptions struct {
+	Characters []string
+	Columns    int  // Squares per row
+	Steps      bool // Include the stroke-by-stroke build-up
+	Guides     int  // Faded copies to trace; negative for the default
+	CellSize   int  // Pixels per square
+}
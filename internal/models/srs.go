@@ -9,7 +9,7 @@ type SRSSchedule struct {
 	ID             string    `json:"id" db:"id"`
 	UserID         string    `json:"user_id" db:"user_id"`
 	ItemID         string    `json:"item_id" db:"item_id"`
-	ItemType       string    `json:"item_type" db:"item_type"` // "vocabulary" or "grammar"
+	ItemType       string    `json:"item_type" db:"item_type"` // "vocabulary", "grammar" or "kanji"
 	IntervalDays   int       `json:"interval_days" db:"interval_days"`
 	Repetitions    int       `json:"repetitions" db:"repetitions"`
 	EaseFactor     float64   `json:"ease_factor" db:"ease_factor"`
@@ -56,7 +56,7 @@ type StudySession struct {
 // SRSReviewRequest is sent by client when reviewing an item
 type SRSReviewRequest struct {
 	ItemID         string `json:"item_id" binding:"required"`
-	ItemType       string `json:"item_type" binding:"required,oneof=vocabulary grammar"`
+	ItemType       string `json:"item_type" binding:"required,oneof=vocabulary grammar kanji"`
 	Quality        int    `json:"quality" binding:"required,min=0,max=5"` // 0-5 SM-2 rating
 	ResponseTimeMs int    `json:"response_time_ms"`                      // Optional
 }
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Vocabulary quiz question types
+const (
+	QuizTypeMeaningToWord = "meaning_to_word" // show meaning, pick the word
+	QuizTypeWordToMeaning = "word_to_meaning" // show word, pick the meaning
+	QuizTypeReading       = "reading"         // show kanji, pick the kana reading
+)
+
+// VocabQuizQuestion is a generated multiple-choice question, including its answer key
+type VocabQuizQuestion struct {
+	ID      string   `json:"id"`
+	VocabID string   `json:"vocab_id"`
+	Type    string   `json:"type"`
+	Prompt  string   `json:"prompt"`
+	Options []string `json:"options"`
+	Correct int      `json:"correct"` // Index of correct option
+}
+
+// VocabQuizPrompt is a question as sent to the client (no answer key)
+type VocabQuizPrompt struct {
+	ID      string   `json:"id"`
+	Type    string   `json:"type"`
+	Prompt  string   `json:"prompt"`
+	Options []string `json:"options"`
+}
+
+// VocabQuizAnswer records a user's answer to one question
+type VocabQuizAnswer struct {
+	QuestionID    string    `json:"question_id"`
+	VocabID       string    `json:"vocab_id"`
+	Selected      int       `json:"selected"`
+	IsCorrect     bool      `json:"is_correct"`
+	CorrectAnswer string    `json:"correct_answer"`
+	AddedToSRS    bool      `json:"added_to_srs"` // Missed items are queued for SRS review
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// VocabQuizSession tracks one run through a generated quiz
+type VocabQuizSession struct {
+	ID             string              `json:"id" db:"id"`
+	UserID         string              `json:"user_id" db:"user_id"`
+	Deck           string              `json:"deck" db:"deck"` // level, srs
+	JLPTLevel      string              `json:"jlpt_level,omitempty" db:"jlpt_level"`
+	Questions      []VocabQuizQuestion `json:"-" db:"questions"` // Never expose answer keys
+	Answers        []VocabQuizAnswer   `json:"answers" db:"answers"`
+	TotalQuestions int                 `json:"total_questions"`
+	Score          int                 `json:"score" db:"score"`   // Percentage correct
+	Status         string              `json:"status" db:"status"` // in_progress, completed
+	StartedAt      time.Time           `json:"started_at" db:"started_at"`
+	CompletedAt    *time.Time          `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// VocabQuizStartResponse is returned when a quiz is generated
+type VocabQuizStartResponse struct {
+	SessionID string            `json:"session_id"`
+	Deck      string            `json:"deck"`
+	JLPTLevel string            `json:"jlpt_level,omitempty"`
+	Questions []VocabQuizPrompt `json:"questions"`
+}
+
+// VocabQuizAnswerResult is returned after each answer
+type VocabQuizAnswerResult struct {
+	QuestionID    string `json:"question_id"`
+	IsCorrect     bool   `json:"is_correct"`
+	Correct       int    `json:"correct"`
+	CorrectAnswer string `json:"correct_answer"`
+	AddedToSRS    bool   `json:"added_to_srs"`
+	Answered      int    `json:"answered"`
+	Total         int    `json:"total"`
+	Score         int    `json:"score"`
+	Completed     bool   `json:"completed"`
+}
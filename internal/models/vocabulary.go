@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -108,8 +109,18 @@ func (rw *RelatedWords) Scan(value interface{}) error {
 	default:
 		return fmt.Errorf("failed to unmarshal RelatedWords JSONB value: expected []byte or string, got %T", value)
 	}
-	
-	return json.Unmarshal(bytes, rw)
+
+	// The SQLite column defaults to '[]' for rows that never had related words
+	trimmed := strings.TrimSpace(string(bytes))
+	if trimmed == "" || trimmed == "null" || trimmed == "[]" {
+		*rw = RelatedWords{}
+		return nil
+	}
+
+	if err := json.Unmarshal(bytes, rw); err != nil {
+		return fmt.Errorf("failed to unmarshal RelatedWords JSONB value: %w", err)
+	}
+	return nil
 }
 
 // Value implements driver.Valuer interface for RelatedWords
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Widget size variants control how much of each item is sent
+const (
+	WidgetSizeSmall  = "small"  // word + reading
+	WidgetSizeMedium = "medium" // + meaning
+	WidgetSizeLarge  = "large"  // + example sentence
+)
+
+// WidgetFeedItem is one entry on the widget timeline
+type WidgetFeedItem struct {
+	Type      string    `json:"type"` // "vocabulary" or "grammar"
+	ID        string    `json:"id"`
+	Text      string    `json:"text"`              // word or grammar pattern
+	Reading   string    `json:"reading,omitempty"` // reading or plain form
+	Meaning   string    `json:"meaning,omitempty"`
+	Example   string    `json:"example,omitempty"`
+	JLPTLevel string    `json:"jlpt_level"`
+	DisplayAt time.Time `json:"display_at"`
+}
+
+// WidgetFeed is the compact timeline returned to home-screen widgets
+type WidgetFeed struct {
+	Size            string           `json:"size"`
+	IntervalMinutes int              `json:"interval_minutes"`
+	GeneratedAt     time.Time        `json:"generated_at"`
+	ValidUntil      time.Time        `json:"valid_until"` // when the widget should refresh
+	StreakDays      int              `json:"streak_days"`
+	Items           []WidgetFeedItem `json:"items"`
+}
+
+// WidgetTokenResponse is returned when issuing a widget-scoped token
+type WidgetTokenResponse struct {
+	Token     string    `json:"token"`
+	Scope     string    `json:"scope"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
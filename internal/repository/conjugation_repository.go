@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/erwinwahyura/daily-kotoba/internal/db"
@@ -39,6 +40,59 @@ func (r *ConjugationRepository) GetChallengeByID(id string) (*models.Conjugation
 	return challenge, err
 }
 
+// GetChallengesByIDs retrieves challenges in the order of ids, skipping any
+// that no longer exist
+func (r *ConjugationRepository) GetChallengesByIDs(ids []string) ([]*models.ConjugationChallenge, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	query := `
+		SELECT id, base_form, reading, "group", target_form, target_ending,
+		       full_answer, hint, difficulty, jlpt_level, category, created_at
+		FROM conjugation_challenges
+		WHERE id IN (` + strings.Join(placeholders, ", ") + `)
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[string]*models.ConjugationChallenge, len(ids))
+	for rows.Next() {
+		c := &models.ConjugationChallenge{}
+		err := rows.Scan(
+			&c.ID, &c.BaseForm, &c.Reading, &c.Group,
+			&c.TargetForm, &c.TargetEnding, &c.FullAnswer,
+			&c.Hint, &c.Difficulty, &c.JLPTLevel,
+			&c.Category, &c.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		byID[c.ID] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	challenges := make([]*models.ConjugationChallenge, 0, len(ids))
+	for _, id := range ids {
+		if c, ok := byID[id]; ok {
+			challenges = append(challenges, c)
+		}
+	}
+	return challenges, nil
+}
+
 // GetChallengesByForm retrieves challenges for a specific form type and level
 func (r *ConjugationRepository) GetChallengesByForm(formType, jlptLevel string, limit int) ([]*models.ConjugationChallenge, error) {
 	if limit < 1 || limit > 50 {
@@ -134,7 +188,8 @@ func (r *ConjugationRepository) GetChallengesByFormUpToLevel(formType, maxLevel
 	return challenges, rows.Err()
 }
 
-// GetWeakPointsByForm gets accuracy stats per form for a user
+// GetWeakPointsByForm gets accuracy stats per form for a user. Chained forms
+// also get "failed_steps": how often each step was the first to go wrong.
 func (r *ConjugationRepository) GetWeakPointsByForm(userID string) (map[string]map[string]interface{}, error) {
 	query := `
 		SELECT 
@@ -175,8 +230,70 @@ func (r *ConjugationRepository) GetWeakPointsByForm(userID string) (map[string]m
 			"incorrect":     total - correct,
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stepQuery := `
+		SELECT form_type, failed_step, COUNT(*)
+		FROM conjugation_attempts
+		WHERE user_id = $1 AND failed_step IS NOT NULL
+		GROUP BY form_type, failed_step
+	`
+	stepRows, err := r.db.Query(stepQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer stepRows.Close()
+
+	for stepRows.Next() {
+		var form string
+		var step, n int
+		if err := stepRows.Scan(&form, &step, &n); err != nil {
+			return nil, err
+		}
+		stats, ok := weakPoints[form]
+		if !ok {
+			continue
+		}
+		failed, _ := stats["failed_steps"].(map[int]int)
+		if failed == nil {
+			failed = make(map[int]int)
+			stats["failed_steps"] = failed
+		}
+		failed[step] = n
+	}
 	
-	return weakPoints, rows.Err()
+	return weakPoints, stepRows.Err()
+}
+
+// GetErrorCategoriesByForm counts a user's wrong answers per form and error category
+func (r *ConjugationRepository) GetErrorCategoriesByForm(userID string) (map[string]map[string]int, error) {
+	query := `
+		SELECT form_type, error_category, COUNT(*)
+		FROM conjugation_attempts
+		WHERE user_id = $1 AND is_correct = false AND error_category IS NOT NULL
+		GROUP BY form_type, error_category
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]int)
+	for rows.Next() {
+		var form, category string
+		var n int
+		if err := rows.Scan(&form, &category, &n); err != nil {
+			return nil, err
+		}
+		if counts[form] == nil {
+			counts[form] = make(map[string]int)
+		}
+		counts[form][category] = n
+	}
+	return counts, rows.Err()
 }
 
 // GetChallengesForWeakPoint gets challenges for a specific weak form
@@ -275,7 +392,7 @@ func (r *ConjugationRepository) CreateOrUpdateChallenge(challenge *models.Conjug
 		ON CONFLICT (id) DO UPDATE SET
 		base_form = EXCLUDED.base_form,
 		reading = EXCLUDED.reading,
-		"group" = EXCLUDED.group,
+		"group" = EXCLUDED."group",
 		target_form = EXCLUDED.target_form,
 		target_ending = EXCLUDED.target_ending,
 		full_answer = EXCLUDED.full_answer,
@@ -295,58 +412,150 @@ func (r *ConjugationRepository) CreateOrUpdateChallenge(challenge *models.Conjug
 
 // Session methods
 
-// GetActiveSession retrieves user's active conjugation session
-func (r *ConjugationRepository) GetActiveSession(userID string) (*models.ConjugationSession, error) {
-	session := &models.ConjugationSession{}
-	var completedFormsJSON []byte
+const conjugationSessionColumns = `id, user_id, current_form, current_index, total_questions,
+		       correct_count, wrong_count, streak, max_streak, start_time, last_active, completed_forms,
+		       mode, time_limit_sec, ends_at, status, challenge_ids, max_level,
+		       is_weak_point_drill, target_weak_form, paused_at`
 
-	query := `
-		SELECT id, user_id, current_form, current_index, total_questions,
-		       correct_count, wrong_count, streak, max_streak, start_time, last_active, completed_forms
-		FROM conjugation_sessions
-		WHERE user_id = $1 AND last_active > $2
-		ORDER BY last_active DESC
-		LIMIT 1
-	`
-	// Sessions expire after 24 hours of inactivity
-	cutoff := time.Now().Add(-24 * time.Hour)
+// scanSession reads a row selected with conjugationSessionColumns
+func scanSession(row rowScanner) (*models.ConjugationSession, error) {
+	session := &models.ConjugationSession{}
+	var completedFormsJSON, challengeIDsJSON []byte
+	var timeLimit sql.NullInt64
+	var endsAt, pausedAt sql.NullTime
+	var maxLevel, targetWeakForm sql.NullString
 
-	err := r.db.QueryRow(query, userID, cutoff).Scan(
+	err := row.Scan(
 		&session.ID, &session.UserID, &session.CurrentForm, &session.CurrentIndex,
 		&session.TotalQuestions, &session.CorrectCount, &session.WrongCount,
 		&session.Streak, &session.MaxStreak, &session.StartTime,
 		&session.LastActive, &completedFormsJSON,
+		&session.Mode, &timeLimit, &endsAt,
+		&session.Status, &challengeIDsJSON, &maxLevel,
+		&session.IsWeakPointDrill, &targetWeakForm, &pausedAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
 		return nil, err
 	}
+	session.TimeLimitSec = int(timeLimit.Int64)
+	if endsAt.Valid {
+		session.EndsAt = &endsAt.Time
+	}
+	if pausedAt.Valid {
+		session.PausedAt = &pausedAt.Time
+	}
+	session.MaxLevel = maxLevel.String
+	session.TargetWeakForm = targetWeakForm.String
 
-	// Parse completed forms JSON
+	// Parse completed forms and challenge list JSON
 	if len(completedFormsJSON) > 0 {
 		json.Unmarshal(completedFormsJSON, &session.CompletedForms)
 	}
+	if len(challengeIDsJSON) > 0 {
+		json.Unmarshal(challengeIDsJSON, &session.ChallengeIDs)
+	}
 
 	return session, nil
 }
 
+// ListSessions retrieves a user's sessions, most recently active first.
+// An empty status lists every session.
+func (r *ConjugationRepository) ListSessions(userID, status string, limit int) ([]*models.ConjugationSession, error) {
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := `
+		SELECT ` + conjugationSessionColumns + `
+		FROM conjugation_sessions
+		WHERE user_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY last_active DESC
+		LIMIT $3
+	`
+	rows, err := r.db.Query(query, userID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.ConjugationSession
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// ExpireSessions marks a user's active sessions idle since activeCutoff, and
+// paused ones idle since pausedCutoff, as expired
+func (r *ConjugationRepository) ExpireSessions(userID string, activeCutoff, pausedCutoff time.Time) error {
+	query := `
+		UPDATE conjugation_sessions
+		SET status = $1
+		WHERE user_id = $2
+		  AND ((status = $3 AND last_active < $4) OR (status = $5 AND last_active < $6))
+	`
+	_, err := r.db.Exec(query,
+		models.ConjugationSessionExpired, userID,
+		models.ConjugationSessionActive, activeCutoff,
+		models.ConjugationSessionPaused, pausedCutoff,
+	)
+	return err
+}
+
+// GetSessionByID retrieves one of a user's sessions, whatever its status
+func (r *ConjugationRepository) GetSessionByID(id, userID string) (*models.ConjugationSession, error) {
+	query := `
+		SELECT ` + conjugationSessionColumns + `
+		FROM conjugation_sessions
+		WHERE id = $1 AND user_id = $2
+	`
+	session, err := scanSession(r.db.QueryRow(query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("session not found")
+	}
+	return session, err
+}
+
 // CreateSession creates a new conjugation session
 func (r *ConjugationRepository) CreateSession(session *models.ConjugationSession) error {
 	completedFormsJSON, _ := json.Marshal(session.CompletedForms)
+	challengeIDsJSON, _ := json.Marshal(session.ChallengeIDs)
 
 	query := `
 		INSERT INTO conjugation_sessions
 		(id, user_id, current_form, current_index, total_questions, correct_count,
-		 wrong_count, streak, max_streak, start_time, last_active, completed_forms)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		 wrong_count, streak, max_streak, start_time, last_active, completed_forms,
+		 mode, time_limit_sec, ends_at, status, challenge_ids, max_level,
+		 is_weak_point_drill, target_weak_form)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 	`
+	mode := session.Mode
+	if mode == "" {
+		mode = models.ConjugationModePractice
+	}
+	status := session.Status
+	if status == "" {
+		status = models.ConjugationSessionActive
+	}
+	var timeLimit sql.NullInt64
+	if session.TimeLimitSec > 0 {
+		timeLimit = sql.NullInt64{Int64: int64(session.TimeLimitSec), Valid: true}
+	}
+	var endsAt sql.NullTime
+	if session.EndsAt != nil {
+		endsAt = sql.NullTime{Time: *session.EndsAt, Valid: true}
+	}
 	_, err := r.db.Exec(query,
 		session.ID, session.UserID, session.CurrentForm, session.CurrentIndex,
 		session.TotalQuestions, session.CorrectCount, session.WrongCount,
 		session.Streak, session.MaxStreak, session.StartTime,
 		session.LastActive, completedFormsJSON,
+		mode, timeLimit, endsAt, status, challengeIDsJSON,
+		nullString(session.MaxLevel), session.IsWeakPointDrill, nullString(session.TargetWeakForm),
 	)
 	return err
 }
@@ -354,38 +563,150 @@ func (r *ConjugationRepository) CreateSession(session *models.ConjugationSession
 // UpdateSession updates an existing session
 func (r *ConjugationRepository) UpdateSession(session *models.ConjugationSession) error {
 	completedFormsJSON, _ := json.Marshal(session.CompletedForms)
+	challengeIDsJSON, _ := json.Marshal(session.ChallengeIDs)
+
+	var pausedAt sql.NullTime
+	if session.PausedAt != nil {
+		pausedAt = sql.NullTime{Time: *session.PausedAt, Valid: true}
+	}
+	var endsAt sql.NullTime
+	if session.EndsAt != nil {
+		endsAt = sql.NullTime{Time: *session.EndsAt, Valid: true}
+	}
 
 	query := `
 		UPDATE conjugation_sessions
 		SET current_form = $1, current_index = $2, total_questions = $3,
 		    correct_count = $4, wrong_count = $5, streak = $6, max_streak = $7,
-		    last_active = $8, completed_forms = $9
-		WHERE id = $10
+		    last_active = $8, completed_forms = $9, status = $10, challenge_ids = $11,
+		    paused_at = $12, ends_at = $13
+		WHERE id = $14
 	`
 	_, err := r.db.Exec(query,
 		session.CurrentForm, session.CurrentIndex, session.TotalQuestions,
 		session.CorrectCount, session.WrongCount, session.Streak, session.MaxStreak,
-		session.LastActive, completedFormsJSON, session.ID,
+		session.LastActive, completedFormsJSON, session.Status, challengeIDsJSON,
+		pausedAt, endsAt, session.ID,
 	)
 	return err
 }
 
+// nullString stores an empty string as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
 // RecordAttempt records a conjugation attempt
 func (r *ConjugationRepository) RecordAttempt(attempt *models.ConjugationAttempt) error {
 	query := `
 		INSERT INTO conjugation_attempts
 		(id, session_id, user_id, challenge_id, form_type, base_form, user_answer,
-		 is_correct, time_spent_sec, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 is_correct, error_category, failed_step, time_spent_sec, time_spent_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
+	var errorCategory sql.NullString
+	if attempt.ErrorCategory != "" {
+		errorCategory = sql.NullString{String: attempt.ErrorCategory, Valid: true}
+	}
+	var failedStep sql.NullInt64
+	if attempt.FailedStep != nil {
+		failedStep = sql.NullInt64{Int64: int64(*attempt.FailedStep), Valid: true}
+	}
 	_, err := r.db.Exec(query,
 		attempt.ID, attempt.SessionID, attempt.UserID, attempt.ChallengeID,
 		attempt.FormType, attempt.BaseForm, attempt.UserAnswer,
-		attempt.IsCorrect, attempt.TimeSpentSec, attempt.CreatedAt,
+		attempt.IsCorrect, errorCategory, failedStep, attempt.TimeSpentSec, attempt.TimeSpentMs, attempt.CreatedAt,
 	)
 	return err
 }
 
+// GetResponseTimes lists a user's timed attempts since a cutoff, oldest first.
+// An empty form means every form.
+func (r *ConjugationRepository) GetResponseTimes(userID, form string, since time.Time) ([]models.ResponseTime, error) {
+	query := `
+		SELECT form_type, is_correct, time_spent_ms, created_at
+		FROM conjugation_attempts
+		WHERE user_id = $1 AND created_at >= $2 AND time_spent_ms > 0
+		  AND ($3 = '' OR form_type = $3)
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(query, userID, since, form)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []models.ResponseTime
+	for rows.Next() {
+		var t models.ResponseTime
+		if err := rows.Scan(&t.Form, &t.IsCorrect, &t.Ms, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
+
+// GetSessionResponseTimes lists the response times of a session's correct answers
+func (r *ConjugationRepository) GetSessionResponseTimes(sessionID string) ([]int, error) {
+	rows, err := r.db.Query(`
+		SELECT time_spent_ms FROM conjugation_attempts
+		WHERE session_id = $1 AND is_correct = true AND time_spent_ms > 0
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []int
+	for rows.Next() {
+		var ms int
+		if err := rows.Scan(&ms); err != nil {
+			return nil, err
+		}
+		times = append(times, ms)
+	}
+	return times, rows.Err()
+}
+
+// GetBestSpeedRuns returns a user's best timed run per form and time limit
+func (r *ConjugationRepository) GetBestSpeedRuns(userID string) ([]models.SpeedRunBest, error) {
+	// SQLite returns the other columns from the row holding the MAX
+	query := `
+		SELECT current_form, time_limit_sec, MAX(correct_count), id, start_time
+		FROM conjugation_sessions
+		WHERE user_id = $1 AND mode = $2 AND time_limit_sec IS NOT NULL
+		GROUP BY current_form, time_limit_sec
+		ORDER BY current_form, time_limit_sec
+	`
+	rows, err := r.db.Query(query, userID, models.ConjugationModeTimed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bests []models.SpeedRunBest
+	for rows.Next() {
+		var b models.SpeedRunBest
+		if err := rows.Scan(&b.Form, &b.TimeLimitSec, &b.Score, &b.SessionID, &b.AchievedAt); err != nil {
+			return nil, err
+		}
+		bests = append(bests, b)
+	}
+	return bests, rows.Err()
+}
+
+// GetBestSpeedRunScore is a user's best score for a form and time limit,
+// leaving out one session (the run being scored)
+func (r *ConjugationRepository) GetBestSpeedRunScore(userID, form string, timeLimitSec int, excludeSessionID string) (int, error) {
+	var best int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(MAX(correct_count), 0) FROM conjugation_sessions
+		WHERE user_id = $1 AND mode = $2 AND current_form = $3 AND time_limit_sec = $4 AND id != $5
+	`, userID, models.ConjugationModeTimed, form, timeLimitSec, excludeSessionID).Scan(&best)
+	return best, err
+}
+
 // GetProgressStats retrieves user's conjugation progress
 func (r *ConjugationRepository) GetProgressStats(userID string) (*models.ConjugationProgress, error) {
 	// Calculate accuracy by form type
@@ -404,6 +725,7 @@ func (r *ConjugationRepository) GetProgressStats(userID string) (*models.Conjuga
 	defer rows.Close()
 
 	formMastery := make(map[string]float64)
+	formAttempts := make(map[string]int)
 	totalAttempts := 0
 	correctAttempts := 0
 
@@ -414,6 +736,7 @@ func (r *ConjugationRepository) GetProgressStats(userID string) (*models.Conjuga
 			continue
 		}
 		formMastery[formType] = float64(correct) / float64(total) * 100
+		formAttempts[formType] = total
 		totalAttempts += total
 		correctAttempts += correct
 	}
@@ -432,6 +755,7 @@ func (r *ConjugationRepository) GetProgressStats(userID string) (*models.Conjuga
 
 	return &models.ConjugationProgress{
 		FormMastery:     formMastery,
+		FormAttempts:    formAttempts,
 		TotalAttempts:   totalAttempts,
 		CorrectAttempts: correctAttempts,
 		AccuracyRate:    accuracy,
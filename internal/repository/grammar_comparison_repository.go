@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/db"
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// GrammarComparisonRepository handles curated grammar comparison data access
+type GrammarComparisonRepository struct {
+	db *db.DB
+}
+
+// NewGrammarComparisonRepository creates a new repository
+func NewGrammarComparisonRepository(db *db.DB) *GrammarComparisonRepository {
+	return &GrammarComparisonRepository{db: db}
+}
+
+const grammarComparisonColumns = `
+	id, pattern_a_id, pattern_b_id, jlpt_level, title, summary, aspects, example_pairs,
+	boundary_rules, decision_tree, common_errors, quiz, created_at
+`
+
+// GetByID retrieves one comparison
+func (r *GrammarComparisonRepository) GetByID(id string) (*models.GrammarComparison, error) {
+	query := `SELECT ` + grammarComparisonColumns + ` FROM grammar_comparisons WHERE id = $1`
+	return r.scanOne(r.db.QueryRow(query, id))
+}
+
+// GetByPair retrieves the comparison for two patterns in either order
+func (r *GrammarComparisonRepository) GetByPair(patternAID, patternBID string) (*models.GrammarComparison, error) {
+	query := `
+		SELECT ` + grammarComparisonColumns + `
+		FROM grammar_comparisons
+		WHERE (pattern_a_id = $1 AND pattern_b_id = $2)
+		   OR (pattern_a_id = $2 AND pattern_b_id = $1)
+		LIMIT 1
+	`
+	return r.scanOne(r.db.QueryRow(query, patternAID, patternBID))
+}
+
+// GetByLevel lists the comparisons for a JLPT level
+func (r *GrammarComparisonRepository) GetByLevel(level string) ([]models.GrammarComparison, error) {
+	query := `
+		SELECT ` + grammarComparisonColumns + `
+		FROM grammar_comparisons
+		WHERE jlpt_level = $1
+		ORDER BY title
+	`
+	rows, err := r.db.Query(query, level)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comparisons := []models.GrammarComparison{}
+	for rows.Next() {
+		c, err := r.scanOne(rows)
+		if err != nil {
+			return nil, err
+		}
+		comparisons = append(comparisons, *c)
+	}
+	return comparisons, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *GrammarComparisonRepository) scanOne(row rowScanner) (*models.GrammarComparison, error) {
+	c := &models.GrammarComparison{}
+	var summary sql.NullString
+	var aspects, examples, boundaries, tree, errs, quiz []byte
+
+	err := row.Scan(
+		&c.ID, &c.PatternAID, &c.PatternBID, &c.JLPTLevel, &c.Title, &summary,
+		&aspects, &examples, &boundaries, &tree, &errs, &quiz, &c.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("grammar comparison not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Summary = summary.String
+
+	for _, field := range []struct {
+		name string
+		data []byte
+		dest interface{}
+	}{
+		{"aspects", aspects, &c.Aspects},
+		{"example_pairs", examples, &c.ExamplePairs},
+		{"boundary_rules", boundaries, &c.BoundaryRules},
+		{"decision_tree", tree, &c.DecisionTree},
+		{"common_errors", errs, &c.CommonErrors},
+		{"quiz", quiz, &c.Quiz},
+	} {
+		if len(field.data) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(field.data, field.dest); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", field.name, err)
+		}
+	}
+	c.QuizCount = len(c.Quiz)
+
+	return c, nil
+}
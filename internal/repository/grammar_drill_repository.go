@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/db"
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// GrammarDrillRepository handles grammar cloze drill attempts
+type GrammarDrillRepository struct {
+	db *db.DB
+}
+
+// NewGrammarDrillRepository creates a new repository
+func NewGrammarDrillRepository(db *db.DB) *GrammarDrillRepository {
+	return &GrammarDrillRepository{db: db}
+}
+
+// RecordAttempt stores one answered cloze
+func (r *GrammarDrillRepository) RecordAttempt(attempt *models.GrammarDrillAttempt) error {
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO grammar_drill_attempts (id, user_id, pattern_id, example_index, mode,
+			user_answer, correct_answer, is_correct, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := r.db.Exec(query, attempt.ID, attempt.UserID, attempt.PatternID, attempt.ExampleIndex,
+		attempt.Mode, attempt.UserAnswer, attempt.CorrectAnswer, attempt.IsCorrect, attempt.CreatedAt)
+	return err
+}
+
+// GetAccuracyByPattern returns drill accuracy per pattern for a user, weakest first
+func (r *GrammarDrillRepository) GetAccuracyByPattern(userID string) ([]models.WeakPattern, error) {
+	query := `
+		SELECT
+			a.pattern_id,
+			COALESCE(MAX(g.pattern), a.pattern_id),
+			COUNT(a.id) as total_attempts,
+			AVG(CASE WHEN a.is_correct THEN 100.0 ELSE 0.0 END) as accuracy
+		FROM grammar_drill_attempts a
+		LEFT JOIN grammar_patterns g ON g.id = a.pattern_id
+		WHERE a.user_id = $1
+		GROUP BY a.pattern_id
+		ORDER BY accuracy ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.WeakPattern
+	for rows.Next() {
+		var wp models.WeakPattern
+		if err := rows.Scan(&wp.PatternID, &wp.Pattern, &wp.Total, &wp.Accuracy); err != nil {
+			return nil, err
+		}
+		stats = append(stats, wp)
+	}
+
+	return stats, rows.Err()
+}
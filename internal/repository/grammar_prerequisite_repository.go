@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/db"
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// GrammarPrerequisiteRepository handles grammar curriculum edges
+type GrammarPrerequisiteRepository struct {
+	db *db.DB
+}
+
+// NewGrammarPrerequisiteRepository creates a new repository
+func NewGrammarPrerequisiteRepository(db *db.DB) *GrammarPrerequisiteRepository {
+	return &GrammarPrerequisiteRepository{db: db}
+}
+
+// GetByLevel returns the prerequisites of every pattern in a level, keyed by pattern ID
+func (r *GrammarPrerequisiteRepository) GetByLevel(level string) (map[string][]models.GrammarPrerequisite, error) {
+	query := `
+		SELECT p.id, p.grammar_id, p.prerequisite_type, p.prerequisite_id, p.label, p.note
+		FROM grammar_prerequisites p
+		JOIN grammar_patterns g ON g.id = p.grammar_id
+		WHERE g.jlpt_level = $1
+		ORDER BY p.prerequisite_type, p.prerequisite_id
+	`
+	rows, err := r.db.Query(query, level)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prereqs := make(map[string][]models.GrammarPrerequisite)
+	for rows.Next() {
+		var p models.GrammarPrerequisite
+		var label, note sql.NullString
+		if err := rows.Scan(&p.ID, &p.GrammarID, &p.PrerequisiteType, &p.PrerequisiteID, &label, &note); err != nil {
+			return nil, err
+		}
+		p.Label = label.String
+		p.Note = note.String
+		prereqs[p.GrammarID] = append(prereqs[p.GrammarID], p)
+	}
+	return prereqs, rows.Err()
+}
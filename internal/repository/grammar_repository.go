@@ -150,6 +150,40 @@ func (r *GrammarRepository) GetByLevel(level string, page, limit int) ([]models.
 	return patterns, total, nil
 }
 
+// GetRangeByLevel returns up to limit patterns starting at fromIndex, in study order
+func (r *GrammarRepository) GetRangeByLevel(level string, fromIndex, limit int) ([]models.GrammarPattern, error) {
+	query := `
+		SELECT id, pattern, plain_form, meaning, detailed_explanation,
+		       conjugation_rules, usage_examples, nuance_notes, jlpt_level,
+		       related_patterns, common_mistakes, index_position, created_at
+		FROM grammar_patterns
+		WHERE jlpt_level = $1 AND index_position >= $2
+		ORDER BY index_position
+		LIMIT $3
+	`
+	rows, err := r.db.Query(query, level, fromIndex, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var patterns []models.GrammarPattern
+	for rows.Next() {
+		var p models.GrammarPattern
+		err := rows.Scan(
+			&p.ID, &p.Pattern, &p.PlainForm, &p.Meaning, &p.DetailedExplanation,
+			&p.ConjugationRules, &p.UsageExamples, &p.NuanceNotes, &p.JLPTLevel,
+			&p.RelatedPatterns, &p.CommonMistakes, &p.IndexPosition, &p.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+
+	return patterns, rows.Err()
+}
+
 func (r *GrammarRepository) GetTotalCountByLevel(level string) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM grammar_patterns WHERE jlpt_level = $1`
@@ -248,3 +282,35 @@ func (r *GrammarRepository) Search(query, level string) ([]*models.GrammarPatter
 	
 	return results, rows.Err()
 }
+
+// GetAll returns every grammar pattern across all levels
+func (r *GrammarRepository) GetAll() ([]models.GrammarPattern, error) {
+	query := `
+		SELECT id, pattern, plain_form, meaning, detailed_explanation,
+		       conjugation_rules, usage_examples, nuance_notes, jlpt_level,
+		       related_patterns, common_mistakes, index_position, created_at
+		FROM grammar_patterns
+		ORDER BY jlpt_level DESC, index_position
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var patterns []models.GrammarPattern
+	for rows.Next() {
+		var p models.GrammarPattern
+		err := rows.Scan(
+			&p.ID, &p.Pattern, &p.PlainForm, &p.Meaning, &p.DetailedExplanation,
+			&p.ConjugationRules, &p.UsageExamples, &p.NuanceNotes, &p.JLPTLevel,
+			&p.RelatedPatterns, &p.CommonMistakes, &p.IndexPosition, &p.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+
+	return patterns, rows.Err()
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -52,7 +53,7 @@ func (r *JLPTRepository) GetTestByID(testID string) (*models.JLPTTest, error) {
 
 // GetQuestionsByTestID retrieves all questions for a test
 func (r *JLPTRepository) GetQuestionsByTestID(testID string) ([]models.JLPTQuestion, error) {
-	query := `SELECT id, test_id, question_num, type, question, question_reading, english_prompt, options, correct_index, explanation, point_value, skill_tested FROM jlpt_questions WHERE test_id = $1 ORDER BY question_num`
+	query := `SELECT id, test_id, question_num, type, question, COALESCE(question_reading, ''), COALESCE(english_prompt, ''), options, correct_index, explanation, point_value, skill_tested, section_num FROM jlpt_questions WHERE test_id = $1 ORDER BY section_num, question_num`
 	rows, err := r.db.Query(query, testID)
 	if err != nil {
 		return nil, err
@@ -63,21 +64,49 @@ func (r *JLPTRepository) GetQuestionsByTestID(testID string) ([]models.JLPTQuest
 	for rows.Next() {
 		var q models.JLPTQuestion
 		var optionsJSON []byte
-		err := rows.Scan(&q.ID, &q.TestID, &q.QuestionNum, &q.Type, &q.Question, &q.QuestionReading, &q.EnglishPrompt, &optionsJSON, &q.CorrectIndex, &q.Explanation, &q.PointValue, &q.SkillTested)
+		err := rows.Scan(&q.ID, &q.TestID, &q.QuestionNum, &q.Type, &q.Question, &q.QuestionReading, &q.EnglishPrompt, &optionsJSON, &q.CorrectIndex, &q.Explanation, &q.PointValue, &q.SkillTested, &q.SectionNum)
 		if err != nil {
 			continue
 		}
-		json.Unmarshal(optionsJSON, &q.Options)
+		if err := json.Unmarshal(optionsJSON, &q.Options); err != nil {
+			// Seeded options were stored as a JSON string holding the array
+			var encoded string
+			if json.Unmarshal(optionsJSON, &encoded) == nil {
+				json.Unmarshal([]byte(encoded), &q.Options)
+			}
+		}
 		questions = append(questions, q)
 	}
 	return questions, rows.Err()
 }
 
+// GetTestSections retrieves a test's timed sections in order; tests timed
+// as a whole have none
+func (r *JLPTRepository) GetTestSections(testID string) ([]models.JLPTTestSection, error) {
+	query := `SELECT test_id, section_num, name, time_limit_minutes FROM jlpt_test_sections WHERE test_id = $1 ORDER BY section_num`
+	rows, err := r.db.Query(query, testID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sections []models.JLPTTestSection
+	for rows.Next() {
+		var sec models.JLPTTestSection
+		if err := rows.Scan(&sec.TestID, &sec.SectionNum, &sec.Name, &sec.TimeLimit); err != nil {
+			return nil, err
+		}
+		sections = append(sections, sec)
+	}
+	return sections, rows.Err()
+}
+
 // CreateTestSession starts a new test session
 func (r *JLPTRepository) CreateTestSession(session *models.UserTestSession) error {
-	query := `INSERT INTO user_test_sessions (id, user_id, test_id, level, started_at, status, answers) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	query := `INSERT INTO user_test_sessions (id, user_id, test_id, level, started_at, status, answers, current_section, section_started_at, section_ends_at, ends_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
 	answersJSON, _ := json.Marshal(session.Answers)
-	_, err := r.db.Exec(query, session.ID, session.UserID, session.TestID, session.Level, session.StartedAt, session.Status, string(answersJSON))
+	_, err := r.db.Exec(query, session.ID, session.UserID, session.TestID, session.Level, session.StartedAt, session.Status, string(answersJSON),
+		session.CurrentSection, session.SectionStartedAt, session.SectionEndsAt, session.EndsAt)
 	return err
 }
 
@@ -85,8 +114,10 @@ func (r *JLPTRepository) CreateTestSession(session *models.UserTestSession) erro
 func (r *JLPTRepository) GetTestSession(sessionID string) (*models.UserTestSession, error) {
 	var s models.UserTestSession
 	var answersJSON string
-	query := `SELECT id, user_id, test_id, level, started_at, completed_at, time_spent_sec, answers, score, correct_count, status FROM user_test_sessions WHERE id = $1`
-	err := r.db.QueryRow(query, sessionID).Scan(&s.ID, &s.UserID, &s.TestID, &s.Level, &s.StartedAt, &s.CompletedAt, &s.TimeSpentSec, &answersJSON, &s.Score, &s.CorrectCount, &s.Status)
+	query := `SELECT id, user_id, test_id, level, started_at, completed_at, time_spent_sec, answers, score, correct_count, status,
+		current_section, section_started_at, section_ends_at, ends_at, auto_submitted, scaled_score, passed FROM user_test_sessions WHERE id = $1`
+	err := r.db.QueryRow(query, sessionID).Scan(&s.ID, &s.UserID, &s.TestID, &s.Level, &s.StartedAt, &s.CompletedAt, &s.TimeSpentSec, &answersJSON, &s.Score, &s.CorrectCount, &s.Status,
+		&s.CurrentSection, &s.SectionStartedAt, &s.SectionEndsAt, &s.EndsAt, &s.AutoSubmitted, &s.ScaledScore, &s.Passed)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("session not found")
 	}
@@ -114,17 +145,63 @@ func (r *JLPTRepository) UpdateSessionAnswer(sessionID string, questionID string
 	return err
 }
 
-// CompleteTestSession marks session complete with score
-func (r *JLPTRepository) CompleteTestSession(sessionID string, score, correctCount, timeSpent int) error {
-	now := time.Now()
-	query := `UPDATE user_test_sessions SET status = 'completed', completed_at = $1, score = $2, correct_count = $3, time_spent_sec = $4 WHERE id = $5`
-	_, err := r.db.Exec(query, now, score, correctCount, timeSpent, sessionID)
+// UpdateSessionSchedule saves which section a session is on and when it
+// and the whole test run out
+func (r *JLPTRepository) UpdateSessionSchedule(session *models.UserTestSession) error {
+	query := `UPDATE user_test_sessions SET current_section = $1, section_started_at = $2, section_ends_at = $3, ends_at = $4 WHERE id = $5`
+	_, err := r.db.Exec(query, session.CurrentSection, session.SectionStartedAt, session.SectionEndsAt, session.EndsAt, session.ID)
 	return err
 }
 
+// ErrTestSessionClosed is returned when a session was no longer in progress,
+// having been completed or abandoned in the meantime
+var ErrTestSessionClosed = errors.New("test session is no longer in progress")
+
+// CompleteTestSession marks session complete with its final answers and
+// scores, if it is still in progress
+func (r *JLPTRepository) CompleteTestSession(session *models.UserTestSession) error {
+	now := time.Now()
+	answersJSON, _ := json.Marshal(session.Answers)
+	query := `UPDATE user_test_sessions SET status = 'completed', completed_at = $1, score = $2, correct_count = $3, time_spent_sec = $4, auto_submitted = $5, scaled_score = $6, passed = $7, answers = $8 WHERE id = $9 AND status = 'in_progress'`
+	result, err := r.db.Exec(query, now, session.Score, session.CorrectCount, session.TimeSpentSec, session.AutoSubmitted, session.ScaledScore, session.Passed, string(answersJSON), session.ID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTestSessionClosed
+	}
+	session.CompletedAt = &now
+	return nil
+}
+
+// GetDueTestSessionIDs lists the sessions still in progress whose time ran
+// out by a moment, and those started before deadlines were kept
+func (r *JLPTRepository) GetDueTestSessionIDs(now time.Time) ([]string, error) {
+	query := `SELECT id FROM user_test_sessions WHERE status = $1 AND (ends_at IS NULL OR ends_at <= $2)`
+	rows, err := r.db.Query(query, models.TestSessionInProgress, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // GetUserTestHistory gets all tests taken by user
 func (r *JLPTRepository) GetUserTestHistory(userID string) ([]models.UserTestSession, error) {
-	query := `SELECT id, test_id, level, started_at, completed_at, score, correct_count, status FROM user_test_sessions WHERE user_id = $1 ORDER BY started_at DESC`
+	query := `SELECT id, test_id, level, started_at, completed_at, score, correct_count, status, auto_submitted, scaled_score, passed FROM user_test_sessions WHERE user_id = $1 ORDER BY started_at DESC`
 	rows, err := r.db.Query(query, userID)
 	if err != nil {
 		return nil, err
@@ -134,7 +211,7 @@ func (r *JLPTRepository) GetUserTestHistory(userID string) ([]models.UserTestSes
 	var sessions []models.UserTestSession
 	for rows.Next() {
 		var s models.UserTestSession
-		err := rows.Scan(&s.ID, &s.TestID, &s.Level, &s.StartedAt, &s.CompletedAt, &s.Score, &s.CorrectCount, &s.Status)
+		err := rows.Scan(&s.ID, &s.TestID, &s.Level, &s.StartedAt, &s.CompletedAt, &s.Score, &s.CorrectCount, &s.Status, &s.AutoSubmitted, &s.ScaledScore, &s.Passed)
 		if err != nil {
 			continue
 		}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/erwinwahyura/daily-kotoba/internal/db"
@@ -22,15 +23,64 @@ func NewKanjiRepository(db *db.DB) *KanjiRepository {
 
 // GetKanjiByCharacter retrieves kanji by character
 func (r *KanjiRepository) GetKanjiByCharacter(char string) (*models.Kanji, error) {
-	kanji := &models.Kanji{}
-	var readingsJSON, strokeOrderJSON []byte
+	kanji, err := scanKanji(r.db.QueryRow(`SELECT `+kanjiColumns+` FROM kanji WHERE character = $1`, char))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("kanji not found: %s", char)
+	}
+	return kanji, err
+}
 
-	query := `
-		SELECT id, character, jlpt_level, meaning, readings, stroke_count, stroke_order, created_at
-		FROM kanji WHERE character = $1
-	`
+// GetKanjiByLevel retrieves kanji by JLPT level
+func (r *KanjiRepository) GetKanjiByLevel(level string, limit int) ([]models.Kanji, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `SELECT ` + kanjiColumns + ` FROM kanji WHERE jlpt_level = $1 ORDER BY stroke_count ASC, character ASC LIMIT $2`
+
+	rows, err := r.db.Query(query, level, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	err := r.db.QueryRow(query, char).Scan(
+	var kanjiList []models.Kanji
+	for rows.Next() {
+		kanji, err := scanKanji(rows)
+		if err != nil {
+			continue
+		}
+		kanjiList = append(kanjiList, *kanji)
+	}
+
+	return kanjiList, rows.Err()
+}
+
+// GetAllKanji retrieves every kanji
+func (r *KanjiRepository) GetAllKanji() ([]*models.Kanji, error) {
+	rows, err := r.db.Query(`SELECT ` + kanjiColumns + ` FROM kanji ORDER BY character`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kanjiList []*models.Kanji
+	for rows.Next() {
+		kanji, err := scanKanji(rows)
+		if err != nil {
+			return nil, err
+		}
+		kanjiList = append(kanjiList, kanji)
+	}
+	return kanjiList, rows.Err()
+}
+
+const kanjiColumns = `id, character, jlpt_level, meaning, readings, stroke_count, stroke_order, created_at, on_readings`
+
+func scanKanji(row rowScanner) (*models.Kanji, error) {
+	kanji := &models.Kanji{}
+	var readingsJSON, strokeOrderJSON, onReadingsJSON []byte
+	err := row.Scan(
 		&kanji.ID,
 		&kanji.Character,
 		&kanji.JLPTLevel,
@@ -39,79 +89,71 @@ func (r *KanjiRepository) GetKanjiByCharacter(char string) (*models.Kanji, error
 		&kanji.StrokeCount,
 		&strokeOrderJSON,
 		&kanji.CreatedAt,
+		&onReadingsJSON,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("kanji not found: %s", char)
-		}
 		return nil, err
 	}
-
-	// Parse JSON arrays
+	if err := json.Unmarshal(onReadingsJSON, &kanji.OnReadings); err != nil {
+		return nil, fmt.Errorf("failed to parse on readings: %w", err)
+	}
 	if err := json.Unmarshal(readingsJSON, &kanji.Readings); err != nil {
 		return nil, fmt.Errorf("failed to parse readings: %w", err)
 	}
 	if err := json.Unmarshal(strokeOrderJSON, &kanji.StrokeOrder); err != nil {
 		return nil, fmt.Errorf("failed to parse stroke order: %w", err)
 	}
-
 	return kanji, nil
 }
 
-// GetKanjiByLevel retrieves kanji by JLPT level
-func (r *KanjiRepository) GetKanjiByLevel(level string, limit int) ([]models.Kanji, error) {
-	if limit <= 0 {
-		limit = 50
+// GetKanjiByID retrieves kanji by ID
+func (r *KanjiRepository) GetKanjiByID(id string) (*models.Kanji, error) {
+	kanji, err := scanKanji(r.db.QueryRow(`SELECT `+kanjiColumns+` FROM kanji WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("kanji not found: %s", id)
 	}
+	return kanji, err
+}
 
-	query := `
-		SELECT id, character, jlpt_level, meaning, readings, stroke_count, stroke_order, created_at
-		FROM kanji WHERE jlpt_level = $1 ORDER BY stroke_count ASC, character ASC LIMIT $2
-	`
-
-	rows, err := r.db.Query(query, level, limit)
+// GetKanjiByStrokeCount retrieves every kanji with a stroke count in a range
+func (r *KanjiRepository) GetKanjiByStrokeCount(minCount, maxCount int) ([]*models.Kanji, error) {
+	query := `SELECT ` + kanjiColumns + ` FROM kanji WHERE stroke_count BETWEEN $1 AND $2 ORDER BY stroke_count, character`
+	rows, err := r.db.Query(query, minCount, maxCount)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var kanjiList []models.Kanji
+	var kanjiList []*models.Kanji
 	for rows.Next() {
-		kanji := models.Kanji{}
-		var readingsJSON, strokeOrderJSON []byte
-
-		err := rows.Scan(
-			&kanji.ID,
-			&kanji.Character,
-			&kanji.JLPTLevel,
-			&kanji.Meaning,
-			&readingsJSON,
-			&kanji.StrokeCount,
-			&strokeOrderJSON,
-			&kanji.CreatedAt,
-		)
+		kanji, err := scanKanji(rows)
 		if err != nil {
-			continue
+			return nil, err
 		}
-
-		// Parse JSON
-		json.Unmarshal(readingsJSON, &kanji.Readings)
-		json.Unmarshal(strokeOrderJSON, &kanji.StrokeOrder)
-
 		kanjiList = append(kanjiList, kanji)
 	}
-
 	return kanjiList, rows.Err()
 }
 
+// GetRandomKanji picks a kanji of a JLPT level at random
+func (r *KanjiRepository) GetRandomKanji(level string) (*models.Kanji, error) {
+	kanji, err := scanKanji(r.db.QueryRow(`SELECT `+kanjiColumns+` FROM kanji WHERE jlpt_level = $1 ORDER BY RANDOM() LIMIT 1`, level))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no kanji for level %s", level)
+	}
+	return kanji, err
+}
+
 // CreatePracticeSession creates a new practice session
 func (r *KanjiRepository) CreatePracticeSession(session *models.KanjiPracticeSession) error {
 	userStrokesJSON, _ := json.Marshal(session.UserStrokes)
+	if session.Mode == "" {
+		session.Mode = models.KanjiModeTrace
+	}
 
 	query := `
-		INSERT INTO kanji_practice_sessions (id, user_id, kanji_id, kanji_char, started_at, status, user_strokes, accuracy)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO kanji_practice_sessions (id, user_id, kanji_id, kanji_char, started_at, status, user_strokes, accuracy, mode, prompt)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 
 	_, err := r.db.Exec(query,
@@ -123,6 +165,8 @@ func (r *KanjiRepository) CreatePracticeSession(session *models.KanjiPracticeSes
 		session.Status,
 		userStrokesJSON,
 		session.Accuracy,
+		session.Mode,
+		nullJSON(session.Prompt),
 	)
 
 	return err
@@ -141,8 +185,8 @@ func (r *KanjiRepository) UpdatePracticeSession(session *models.KanjiPracticeSes
 
 	query := `
 		UPDATE kanji_practice_sessions 
-		SET user_strokes = $1, accuracy = $2, status = $3, completed_at = $4
-		WHERE id = $5
+		SET user_strokes = $1, accuracy = $2, status = $3, completed_at = $4, recall_result = $5, srs_quality = $6
+		WHERE id = $7
 	`
 
 	_, err := r.db.Exec(query,
@@ -150,6 +194,8 @@ func (r *KanjiRepository) UpdatePracticeSession(session *models.KanjiPracticeSes
 		session.Accuracy,
 		session.Status,
 		completedAt,
+		nullJSON(session.RecallResult),
+		session.SRSQuality,
 		session.ID,
 	)
 
@@ -161,9 +207,12 @@ func (r *KanjiRepository) GetPracticeSession(sessionID string) (*models.KanjiPra
 	session := &models.KanjiPracticeSession{}
 	var userStrokesJSON []byte
 	var completedAt sql.NullTime
+	var prompt, recallResult sql.NullString
+	var srsQuality sql.NullInt64
 
 	query := `
-		SELECT id, user_id, kanji_id, kanji_char, started_at, completed_at, user_strokes, accuracy, status
+		SELECT id, user_id, kanji_id, kanji_char, started_at, completed_at, user_strokes, accuracy, status,
+		       mode, prompt, recall_result, srs_quality
 		FROM kanji_practice_sessions WHERE id = $1
 	`
 
@@ -177,6 +226,10 @@ func (r *KanjiRepository) GetPracticeSession(sessionID string) (*models.KanjiPra
 		&userStrokesJSON,
 		&session.Accuracy,
 		&session.Status,
+		&session.Mode,
+		&prompt,
+		&recallResult,
+		&srsQuality,
 	)
 
 	if err != nil {
@@ -188,10 +241,34 @@ func (r *KanjiRepository) GetPracticeSession(sessionID string) (*models.KanjiPra
 	}
 
 	json.Unmarshal(userStrokesJSON, &session.UserStrokes)
+	if prompt.Valid {
+		session.Prompt = &models.KanjiRecallPrompt{}
+		json.Unmarshal([]byte(prompt.String), session.Prompt)
+	}
+	if recallResult.Valid {
+		session.RecallResult = &models.KanjiRecallResult{}
+		json.Unmarshal([]byte(recallResult.String), session.RecallResult)
+	}
+	if srsQuality.Valid {
+		q := int(srsQuality.Int64)
+		session.SRSQuality = &q
+	}
 
 	return session, nil
 }
 
+// nullJSON stores a value as JSON, or NULL when there is none
+func nullJSON[T any](v *T) sql.NullString {
+	if v == nil {
+		return sql.NullString{}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(b), Valid: true}
+}
+
 // GetUserKanjiStats gets user's kanji practice stats
 func (r *KanjiRepository) GetUserKanjiStats(userID string) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
@@ -211,9 +288,429 @@ func (r *KanjiRepository) GetUserKanjiStats(userID string) (map[string]interface
 	r.db.QueryRow("SELECT COALESCE(AVG(accuracy), 0) FROM kanji_practice_sessions WHERE user_id = $1 AND status = 'completed'", userID).Scan(&avgAccuracy)
 	stats["average_accuracy"] = avgAccuracy
 
+	// Recall (write from memory) sessions, graded as whole characters
+	var recallGraded, recallRecalled int
+	r.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN srs_quality >= 3 THEN 1 ELSE 0 END), 0)
+		FROM kanji_practice_sessions WHERE user_id = $1 AND mode = 'recall' AND srs_quality IS NOT NULL
+	`, userID).Scan(&recallGraded, &recallRecalled)
+	stats["recall_attempts"] = recallGraded
+	stats["recall_correct"] = recallRecalled
+
 	return stats, nil
 }
 
+// RecordStrokeErrors saves the stroke errors of a finished practice session
+func (r *KanjiRepository) RecordStrokeErrors(session *models.KanjiPracticeSession, errors []models.KanjiStrokeError) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO kanji_stroke_errors (id, user_id, session_id, kanji_id, kanji_char, stroke_num, error_type, rule)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, e := range errors {
+		var rule sql.NullString
+		if e.Rule != "" {
+			rule = sql.NullString{String: e.Rule, Valid: true}
+		}
+		if _, err := stmt.Exec(fmt.Sprintf("%s-%d", session.ID, i+1), session.UserID, session.ID,
+			session.KanjiID, session.KanjiChar, e.StrokeNum, e.Type, rule); err != nil {
+			return fmt.Errorf("failed to record stroke error: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetStrokeErrorStats counts a user's stroke errors by type, and the
+// stroke-order rules they break with the kanji they broke them on
+func (r *KanjiRepository) GetStrokeErrorStats(userID string) (*models.KanjiStrokeErrorStats, error) {
+	stats := &models.KanjiStrokeErrorStats{
+		ErrorTypes: map[string]int{},
+		Rules:      []models.StrokeOrderRuleStat{},
+	}
+
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM kanji_practice_sessions
+		WHERE user_id = $1 AND mode = 'trace' AND status = 'completed'
+	`, userID).Scan(&stats.SessionsReviewed)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT error_type, COUNT(*) FROM kanji_stroke_errors
+		WHERE user_id = $1 GROUP BY error_type
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var errorType string
+		var count int
+		if err := rows.Scan(&errorType, &count); err != nil {
+			return nil, err
+		}
+		stats.ErrorTypes[errorType] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ruleRows, err := r.db.Query(`
+		SELECT rule, kanji_char, COUNT(*) AS n FROM kanji_stroke_errors
+		WHERE user_id = $1 AND rule IS NOT NULL
+		GROUP BY rule, kanji_char
+		ORDER BY n DESC, kanji_char
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer ruleRows.Close()
+	index := map[string]int{}
+	for ruleRows.Next() {
+		var rule, kanjiChar string
+		var count int
+		if err := ruleRows.Scan(&rule, &kanjiChar, &count); err != nil {
+			return nil, err
+		}
+		i, ok := index[rule]
+		if !ok {
+			i = len(stats.Rules)
+			index[rule] = i
+			stats.Rules = append(stats.Rules, models.StrokeOrderRuleStat{Rule: rule, Kanji: []string{}})
+		}
+		stats.Rules[i].Count += count
+		stats.Rules[i].Kanji = append(stats.Rules[i].Kanji, kanjiChar)
+	}
+	return stats, ruleRows.Err()
+}
+
+// ReplaceComponents replaces every radical and component decomposition with
+// a fresh import
+func (r *KanjiRepository) ReplaceComponents(radicals []models.Radical, components map[string][]string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM radicals`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM kanji_components`); err != nil {
+		return err
+	}
+
+	radicalStmt, err := tx.Prepare(`INSERT INTO radicals (character, stroke_count) VALUES ($1, $2)`)
+	if err != nil {
+		return err
+	}
+	defer radicalStmt.Close()
+	for _, rad := range radicals {
+		if _, err := radicalStmt.Exec(rad.Character, rad.StrokeCount); err != nil {
+			return fmt.Errorf("failed to insert radical %s: %w", rad.Character, err)
+		}
+	}
+
+	componentStmt, err := tx.Prepare(`INSERT INTO kanji_components (kanji_char, component, position) VALUES ($1, $2, $3)`)
+	if err != nil {
+		return err
+	}
+	defer componentStmt.Close()
+	for char, parts := range components {
+		for i, part := range parts {
+			if _, err := componentStmt.Exec(char, part, i); err != nil {
+				return fmt.Errorf("failed to insert components of %s: %w", char, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetRadicals lists the radicals by stroke count
+func (r *KanjiRepository) GetRadicals() ([]models.Radical, error) {
+	rows, err := r.db.Query(`SELECT character, stroke_count FROM radicals ORDER BY stroke_count, character`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	radicals := []models.Radical{}
+	for rows.Next() {
+		var rad models.Radical
+		if err := rows.Scan(&rad.Character, &rad.StrokeCount); err != nil {
+			return nil, err
+		}
+		radicals = append(radicals, rad)
+	}
+	return radicals, rows.Err()
+}
+
+// GetComponents gets the components of each of a set of characters, in
+// KRADFILE order. Characters without any are left out.
+func (r *KanjiRepository) GetComponents(chars []string) (map[string][]string, error) {
+	components := make(map[string][]string)
+	if len(chars) == 0 {
+		return components, nil
+	}
+
+	placeholders, args := inPlaceholders(chars, 1)
+	rows, err := r.db.Query(`
+		SELECT kanji_char, component FROM kanji_components
+		WHERE kanji_char IN (`+placeholders+`)
+		ORDER BY kanji_char, position
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var char, component string
+		if err := rows.Scan(&char, &component); err != nil {
+			return nil, err
+		}
+		components[char] = append(components[char], component)
+	}
+	return components, rows.Err()
+}
+
+// SearchKanjiByComponents finds the kanji containing every one of a set of
+// components, optionally within a stroke count range (0 for no bound). It
+// also returns how many match in all, and every component found in them.
+func (r *KanjiRepository) SearchKanjiByComponents(components []string, minStrokes, maxStrokes, limit int) ([]*models.Kanji, int, []string, error) {
+	where := "WHERE 1=1"
+	var args []interface{}
+	if len(components) > 0 {
+		placeholders, componentArgs := inPlaceholders(components, 1)
+		args = append(args, componentArgs...)
+		where += fmt.Sprintf(` AND character IN (
+			SELECT kanji_char FROM kanji_components WHERE component IN (%s)
+			GROUP BY kanji_char HAVING COUNT(DISTINCT component) = $%d
+		)`, placeholders, len(args)+1)
+		args = append(args, len(components))
+	}
+	if minStrokes > 0 {
+		where += fmt.Sprintf(" AND stroke_count >= $%d", len(args)+1)
+		args = append(args, minStrokes)
+	}
+	if maxStrokes > 0 {
+		where += fmt.Sprintf(" AND stroke_count <= $%d", len(args)+1)
+		args = append(args, maxStrokes)
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM kanji `+where, args...).Scan(&total); err != nil {
+		return nil, 0, nil, err
+	}
+
+	narrowing := []string{}
+	rows, err := r.db.Query(`
+		SELECT DISTINCT component FROM kanji_components
+		WHERE kanji_char IN (SELECT character FROM kanji `+where+`)
+		ORDER BY component
+	`, args...)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var component string
+		if err := rows.Scan(&component); err != nil {
+			return nil, 0, nil, err
+		}
+		narrowing = append(narrowing, component)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, nil, err
+	}
+
+	kanjiRows, err := r.db.Query(fmt.Sprintf(`SELECT `+kanjiColumns+` FROM kanji %s ORDER BY stroke_count, character LIMIT $%d`, where, len(args)+1),
+		append(args, limit)...)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer kanjiRows.Close()
+
+	var kanjiList []*models.Kanji
+	for kanjiRows.Next() {
+		kanji, err := scanKanji(kanjiRows)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		kanjiList = append(kanjiList, kanji)
+	}
+	return kanjiList, total, narrowing, kanjiRows.Err()
+}
+
+// GetKanjiSharingComponents finds the kanji sharing a component with a kanji,
+// most components in common first. A component narrows it to the kanji
+// sharing that one.
+func (r *KanjiRepository) GetKanjiSharingComponents(char, component string, limit int) ([]*models.Kanji, error) {
+	args := []interface{}{char}
+	filter := ""
+	if component != "" {
+		filter = " AND own.component = $2"
+		args = append(args, component)
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s FROM kanji
+		JOIN (
+			SELECT c.kanji_char, COUNT(*) AS shared
+			FROM kanji_components c
+			JOIN kanji_components own ON own.component = c.component
+			WHERE own.kanji_char = $1 AND c.kanji_char <> $1%s
+			GROUP BY c.kanji_char
+		) s ON s.kanji_char = kanji.character
+		ORDER BY s.shared DESC, stroke_count, character
+		LIMIT $%d
+	`, kanjiColumns, filter, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kanjiList []*models.Kanji
+	for rows.Next() {
+		kanji, err := scanKanji(rows)
+		if err != nil {
+			return nil, err
+		}
+		kanjiList = append(kanjiList, kanji)
+	}
+	return kanjiList, rows.Err()
+}
+
+// inPlaceholders builds "$n, $n+1, ..." for an IN list
+func inPlaceholders(values []string, first int) (string, []interface{}) {
+	placeholders := make([]string, len(values))
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		placeholders[i] = fmt.Sprintf("$%d", first+i)
+		args[i] = v
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+// ReplaceKanjiVocabularyLinks replaces every kanji-vocabulary link
+func (r *KanjiRepository) ReplaceKanjiVocabularyLinks(links []models.KanjiVocabLink) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM kanji_vocabulary`); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO kanji_vocabulary (kanji_id, vocabulary_id, position, reading, base_reading, reading_type)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, l := range links {
+		if _, err := stmt.Exec(l.KanjiID, l.VocabularyID, l.Position, l.Reading, l.BaseReading, l.ReadingType); err != nil {
+			return fmt.Errorf("failed to link kanji %s to vocabulary %s: %w", l.KanjiID, l.VocabularyID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetKanjiVocabulary gets the words using a kanji, in the given JLPT levels
+// (any level when none are given), with the user's status for each
+func (r *KanjiRepository) GetKanjiVocabulary(kanjiID, userID string, levels []string) ([]models.KanjiVocabWord, error) {
+	args := []interface{}{userID, kanjiID}
+	levelFilter := ""
+	if len(levels) > 0 {
+		placeholders, levelArgs := inPlaceholders(levels, len(args)+1)
+		levelFilter = " AND v.jlpt_level IN (" + placeholders + ")"
+		args = append(args, levelArgs...)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT v.id, v.word, v.reading, v.short_meaning, v.jlpt_level,
+		       kv.reading, kv.base_reading, kv.reading_type, COALESCE(s.status, '')
+		FROM kanji_vocabulary kv
+		JOIN vocabulary v ON v.id = kv.vocabulary_id
+		LEFT JOIN user_vocab_status s ON s.vocab_id = v.id AND s.user_id = $1
+		WHERE kv.kanji_id = $2`+levelFilter+`
+		ORDER BY v.jlpt_level DESC, v.index_position, kv.position
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var words []models.KanjiVocabWord
+	for rows.Next() {
+		var w models.KanjiVocabWord
+		err := rows.Scan(&w.VocabID, &w.Word, &w.Reading, &w.Meaning, &w.JLPTLevel,
+			&w.KanjiReading, &w.BaseReading, &w.ReadingType, &w.Status)
+		if err != nil {
+			return nil, err
+		}
+		w.Known = w.Status == "known"
+		words = append(words, w)
+	}
+	return words, rows.Err()
+}
+
+// UpsertKanji inserts a kanji or, when the character is already there,
+// replaces its data while keeping its ID (practice sessions point at it)
+func (r *KanjiRepository) UpsertKanji(k *models.Kanji) error {
+	readingsJSON, err := json.Marshal(k.Readings)
+	if err != nil {
+		return err
+	}
+	onReadingsJSON, err := json.Marshal(k.OnReadings)
+	if err != nil {
+		return err
+	}
+	strokeJSON, err := json.Marshal(k.StrokeOrder)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO kanji (id, character, jlpt_level, meaning, readings, stroke_count, stroke_order, created_at, on_readings)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (character) DO UPDATE SET
+			jlpt_level = EXCLUDED.jlpt_level,
+			meaning = EXCLUDED.meaning,
+			readings = EXCLUDED.readings,
+			stroke_count = EXCLUDED.stroke_count,
+			stroke_order = EXCLUDED.stroke_order,
+			on_readings = EXCLUDED.on_readings
+	`
+	_, err = r.db.Exec(query, k.ID, k.Character, k.JLPTLevel, k.Meaning, readingsJSON, k.StrokeCount, strokeJSON, k.CreatedAt, onReadingsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to upsert kanji %s: %w", k.Character, err)
+	}
+	return nil
+}
+
 // SeedSampleKanji seeds initial kanji data
 func (r *KanjiRepository) SeedSampleKanji() error {
 	sampleKanji := []models.Kanji{
@@ -223,6 +720,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Sun, day",
 			Readings:    []string{"にち", "ひ", "か"},
+			OnReadings:  []string{"にち"},
 			StrokeCount: 4,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "vertical", StartPoint: models.Point{X: 50, Y: 20}, EndPoint: models.Point{X: 50, Y: 80}},
@@ -238,6 +736,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Moon, month",
 			Readings:    []string{"げつ", "つき"},
+			OnReadings:  []string{"げつ"},
 			StrokeCount: 4,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "vertical", StartPoint: models.Point{X: 30, Y: 20}, EndPoint: models.Point{X: 30, Y: 80}},
@@ -253,6 +752,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Fire",
 			Readings:    []string{"か", "ひ"},
+			OnReadings:  []string{"か"},
 			StrokeCount: 4,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "diagonal", StartPoint: models.Point{X: 35, Y: 25}, EndPoint: models.Point{X: 25, Y: 45}},
@@ -268,6 +768,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Water",
 			Readings:    []string{"すい", "みず"},
+			OnReadings:  []string{"すい"},
 			StrokeCount: 4,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "vertical", StartPoint: models.Point{X: 50, Y: 20}, EndPoint: models.Point{X: 35, Y: 45}},
@@ -283,6 +784,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Tree, wood",
 			Readings:    []string{"もく", "き"},
+			OnReadings:  []string{"もく"},
 			StrokeCount: 4,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "vertical", StartPoint: models.Point{X: 50, Y: 20}, EndPoint: models.Point{X: 50, Y: 80}},
@@ -298,6 +800,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Gold, metal, money",
 			Readings:    []string{"きん", "かね"},
+			OnReadings:  []string{"きん"},
 			StrokeCount: 8,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "horizontal", StartPoint: models.Point{X: 25, Y: 20}, EndPoint: models.Point{X: 40, Y: 20}},
@@ -318,6 +821,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Person, people",
 			Readings:    []string{"じん", "にん", "ひと"},
+			OnReadings:  []string{"じん", "にん"},
 			StrokeCount: 2,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "diagonal", StartPoint: models.Point{X: 50, Y: 20}, EndPoint: models.Point{X: 25, Y: 70}},
@@ -331,6 +835,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Big, large",
 			Readings:    []string{"だい", "おお"},
+			OnReadings:  []string{"だい"},
 			StrokeCount: 3,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "horizontal", StartPoint: models.Point{X: 20, Y: 35}, EndPoint: models.Point{X: 80, Y: 35}},
@@ -345,6 +850,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Small",
 			Readings:    []string{"しょう", "ちい", "こ", "お"},
+			OnReadings:  []string{"しょう"},
 			StrokeCount: 3,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "vertical", StartPoint: models.Point{X: 50, Y: 20}, EndPoint: models.Point{X: 50, Y: 55}},
@@ -359,6 +865,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Up, above",
 			Readings:    []string{"じょう", "うえ", "あ", "のぼ", "かみ"},
+			OnReadings:  []string{"じょう"},
 			StrokeCount: 3,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "horizontal", StartPoint: models.Point{X: 20, Y: 30}, EndPoint: models.Point{X: 80, Y: 30}},
@@ -373,6 +880,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 			JLPTLevel:   "N5",
 			Meaning:     "Down, below",
 			Readings:    []string{"か", "げ", "くだ", "お", "しも", "さ"},
+			OnReadings:  []string{"か", "げ"},
 			StrokeCount: 3,
 			StrokeOrder: []models.Stroke{
 				{StrokeNum: 1, Direction: "horizontal", StartPoint: models.Point{X: 20, Y: 30}, EndPoint: models.Point{X: 80, Y: 30}},
@@ -385,6 +893,7 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 
 	for _, k := range sampleKanji {
 		readingsJSON, _ := json.Marshal(k.Readings)
+		onReadingsJSON, _ := json.Marshal(k.OnReadings)
 		strokeJSON, _ := json.Marshal(k.StrokeOrder)
 
 		// Check if exists
@@ -395,10 +904,10 @@ func (r *KanjiRepository) SeedSampleKanji() error {
 		}
 
 		query := `
-			INSERT INTO kanji (id, character, jlpt_level, meaning, readings, stroke_count, stroke_order, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			INSERT INTO kanji (id, character, jlpt_level, meaning, readings, stroke_count, stroke_order, created_at, on_readings)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		`
-		_, err := r.db.Exec(query, k.ID, k.Character, k.JLPTLevel, k.Meaning, readingsJSON, k.StrokeCount, strokeJSON, k.CreatedAt)
+		_, err := r.db.Exec(query, k.ID, k.Character, k.JLPTLevel, k.Meaning, readingsJSON, k.StrokeCount, strokeJSON, k.CreatedAt, onReadingsJSON)
 		if err != nil {
 			return fmt.Errorf("failed to seed kanji %s: %w", k.Character, err)
 		}
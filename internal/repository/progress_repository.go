@@ -134,6 +134,13 @@ func (r *ProgressRepository) GetVocabStatusCount(userID, status string) (int, er
 	return count, err
 }
 
+func (r *ProgressRepository) HasVocabStatus(userID, vocabID, status string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM user_vocab_status WHERE user_id = $1 AND vocab_id = $2 AND status = $3`
+	err := r.db.QueryRow(query, userID, vocabID, status).Scan(&count)
+	return count > 0, err
+}
+
 func (r *ProgressRepository) IncrementWordsLearned(userID string) error {
 	query := `
 		UPDATE user_progress
@@ -185,17 +192,165 @@ func (r *ProgressRepository) IncrementGrammarIndex(userID string) (*models.UserP
 	return progress, err
 }
 
-func (r *ProgressRepository) MarkGrammarStatus(userID, grammarID, status string) error {
+// MarkGrammarStatus records a pattern's status. The first mark keeps id and
+// first_seen_at; known_at is set the first time a pattern becomes known.
+func (r *ProgressRepository) MarkGrammarStatus(id, userID, grammarID, status string) error {
 	query := `
-		INSERT INTO user_grammar_status (user_id, grammar_id, status)
-		VALUES ($1, $2, $3)
+		INSERT INTO user_grammar_status (id, user_id, grammar_id, status, known_at)
+		VALUES ($1, $2, $3, $4, CASE WHEN $4 = 'known' THEN CURRENT_TIMESTAMP END)
 		ON CONFLICT (user_id, grammar_id)
-		DO UPDATE SET status = EXCLUDED.status, marked_at = CURRENT_TIMESTAMP
+		DO UPDATE SET status = EXCLUDED.status,
+		              marked_at = CURRENT_TIMESTAMP,
+		              known_at = CASE WHEN EXCLUDED.status = 'known'
+		                              THEN COALESCE(user_grammar_status.known_at, CURRENT_TIMESTAMP)
+		                              ELSE NULL END
+	`
+	_, err := r.db.Exec(query, id, userID, grammarID, status)
+	return err
+}
+
+// RevisitGrammar moves a pattern back to learning and counts the revisit
+func (r *ProgressRepository) RevisitGrammar(id, userID, grammarID string) error {
+	query := `
+		INSERT INTO user_grammar_status (id, user_id, grammar_id, status, revisit_count)
+		VALUES ($1, $2, $3, 'learning', 1)
+		ON CONFLICT (user_id, grammar_id)
+		DO UPDATE SET status = 'learning',
+		              marked_at = CURRENT_TIMESTAMP,
+		              known_at = NULL,
+		              revisit_count = user_grammar_status.revisit_count + 1
 	`
-	_, err := r.db.Exec(query, userID, grammarID, status)
+	_, err := r.db.Exec(query, id, userID, grammarID)
 	return err
 }
 
+// GetGrammarStatus returns a user's status for one pattern
+func (r *ProgressRepository) GetGrammarStatus(userID, grammarID string) (*models.UserGrammarStatus, error) {
+	status := &models.UserGrammarStatus{}
+	var knownAt sql.NullTime
+	query := `
+		SELECT id, user_id, grammar_id, status, first_seen_at, marked_at, known_at, revisit_count
+		FROM user_grammar_status
+		WHERE user_id = $1 AND grammar_id = $2
+	`
+	err := r.db.QueryRow(query, userID, grammarID).Scan(
+		&status.ID, &status.UserID, &status.GrammarID, &status.Status,
+		&status.FirstSeenAt, &status.MarkedAt, &knownAt, &status.RevisitCount,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("grammar status not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if knownAt.Valid {
+		status.KnownAt = &knownAt.Time
+	}
+	return status, nil
+}
+
+// DeleteGrammarStatus resets a pattern to unseen
+func (r *ProgressRepository) DeleteGrammarStatus(userID, grammarID string) error {
+	_, err := r.db.Exec(`DELETE FROM user_grammar_status WHERE user_id = $1 AND grammar_id = $2`, userID, grammarID)
+	return err
+}
+
+// ListGrammarStatuses pages through a user's marked patterns, newest first.
+// Empty level or status means no filter.
+func (r *ProgressRepository) ListGrammarStatuses(userID, level, status string, page, limit int) ([]models.LearnedGrammarPattern, int, error) {
+	where := `WHERE s.user_id = $1 AND ($2 = '' OR g.jlpt_level = $2) AND ($3 = '' OR s.status = $3)`
+
+	var total int
+	countQuery := `
+		SELECT COUNT(*) FROM user_grammar_status s
+		JOIN grammar_patterns g ON g.id = s.grammar_id
+		` + where
+	if err := r.db.QueryRow(countQuery, userID, level, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT s.id, s.user_id, s.grammar_id, s.status, s.first_seen_at, s.marked_at,
+		       s.known_at, s.revisit_count, g.pattern, g.meaning, g.jlpt_level, g.index_position
+		FROM user_grammar_status s
+		JOIN grammar_patterns g ON g.id = s.grammar_id
+		` + where + `
+		ORDER BY s.marked_at DESC, g.index_position
+		LIMIT $4 OFFSET $5
+	`
+	rows, err := r.db.Query(query, userID, level, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	patterns := []models.LearnedGrammarPattern{}
+	for rows.Next() {
+		var p models.LearnedGrammarPattern
+		var knownAt sql.NullTime
+		if err := rows.Scan(
+			&p.ID, &p.UserID, &p.GrammarID, &p.Status, &p.FirstSeenAt, &p.MarkedAt,
+			&knownAt, &p.RevisitCount, &p.Pattern, &p.Meaning, &p.JLPTLevel, &p.IndexPosition,
+		); err != nil {
+			return nil, 0, err
+		}
+		if knownAt.Valid {
+			p.KnownAt = &knownAt.Time
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, total, rows.Err()
+}
+
+// GetGrammarStatusMap returns the user's status for every marked pattern, keyed by pattern ID
+func (r *ProgressRepository) GetGrammarStatusMap(userID string) (map[string]string, error) {
+	rows, err := r.db.Query(`SELECT grammar_id, status FROM user_grammar_status WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := make(map[string]string)
+	for rows.Next() {
+		var grammarID, status string
+		if err := rows.Scan(&grammarID, &status); err != nil {
+			return nil, err
+		}
+		statuses[grammarID] = status
+	}
+	return statuses, rows.Err()
+}
+
+// GetGrammarStatusCounts counts a user's patterns per level and status
+func (r *ProgressRepository) GetGrammarStatusCounts(userID string) (map[string]map[string]int, error) {
+	query := `
+		SELECT g.jlpt_level, s.status, COUNT(*)
+		FROM user_grammar_status s
+		JOIN grammar_patterns g ON g.id = s.grammar_id
+		WHERE s.user_id = $1
+		GROUP BY g.jlpt_level, s.status
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]int)
+	for rows.Next() {
+		var level, status string
+		var n int
+		if err := rows.Scan(&level, &status, &n); err != nil {
+			return nil, err
+		}
+		if counts[level] == nil {
+			counts[level] = make(map[string]int)
+		}
+		counts[level][status] = n
+	}
+	return counts, rows.Err()
+}
+
 func (r *ProgressRepository) IncrementGrammarLearned(userID string) error {
 	query := `
 		UPDATE user_progress
@@ -206,3 +361,14 @@ func (r *ProgressRepository) IncrementGrammarLearned(userID string) error {
 	_, err := r.db.Exec(query, userID)
 	return err
 }
+
+func (r *ProgressRepository) DecrementGrammarLearned(userID string) error {
+	query := `
+		UPDATE user_progress
+		SET grammar_learned_count = CASE WHEN grammar_learned_count > 0 THEN grammar_learned_count - 1 ELSE 0 END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1
+	`
+	_, err := r.db.Exec(query, userID)
+	return err
+}
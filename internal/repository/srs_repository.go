@@ -367,4 +367,51 @@ func (r *SRSRepository) GetSRSStats(userID string) (*models.SRSStats, error) {
 	}
 	
 	return stats, nil
-}
\ No newline at end of file
+}
+// GetItemIDsByType returns the IDs of a user's scheduled items of one type
+func (r *SRSRepository) GetItemIDsByType(userID, itemType string, limit int) ([]string, error) {
+	if limit < 1 {
+		limit = 200
+	}
+
+	query := `
+		SELECT item_id FROM srs_schedules
+		WHERE user_id = ` + r.db.Placeholder(1) + ` AND item_type = ` + r.db.Placeholder(2) + `
+		ORDER BY next_review_at ASC
+		LIMIT ` + r.db.Placeholder(3)
+
+	rows, err := r.db.Query(query, userID, itemType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// RequeueItem makes an item due for review now, creating its schedule if needed.
+// Used when a user misses an item outside of SRS review (e.g. in a quiz).
+func (r *SRSRepository) RequeueItem(userID, itemID, itemType string) error {
+	schedule, err := r.GetOrCreateSchedule(userID, itemID, itemType)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE srs_schedules
+		SET next_review_at = %s, streak = 0,
+		    status = CASE WHEN status IN ('mastered', 'lapsed') THEN 'learning' ELSE status END
+		WHERE id = %s
+	`, r.db.Placeholder(1), r.db.Placeholder(2))
+
+	_, err = r.db.Exec(query, time.Now(), schedule.ID)
+	return err
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/erwinwahyura/daily-kotoba/internal/db"
 	"github.com/erwinwahyura/daily-kotoba/internal/models"
@@ -111,3 +112,30 @@ func (r *UserRepository) UpdateUserLevel(userID, level string) error {
 	_, err := r.db.Exec(query, level, userID)
 	return err
 }
+
+// CreateWidgetToken records an issued widget token by its ID
+func (r *UserRepository) CreateWidgetToken(id, userID string, expiresAt time.Time) error {
+	query := `INSERT INTO widget_tokens (id, user_id, expires_at) VALUES ($1, $2, $3)`
+	_, err := r.db.Exec(query, id, userID, expiresAt)
+	return err
+}
+
+// WidgetTokenActive reports whether a widget token was issued to a user and
+// hasn't been revoked
+func (r *UserRepository) WidgetTokenActive(id, userID string) (bool, error) {
+	var active bool
+	query := `SELECT EXISTS(SELECT 1 FROM widget_tokens WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL)`
+	err := r.db.QueryRow(query, id, userID).Scan(&active)
+	return active, err
+}
+
+// RevokeWidgetTokens revokes every widget token of a user, returning how
+// many were still active
+func (r *UserRepository) RevokeWidgetTokens(userID string) (int64, error) {
+	query := `UPDATE widget_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
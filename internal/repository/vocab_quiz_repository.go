@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/db"
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// VocabQuizRepository handles vocabulary quiz session data access
+type VocabQuizRepository struct {
+	db *db.DB
+}
+
+// NewVocabQuizRepository creates a new repository
+func NewVocabQuizRepository(db *db.DB) *VocabQuizRepository {
+	return &VocabQuizRepository{db: db}
+}
+
+// CreateSession stores a newly generated quiz
+func (r *VocabQuizRepository) CreateSession(session *models.VocabQuizSession) error {
+	questionsJSON, err := json.Marshal(session.Questions)
+	if err != nil {
+		return err
+	}
+	answersJSON, _ := json.Marshal(session.Answers)
+
+	query := `
+		INSERT INTO vocab_quiz_sessions (id, user_id, deck, jlpt_level, questions, answers,
+			score, status, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err = r.db.Exec(query, session.ID, session.UserID, session.Deck, session.JLPTLevel,
+		questionsJSON, answersJSON, session.Score, session.Status, session.StartedAt)
+
+	return err
+}
+
+// UpdateSession saves answers, score and status
+func (r *VocabQuizRepository) UpdateSession(session *models.VocabQuizSession) error {
+	answersJSON, _ := json.Marshal(session.Answers)
+
+	var completedAt interface{}
+	if session.CompletedAt != nil {
+		completedAt = *session.CompletedAt
+	}
+
+	query := `
+		UPDATE vocab_quiz_sessions
+		SET answers = $1, score = $2, status = $3, completed_at = $4
+		WHERE id = $5
+	`
+
+	_, err := r.db.Exec(query, answersJSON, session.Score, session.Status, completedAt, session.ID)
+	return err
+}
+
+// GetSession retrieves a quiz session including its answer keys
+func (r *VocabQuizRepository) GetSession(sessionID string) (*models.VocabQuizSession, error) {
+	session := &models.VocabQuizSession{}
+	var questionsJSON, answersJSON []byte
+	var level sql.NullString
+	var completedAt sql.NullTime
+
+	query := `
+		SELECT id, user_id, deck, jlpt_level, questions, answers, score, status,
+		       started_at, completed_at
+		FROM vocab_quiz_sessions WHERE id = $1
+	`
+
+	err := r.db.QueryRow(query, sessionID).Scan(
+		&session.ID, &session.UserID, &session.Deck, &level, &questionsJSON,
+		&answersJSON, &session.Score, &session.Status, &session.StartedAt, &completedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("quiz session not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session.JLPTLevel = level.String
+	if completedAt.Valid {
+		session.CompletedAt = &completedAt.Time
+	}
+
+	if err := json.Unmarshal(questionsJSON, &session.Questions); err != nil {
+		return nil, fmt.Errorf("failed to parse questions: %w", err)
+	}
+	json.Unmarshal(answersJSON, &session.Answers)
+	session.TotalQuestions = len(session.Questions)
+
+	return session, nil
+}
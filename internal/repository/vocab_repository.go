@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/erwinwahyura/daily-kotoba/internal/db"
 	"github.com/erwinwahyura/daily-kotoba/internal/models"
@@ -129,6 +130,46 @@ func (r *VocabRepository) GetByLevel(level string, page, limit int) ([]models.Vo
 	return vocabList, total, nil
 }
 
+// GetRangeByLevel returns up to limit words starting at fromIndex, in study order
+func (r *VocabRepository) GetRangeByLevel(level string, fromIndex, limit int) ([]models.Vocabulary, error) {
+	query := `
+		SELECT id, word, reading, short_meaning, detailed_explanation,
+		       example_sentences, usage_notes, jlpt_level, index_position, created_at
+		FROM vocabulary
+		WHERE jlpt_level = $1 AND index_position >= $2
+		ORDER BY index_position
+		LIMIT $3
+	`
+	rows, err := r.db.Query(query, level, fromIndex, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vocabList []models.Vocabulary
+	for rows.Next() {
+		var vocab models.Vocabulary
+		err := rows.Scan(
+			&vocab.ID,
+			&vocab.Word,
+			&vocab.Reading,
+			&vocab.ShortMeaning,
+			&vocab.DetailedExplanation,
+			&vocab.ExampleSentences,
+			&vocab.UsageNotes,
+			&vocab.JLPTLevel,
+			&vocab.IndexPosition,
+			&vocab.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		vocabList = append(vocabList, vocab)
+	}
+
+	return vocabList, rows.Err()
+}
+
 func (r *VocabRepository) GetTotalCountByLevel(level string) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM vocabulary WHERE jlpt_level = $1`
@@ -243,3 +284,93 @@ func (r *VocabRepository) Search(query, level string) ([]*models.Vocabulary, err
 	
 	return results, rows.Err()
 }
+
+// GetAllByLevel returns every word in a level, including the enhanced fields
+// (related words, word type) used to build quiz distractors
+func (r *VocabRepository) GetAllByLevel(level string) ([]models.Vocabulary, error) {
+	query := `
+		SELECT id, word, reading, short_meaning, example_sentences, jlpt_level,
+		       index_position, COALESCE(related_words, '{}'), COALESCE(word_type, 'unknown')
+		FROM vocabulary
+		WHERE jlpt_level = $1
+		ORDER BY index_position
+	`
+	rows, err := r.db.Query(query, level)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanVocabWithRelated(rows)
+}
+
+// GetAllWords returns the ID, word, reading and level of every word
+func (r *VocabRepository) GetAllWords() ([]models.Vocabulary, error) {
+	rows, err := r.db.Query(`SELECT id, word, reading, jlpt_level FROM vocabulary ORDER BY jlpt_level DESC, index_position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vocabList []models.Vocabulary
+	for rows.Next() {
+		var vocab models.Vocabulary
+		if err := rows.Scan(&vocab.ID, &vocab.Word, &vocab.Reading, &vocab.JLPTLevel); err != nil {
+			return nil, err
+		}
+		vocabList = append(vocabList, vocab)
+	}
+	return vocabList, rows.Err()
+}
+
+// GetByWords looks up vocabulary whose word or reading matches any of the given strings
+func (r *VocabRepository) GetByWords(words []string) ([]models.Vocabulary, error) {
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(words))
+	args := make([]interface{}, len(words))
+	for i, w := range words {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = w
+	}
+	in := strings.Join(placeholders, ", ")
+
+	query := `
+		SELECT id, word, reading, short_meaning, example_sentences, jlpt_level,
+		       index_position, COALESCE(related_words, '{}'), COALESCE(word_type, 'unknown')
+		FROM vocabulary
+		WHERE word IN (` + in + `) OR reading IN (` + in + `)
+	`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanVocabWithRelated(rows)
+}
+
+func scanVocabWithRelated(rows *sql.Rows) ([]models.Vocabulary, error) {
+	var vocabList []models.Vocabulary
+	for rows.Next() {
+		var vocab models.Vocabulary
+		err := rows.Scan(
+			&vocab.ID,
+			&vocab.Word,
+			&vocab.Reading,
+			&vocab.ShortMeaning,
+			&vocab.ExampleSentences,
+			&vocab.JLPTLevel,
+			&vocab.IndexPosition,
+			&vocab.RelatedWords,
+			&vocab.WordType,
+		)
+		if err != nil {
+			return nil, err
+		}
+		vocabList = append(vocabList, vocab)
+	}
+	return vocabList, rows.Err()
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// isAdjectiveForm reports whether a form is one of the adjective and copula forms
+func isAdjectiveForm(form string) bool {
+	return models.ConjugationFormTrack(form) == models.ConjugationTrackAdjective
+}
+
+// adjectiveMistake explains the mistakes typical of adjectives and the copula:
+// keeping the い (高いかった), treating a な-adjective like an い-adjective
+// (静かくない), keeping the な (静かなだった), いい without よ (いくない), and
+// mixing up だ and です.
+func adjectiveMistake(ch *models.ConjugationChallenge, table *models.ConjugationTable, spellings []conjugationSpelling, given string) (conjugationVerdict, bool) {
+	formName := conjugationFormName(ch.TargetForm)
+	wrong := func(category, format string, args ...interface{}) (conjugationVerdict, bool) {
+		return conjugationVerdict{Category: category, Explanation: fmt.Sprintf(format, args...)}, true
+	}
+
+	switch table.Group {
+	case models.ConjugationIAdjective:
+		traits := traitsOf(ch.BaseForm, ch.Reading)
+		for _, sp := range spellings {
+			if traits.ii {
+				for _, s := range conjugateIAdjective(sp.base, wordTraits{})[ch.TargetForm] {
+					if given == s {
+						return wrong(models.ConjugationErrorStem, "いい borrows its forms from よい: %s, not %s.", ch.FullAnswer, given)
+					}
+				}
+			}
+			if strings.HasPrefix(given, sp.base) && given != sp.answer {
+				return wrong(models.ConjugationErrorStem, "い-adjectives drop the final い before adding the ending of the %s: %s, not %s.",
+					formName, ch.FullAnswer, given)
+			}
+		}
+
+	case models.ConjugationNaAdjective:
+		for _, sp := range spellings {
+			stem := strings.TrimSuffix(strings.TrimSuffix(sp.base, "だ"), "な")
+			if strings.HasPrefix(given, stem+"な") && given != sp.answer {
+				return wrong(models.ConjugationErrorStem, "な only comes before a noun (%sな人). The %s is %s, not %s.",
+					stem, formName, ch.FullAnswer, given)
+			}
+			for _, s := range conjugateIAdjective(stem+"い", wordTraits{})[ch.TargetForm] {
+				if given == s {
+					return wrong(models.ConjugationErrorGroup, "%s is a な-adjective and conjugates like the copula, not with い-adjective endings: %s, not %s.",
+						ch.BaseForm, ch.FullAnswer, given)
+				}
+			}
+		}
+
+	case models.ConjugationCopula:
+		for _, other := range []string{"だ", "です"} {
+			if other == ch.BaseForm {
+				continue
+			}
+			for _, s := range conjugateCopula(other)[ch.TargetForm] {
+				if given == s {
+					return wrong(models.ConjugationErrorForm, "%s comes from %s. The %s of %s is %s.",
+						given, other, formName, ch.BaseForm, ch.FullAnswer)
+				}
+			}
+		}
+	}
+	return conjugationVerdict{}, false
+}
+
+// fitsConjugationTrack reports whether a word of a category is drilled on a
+// form: verbs on verb forms, adjectives and the copula on adjective forms
+func fitsConjugationTrack(category, form string) bool {
+	return (category == "verb") != isAdjectiveForm(form)
+}
+
+// filterConjugationTrack drops the challenges drilling a word on the other
+// track's form (an adjective on the verb て形)
+func filterConjugationTrack(challenges []*models.ConjugationChallenge, form string) []*models.ConjugationChallenge {
+	kept := challenges[:0]
+	for _, c := range challenges {
+		if fitsConjugationTrack(c.Category, form) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
@@ -5,28 +5,35 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/erwinwahyura/daily-kotoba/internal/models"
 	"github.com/erwinwahyura/daily-kotoba/internal/repository"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// WidgetTokenScope marks a token that may only read the home-screen widget feed
+const WidgetTokenScope = "widget"
+
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	jwtSecret string
-	jwtExpiry int
+	userRepo         *repository.UserRepository
+	jwtSecret        string
+	jwtExpiry        int
+	widgetExpiryDays int
 }
 
 type JWTClaims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	Scope  string `json:"scope,omitempty"` // empty = full access, "widget" = widget feed only
 	jwt.RegisteredClaims
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtSecret string, jwtExpiry int) *AuthService {
+func NewAuthService(userRepo *repository.UserRepository, jwtSecret string, jwtExpiry int, widgetExpiryDays int) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
-		jwtExpiry: jwtExpiry,
+		userRepo:         userRepo,
+		jwtSecret:        jwtSecret,
+		jwtExpiry:        jwtExpiry,
+		widgetExpiryDays: widgetExpiryDays,
 	}
 }
 
@@ -102,6 +109,39 @@ func (s *AuthService) GenerateToken(user *models.User) (string, error) {
 	return token.SignedString([]byte(s.jwtSecret))
 }
 
+// GenerateWidgetToken issues a long-lived token scoped to the widget feed.
+// Widgets run outside the app and can't refresh a normal session token, so
+// this token is read-only and rejected by the regular auth middleware. Its ID
+// is recorded so it can be revoked.
+func (s *AuthService) GenerateWidgetToken(userID string) (string, time.Time, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(time.Hour * 24 * time.Duration(s.widgetExpiryDays))
+	claims := JWTClaims{
+		UserID: user.ID,
+		Email:  user.Email,
+		Scope:  WidgetTokenScope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	if err := s.userRepo.CreateWidgetToken(claims.ID, user.ID, expiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
 func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.jwtSecret), nil
@@ -111,11 +151,30 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 
-	return nil, errors.New("invalid token")
+	// Widget tokens live for weeks, so they must still be on record
+	if claims.Scope == WidgetTokenScope {
+		if claims.ID == "" {
+			return nil, errors.New("widget token has been revoked")
+		}
+		active, err := s.userRepo.WidgetTokenActive(claims.ID, claims.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, errors.New("widget token has been revoked")
+		}
+	}
+	return claims, nil
+}
+
+// RevokeWidgetTokens revokes every widget token issued to a user
+func (s *AuthService) RevokeWidgetTokens(userID string) (int64, error) {
+	return s.userRepo.RevokeWidgetTokens(userID)
 }
 
 func (s *AuthService) GetUserByID(userID string) (*models.User, error) {
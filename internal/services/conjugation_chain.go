@@ -0,0 +1,326 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// chainStackRank orders the forms that can stack: causative, then passive or
+// potential, then ない, then one closing form (食べさせられなかった)
+var chainStackRank = map[string]int{
+	"causative": 0,
+	"passive":   1,
+	"potential": 1,
+	"nai":       2,
+}
+
+// chainClosingRank is the rank of every form that ends a chain
+const chainClosingRank = 3
+
+func chainRank(form string) int {
+	if rank, ok := chainStackRank[form]; ok {
+		return rank
+	}
+	return chainClosingRank
+}
+
+// validateConjugationChain checks a chain has known forms in an order Japanese
+// allows. Only forms that produce a conjugatable word can be followed.
+func validateConjugationChain(forms []string) error {
+	if len(forms) < 2 {
+		return fmt.Errorf("a chain needs at least two forms")
+	}
+	for i, form := range forms {
+		if conjugationFormInfo(form) == nil {
+			return fmt.Errorf("unknown conjugation form: %s", form)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := forms[i-1]
+		if _, ok := deinflectNextGroup[prev]; !ok {
+			return fmt.Errorf("%s can't be conjugated any further", prev)
+		}
+		if chainRank(form) <= chainRank(prev) {
+			return fmt.Errorf("%s can't follow %s", form, prev)
+		}
+	}
+	return nil
+}
+
+// conjugateChain applies forms one after another, each step conjugating the
+// previous result in the group that form produces (食べさせる is ichidan)
+func conjugateChain(word, reading, group string, forms []string) ([]models.ConjugationChainStep, error) {
+	steps := make([]models.ConjugationChainStep, 0, len(forms))
+	for i, form := range forms {
+		table, err := ConjugateWord(word, reading, group)
+		if err != nil {
+			return nil, err
+		}
+		f := tableForm(table, form)
+		if f == nil {
+			return nil, fmt.Errorf("%s has no %s", table.BaseForm, form)
+		}
+		steps = append(steps, models.ConjugationChainStep{
+			Form:     form,
+			From:     table.BaseForm,
+			Expected: f.Surface,
+			Reading:  f.Reading,
+			Hint:     f.Hint,
+		})
+		if i == len(forms)-1 {
+			break
+		}
+		next, ok := deinflectNextGroup[form]
+		if !ok {
+			return nil, fmt.Errorf("%s can't be conjugated any further", form)
+		}
+		word, reading, group = f.Surface, f.Reading, next
+	}
+	return steps, nil
+}
+
+// contractedChainSteps is a chain with a godan verb's causative-passive
+// contracted (当たらされる for 当たらせられる), the form most people say. It is
+// nil when the chain has no causative-passive or the verb can't contract:
+// す-verbs only take the long form (話させられる).
+func contractedChainSteps(ch *models.ConjugationChallenge, steps []models.ConjugationChainStep) []models.ConjugationChainStep {
+	if ch.Group != models.ConjugationGodan || strings.HasSuffix(ch.BaseForm, "す") || strings.HasSuffix(ch.Reading, "す") {
+		return nil
+	}
+	for k := 1; k < len(steps); k++ {
+		if steps[k-1].Form != "causative" || steps[k].Form != "passive" {
+			continue
+		}
+		stem, ok := strings.CutSuffix(steps[k-1].Expected, "せる")
+		stemReading, readingOK := strings.CutSuffix(steps[k-1].Reading, "せる")
+		if !ok || !readingOK {
+			return nil
+		}
+
+		alt := append([]models.ConjugationChainStep(nil), steps[:k+1]...)
+		alt[k].Expected, alt[k].Reading = stem+"される", stemReading+"される"
+		alt[k].Hint = fmt.Sprintf("Contracted 使役受身形: %s → %s", steps[k-1].Expected, alt[k].Expected)
+		if k+1 == len(steps) {
+			return alt
+		}
+		forms := make([]string, 0, len(steps)-k-1)
+		for _, step := range steps[k+1:] {
+			forms = append(forms, step.Form)
+		}
+		rest, err := conjugateChain(alt[k].Expected, alt[k].Reading, deinflectNextGroup["passive"], forms)
+		if err != nil {
+			return nil
+		}
+		return append(alt, rest...)
+	}
+	return nil
+}
+
+// chainFormInfo describes a chain the way a single form is described
+func chainFormInfo(forms []string) *models.ConjugationFormType {
+	key := models.ConjugationChainKey(forms)
+	for _, c := range models.GetConjugationChains() {
+		if models.ConjugationChainKey(c.Forms) == key {
+			return &models.ConjugationFormType{Name: key, DisplayName: c.DisplayName, Description: c.Description, Level: c.Level}
+		}
+	}
+
+	names := make([]string, len(forms))
+	descriptions := make([]string, len(forms))
+	level := "N5"
+	for i, form := range forms {
+		info := conjugationFormInfo(form)
+		if info == nil {
+			return nil
+		}
+		names[i], descriptions[i] = info.DisplayName, info.Description
+		if curriculumLevels[info.Level] < curriculumLevels[level] {
+			level = info.Level
+		}
+	}
+	return &models.ConjugationFormType{
+		Name:        key,
+		DisplayName: strings.Join(names, " → "),
+		Description: strings.Join(descriptions, " → "),
+		Level:       level,
+	}
+}
+
+// stepChallenge turns step i of a chain into a single-form challenge so it can
+// be checked and classified like any other
+func stepChallenge(ch *models.ConjugationChallenge, steps []models.ConjugationChainStep, i int) *models.ConjugationChallenge {
+	reading, group := ch.Reading, ch.Group
+	if i > 0 {
+		reading, group = steps[i-1].Reading, deinflectNextGroup[steps[i-1].Form]
+	}
+	return &models.ConjugationChallenge{
+		ID:           ch.ID,
+		BaseForm:     steps[i].From,
+		Reading:      reading,
+		Group:        group,
+		TargetForm:   steps[i].Form,
+		TargetEnding: conjugationEnding(steps[i].From, steps[i].Expected),
+		FullAnswer:   steps[i].Expected,
+		Hint:         steps[i].Hint,
+		Category:     ch.Category,
+	}
+}
+
+// chainVerdict is the outcome of checking a chained answer step by step
+type chainVerdict struct {
+	conjugationVerdict
+	Steps      []models.ConjugationStepResult
+	FailedStep int // -1 when every step is right
+}
+
+// checkChainAnswer checks each answered step of a chain on its own. The final
+// answer is required; when intermediate steps are left out, the first failing
+// one is worked out from the final answer.
+func checkChainAnswer(ch *models.ConjugationChallenge, forms, stepAnswers []string, answer string) chainVerdict {
+	steps, err := conjugateChain(ch.BaseForm, ch.Reading, ch.Group, forms)
+	if err != nil {
+		return chainVerdict{conjugationVerdict: checkConjugationAnswer(ch, answer), FailedStep: -1}
+	}
+
+	answers := make([]string, len(steps))
+	copy(answers, stepAnswers)
+	answers[len(steps)-1] = answer
+	contracted := contractedChainSteps(ch, steps)
+
+	v := chainVerdict{conjugationVerdict: conjugationVerdict{Correct: true}, FailedStep: -1}
+	for i, step := range steps {
+		result := models.ConjugationStepResult{
+			Step:     i,
+			Form:     step.Form,
+			From:     step.From,
+			Expected: step.Expected,
+			Answer:   answers[i],
+		}
+		if strings.TrimSpace(answers[i]) != "" {
+			sv := checkConjugationAnswer(stepChallenge(ch, steps, i), answers[i])
+			if !sv.Correct && contracted != nil && contracted[i].Expected != step.Expected {
+				if alt := checkConjugationAnswer(stepChallenge(ch, contracted, i), answers[i]); alt.Correct {
+					sv = alt
+				}
+			}
+			result.IsCorrect, result.ErrorCategory, result.Explanation = sv.Correct, sv.Category, sv.Explanation
+			if !sv.Correct && v.FailedStep < 0 {
+				v.FailedStep = i
+			}
+		}
+		v.Steps = append(v.Steps, result)
+	}
+
+	// Unanswered steps before the first wrong one: judge them from the final answer
+	last := len(steps) - 1
+	if !v.Steps[last].IsCorrect {
+		from := 0
+		for i := last - 1; i >= 0; i-- {
+			if answers[i] != "" {
+				from = i + 1
+				break
+			}
+		}
+		if v.FailedStep < 0 || v.FailedStep == last {
+			v.FailedStep = last
+			if failed, skipped := inferFailedChainStep(ch, steps, answer, from); failed < last {
+				v.FailedStep = failed
+				v.Steps[failed].ErrorCategory, v.Steps[failed].Explanation = chainStepMistake(steps[failed], skipped)
+				v.Steps[last].ErrorCategory = ""
+				v.Steps[last].Explanation = fmt.Sprintf("Follows from the mistake at step %d.", failed+1)
+			}
+		}
+	}
+	for i := range v.Steps {
+		if v.Steps[i].Answer == "" {
+			v.Steps[i].IsCorrect = v.FailedStep < 0 || i < v.FailedStep
+		}
+	}
+
+	if v.FailedStep < 0 {
+		return v
+	}
+	failed := v.Steps[v.FailedStep]
+	v.Correct = false
+	v.Category = failed.ErrorCategory
+	if v.Category == "" {
+		v.Category = models.ConjugationErrorOther
+	}
+	v.Explanation = fmt.Sprintf("Step %d (%s, %s → %s): %s The full answer is %s.",
+		v.FailedStep+1, conjugationFormName(failed.Form), failed.From, failed.Expected, failed.Explanation, ch.FullAnswer)
+	return v
+}
+
+// inferFailedChainStep guesses which step went wrong from a wrong final answer.
+// If the answer deinflects back to the same word, the chains part where its
+// route leaves the expected one (食べさせなかった skips the passive). Otherwise
+// the first step whose stem the answer doesn't start with is blamed.
+func inferFailedChainStep(ch *models.ConjugationChallenge, steps []models.ConjugationChainStep, answer string, from int) (step int, skipped bool) {
+	given := normalizeConjugationAnswer(answer)
+	last := len(steps) - 1
+
+	best := -1
+	for _, c := range deinflectCandidates(given) {
+		if c.BaseForm != ch.BaseForm && c.BaseForm != katakanaToHiragana(ch.Reading) {
+			continue
+		}
+		i := 0
+		for i < len(c.Chain) && i < len(steps) && c.Chain[i].Form == steps[i].Form {
+			i++
+		}
+		best = max(best, i)
+	}
+	if best >= 0 {
+		return min(max(best, from), last), best < last
+	}
+
+	stem := func(s string) string {
+		runes := []rune(katakanaToHiragana(s))
+		return string(runes[:max(len(runes)-1, 0)])
+	}
+	for i := from; i < last; i++ {
+		if !strings.HasPrefix(given, stem(steps[i].Expected)) && !strings.HasPrefix(given, stem(steps[i].Reading)) {
+			return i, false
+		}
+	}
+	return last, false
+}
+
+// chainStepMistake explains a step that was only judged from the final answer
+func chainStepMistake(step models.ConjugationChainStep, skipped bool) (category, explanation string) {
+	formName := conjugationFormName(step.Form)
+	if skipped {
+		return models.ConjugationErrorForm, fmt.Sprintf("Your answer takes a different route here: the %s turns %s into %s.",
+			formName, step.From, step.Expected)
+	}
+	return models.ConjugationErrorOther, fmt.Sprintf("Your answer goes wrong here: the %s of %s is %s. Hint: %s",
+		formName, step.From, step.Expected, step.Hint)
+}
+
+// chainTransitions turns failures per step into transition stats, most failed first
+func chainTransitions(forms []string, failures map[int]int, total int) []models.ChainTransition {
+	var transitions []models.ChainTransition
+	for i, form := range forms {
+		n := failures[i]
+		if n == 0 {
+			continue
+		}
+		from := "dictionary"
+		if i > 0 {
+			from = forms[i-1]
+		}
+		rate := 0.0
+		if total > 0 {
+			rate = float64(n) / float64(total) * 100
+		}
+		transitions = append(transitions, models.ChainTransition{Step: i, From: from, To: form, Failures: n, FailureRate: rate})
+	}
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].Failures > transitions[j].Failures
+	})
+	return transitions
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// conjugationVerdict is the outcome of checking one drill answer
+type conjugationVerdict struct {
+	Correct     bool
+	Category    string // One of the models.ConjugationError* constants when wrong
+	Explanation string
+}
+
+// godanStemRows maps a stem label to its index in godanRows
+var godanStemRows = map[string]int{"あ-stem": 0, "い-stem": 1, "え-stem": 2, "お-stem": 3}
+
+// normalizeConjugationAnswer trims an answer, converts romaji and folds katakana
+// so 食べて, たべて, タベテ and tabete all compare against the same kana
+func normalizeConjugationAnswer(answer string) string {
+	answer = normalizeGrammarAnswer(answer)
+	answer = strings.TrimRight(answer, "。.!！")
+	for _, r := range answer {
+		if r < unicode.MaxASCII && unicode.IsLetter(r) {
+			if kana, ok := romajiToHiragana(answer); ok {
+				answer = kana
+			}
+			break
+		}
+	}
+	return katakanaToHiragana(answer)
+}
+
+// conjugationSpelling is one way of writing the challenge: in kanji or in kana
+type conjugationSpelling struct {
+	base, answer string
+}
+
+// checkConjugationAnswer accepts the expected answer in kanji or kana (or just
+// the changed ending) and otherwise works out what kind of mistake was made
+func checkConjugationAnswer(ch *models.ConjugationChallenge, answer string) conjugationVerdict {
+	given := normalizeConjugationAnswer(answer)
+	if given == "" {
+		return conjugationVerdict{Category: models.ConjugationErrorOther, Explanation: fmt.Sprintf("The correct answer is %s.", ch.FullAnswer)}
+	}
+
+	table, _ := ConjugateWord(ch.BaseForm, ch.Reading, ch.Group)
+	target := tableForm(table, ch.TargetForm)
+
+	accepted := []string{ch.FullAnswer, ch.TargetEnding}
+	spellings := []conjugationSpelling{{base: ch.BaseForm, answer: ch.FullAnswer}}
+	if target != nil {
+		accepted = append(accepted, target.Surface, target.Reading)
+		accepted = append(accepted, target.Alternatives...)
+		if target.Reading != "" && target.Reading != target.Surface {
+			spellings = append(spellings, conjugationSpelling{base: ch.Reading, answer: target.Reading})
+		}
+	}
+	// Alternatives only come in kanji; conjugating the reading spells them in kana
+	if kanaTable, err := ConjugateWord(ch.Reading, "", ch.Group); err == nil && ch.Reading != ch.BaseForm {
+		if f := tableForm(kanaTable, ch.TargetForm); f != nil {
+			accepted = append(accepted, f.Alternatives...)
+		}
+	}
+	for _, a := range accepted {
+		if a != "" && given == katakanaToHiragana(a) {
+			return conjugationVerdict{Correct: true}
+		}
+	}
+
+	if table == nil || target == nil {
+		return conjugationVerdict{
+			Category:    models.ConjugationErrorOther,
+			Explanation: fmt.Sprintf("The correct answer is %s. Hint: %s", ch.FullAnswer, ch.Hint),
+		}
+	}
+	return classifyConjugationError(ch, table, spellings, given)
+}
+
+// classifyConjugationError tries each explanation from most to least specific
+func classifyConjugationError(ch *models.ConjugationChallenge, table *models.ConjugationTable, spellings []conjugationSpelling, given string) conjugationVerdict {
+	formName := conjugationFormName(ch.TargetForm)
+	wrong := func(category, format string, args ...interface{}) conjugationVerdict {
+		return conjugationVerdict{Category: category, Explanation: fmt.Sprintf(format, args...)}
+	}
+
+	// Another form of the same word (食べた for the て形). Adjective forms are
+	// only compared with each other, as the verb-named ones share their spelling.
+	for _, f := range table.Forms {
+		if f.Form == ch.TargetForm || isAdjectiveForm(f.Form) != isAdjectiveForm(ch.TargetForm) {
+			continue
+		}
+		for _, s := range append([]string{f.Surface, f.Reading}, f.Alternatives...) {
+			if s != "" && given == s {
+				return wrong(models.ConjugationErrorForm, "%s is the %s of %s. The %s is %s.",
+					f.Surface, conjugationFormName(f.Form), ch.BaseForm, formName, ch.FullAnswer)
+			}
+		}
+	}
+
+	if isKeigoForm(ch.TargetForm) {
+		if v, ok := keigoMistake(ch, given); ok {
+			return v
+		}
+	}
+	if isAdjectiveForm(ch.TargetForm) {
+		if v, ok := adjectiveMistake(ch, table, spellings, given); ok {
+			return v
+		}
+	}
+
+	// Conjugated as if it belonged to another group (帰ない, くない)
+	traits := traitsOf(ch.BaseForm, ch.Reading)
+	for _, g := range conjugationGroupRivals(table.Group) {
+		for _, sp := range spellings {
+			for _, s := range conjugateSurface(sp.base, g, traits)[ch.TargetForm] {
+				if given == s {
+					return wrong(models.ConjugationErrorGroup, "%s That gives %s, not %s.",
+						conjugationGroupNote(ch.BaseForm, table.Group, g), ch.FullAnswer, given)
+				}
+			}
+		}
+	}
+
+	for _, sp := range spellings {
+		if v, ok := euphonicMistake(sp, table.Group, ch.TargetForm, traits, given); ok {
+			return v
+		}
+	}
+
+	for _, sp := range spellings {
+		stem, label := conjugationStem(sp.base, table.Group, ch.TargetForm, traits)
+		if stem == "" || !strings.HasPrefix(sp.answer, stem) {
+			continue
+		}
+		tail := strings.TrimPrefix(sp.answer, stem)
+		if tail != "" && strings.HasSuffix(given, tail) && given != sp.answer {
+			return wrong(models.ConjugationErrorStem, "The %s is built on the %s: %s + %s = %s, not %s.",
+				formName, label, stem, tail, sp.answer, given)
+		}
+		if strings.HasPrefix(given, stem) && given != sp.answer {
+			return wrong(models.ConjugationErrorEnding, "The stem %s is right, but the %s ends in %s (%s), not %s.",
+				stem, formName, tail, sp.answer, strings.TrimPrefix(given, stem))
+		}
+	}
+
+	return wrong(models.ConjugationErrorOther, "The correct answer is %s. Hint: %s", ch.FullAnswer, ch.Hint)
+}
+
+// euphonicMistake spots a godan て/た form with the wrong sound change (書きて, 書って, 行いて)
+func euphonicMistake(sp conjugationSpelling, group, form string, t wordTraits, given string) (conjugationVerdict, bool) {
+	if group != models.ConjugationGodan || (form != "te" && form != "ta") {
+		return conjugationVerdict{}, false
+	}
+	runes := []rune(sp.base)
+	last := runes[len(runes)-1]
+	prefix := string(runes[:len(runes)-1])
+	row, ok := godanRows[last]
+	if !ok {
+		return conjugationVerdict{}, false
+	}
+	idx := 0
+	if form == "ta" {
+		idx = 1
+	}
+	plain := "て"
+	if form == "ta" {
+		plain = "た"
+	}
+
+	wrongs := []string{prefix + row[1] + plain}
+	for _, te := range godanTe {
+		wrongs = append(wrongs, prefix+te[idx])
+	}
+	for _, w := range wrongs {
+		if w != given || w == sp.answer {
+			continue
+		}
+		formName := conjugationFormName(form)
+		var explanation string
+		switch {
+		case w == prefix+row[1]+plain:
+			explanation = fmt.Sprintf("Group 1 verbs don't add %s to the masu stem. %c changes to %s in the %s: %s.",
+				plain, last, strings.TrimPrefix(sp.answer, prefix), formName, sp.answer)
+		case t.iku && last == 'く':
+			explanation = fmt.Sprintf("%s is the exception among く verbs: %s, not %s.", sp.base, sp.answer, given)
+		case t.tou && last == 'う':
+			explanation = fmt.Sprintf("%s keeps its う in the %s: %s, not %s.", sp.base, formName, sp.answer, given)
+		default:
+			explanation = fmt.Sprintf("Verbs ending in %c take %s in the %s (%s), not %s.",
+				last, strings.TrimPrefix(sp.answer, prefix), formName, sp.answer, strings.TrimPrefix(given, prefix))
+		}
+		return conjugationVerdict{Category: models.ConjugationErrorEuphonic, Explanation: explanation}, true
+	}
+	return conjugationVerdict{}, false
+}
+
+// conjugationStem is the part of a word a form attaches to, with a label for
+// explanations. Forms without a regular stem (する, godan て/た) return "".
+func conjugationStem(base, group, form string, t wordTraits) (stem, label string) {
+	runes := []rune(base)
+	if len(runes) < 2 {
+		return "", ""
+	}
+	prefix := string(runes[:len(runes)-1])
+	switch group {
+	case models.ConjugationGodan:
+		label = godanStemLabels[form]
+		idx, ok := godanStemRows[label]
+		row, known := godanRows[runes[len(runes)-1]]
+		if !ok || !known {
+			return "", ""
+		}
+		if idx == 1 && t.honorific {
+			return prefix + "い", label
+		}
+		return prefix + row[idx], label
+	case models.ConjugationIchidan:
+		return prefix, "stem (drop る)"
+	case models.ConjugationIAdjective:
+		if t.ii && strings.HasSuffix(base, "いい") {
+			return strings.TrimSuffix(base, "いい") + "よ", "stem よ (いい borrows from よい)"
+		}
+		if form == "polite" {
+			return base, "dictionary form"
+		}
+		return prefix, "stem (drop い)"
+	case models.ConjugationNaAdjective:
+		return strings.TrimSuffix(base, "だ"), "stem"
+	}
+	return "", ""
+}
+
+// conjugationGroupRivals lists the groups a learner might mistake a word's group for
+func conjugationGroupRivals(group string) []string {
+	switch group {
+	case models.ConjugationGodan:
+		return []string{models.ConjugationIchidan}
+	case models.ConjugationIchidan:
+		return []string{models.ConjugationGodan}
+	case models.ConjugationIrregular:
+		return []string{models.ConjugationIchidan, models.ConjugationGodan}
+	case models.ConjugationIAdjective:
+		return []string{models.ConjugationNaAdjective}
+	case models.ConjugationNaAdjective:
+		return []string{models.ConjugationIAdjective}
+	}
+	return nil
+}
+
+// conjugationGroupNote explains which group a word really belongs to
+func conjugationGroupNote(base, group, mistaken string) string {
+	switch group {
+	case models.ConjugationGodan:
+		if strings.HasSuffix(base, "る") {
+			return fmt.Sprintf("%s looks like a Group 2 verb but is Group 1 (godan).", base)
+		}
+		return fmt.Sprintf("%s is a Group 1 (godan) verb.", base)
+	case models.ConjugationIchidan:
+		return fmt.Sprintf("%s is a Group 2 (ichidan) verb: drop る and add the ending.", base)
+	case models.ConjugationIrregular:
+		return fmt.Sprintf("%s is irregular and doesn't follow %s rules.", base, mistaken)
+	case models.ConjugationIAdjective:
+		return fmt.Sprintf("%s is an い-adjective.", base)
+	case models.ConjugationNaAdjective:
+		return fmt.Sprintf("%s is a な-adjective even though it ends in い.", base)
+	}
+	return ""
+}
+
+// conjugationFormName is a form's display name (て形), or its key if unknown
+func conjugationFormName(form string) string {
+	if info := conjugationFormInfo(form); info != nil {
+		return info.DisplayName
+	}
+	return form
+}
+
+func tableForm(table *models.ConjugationTable, form string) *models.ConjugatedForm {
+	if table == nil {
+		return nil
+	}
+	for i := range table.Forms {
+		if table.Forms[i].Form == form {
+			return &table.Forms[i]
+		}
+	}
+	return nil
+}
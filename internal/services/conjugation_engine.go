@@ -0,0 +1,402 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// honorificGodanVerbs take an い masu stem and imperative (ください, not くだります)
+var honorificGodanVerbs = []string{"くださる", "下さる", "なさる", "いらっしゃる", "おっしゃる", "仰る", "ござる"}
+
+// godanStemLabels names the stem a godan form is built on, for hints
+var godanStemLabels = map[string]string{
+	"polite":               "い-stem",
+	"polite_negative":      "い-stem",
+	"polite_past":          "い-stem",
+	"polite_past_negative": "い-stem",
+	"polite_volitional":    "い-stem",
+	"nai":                  "あ-stem",
+	"nakatta":              "あ-stem",
+	"passive":              "あ-stem",
+	"causative":            "あ-stem",
+	"potential":            "え-stem",
+	"imperative":           "え-stem",
+	"conditional":          "え-stem",
+	"volitional":           "お-stem",
+	"te":                   "sound change",
+	"ta":                   "sound change",
+}
+
+// wordTraits flags the irregular words a group alone doesn't capture
+type wordTraits struct {
+	iku       bool // 行く: 行って, not 行いて
+	tou       bool // 問う: 問うて, not 問って
+	aru       bool // ある: ない, not あらない
+	honorific bool // くださる: ください, くださいます
+	ii        bool // いい: よくない, not いくない
+}
+
+func traitsOf(word, reading string) wordTraits {
+	var t wordTraits
+	for _, w := range []string{word, reading} {
+		t.iku = t.iku || strings.HasSuffix(w, "行く") || w == "いく" || w == "ゆく"
+		t.tou = t.tou || strings.HasSuffix(w, "問う") || w == "とう" || strings.HasSuffix(w, "請う") || strings.HasSuffix(w, "乞う")
+		t.aru = t.aru || w == "ある" || w == "有る" || w == "在る"
+		t.ii = t.ii || w == "いい" || w == "良い" || w == "よい" || strings.HasSuffix(w, "っこいい")
+		for _, h := range honorificGodanVerbs {
+			t.honorific = t.honorific || strings.HasSuffix(w, h)
+		}
+	}
+	return t
+}
+
+// InferConjugationGroup guesses a word's group from its ending. The reading is
+// what decides between ichidan and godan る-verbs (見る is みる). Nouns that take
+// な can't be told apart by shape, so な-adjectives are only inferred from だ.
+func InferConjugationGroup(word, reading string) string {
+	if reading == "" {
+		reading = word
+	}
+	runes := []rune(reading)
+	if len(runes) == 0 {
+		return ""
+	}
+	last := runes[len(runes)-1]
+
+	for _, na := range naAdjectivesEndingInI {
+		if word == na || reading == na {
+			return models.ConjugationNaAdjective
+		}
+	}
+	switch {
+	case isCopula(word):
+		return models.ConjugationCopula
+	case strings.HasSuffix(word, "する") || strings.HasSuffix(reading, "する"):
+		return models.ConjugationIrregular
+	case strings.HasSuffix(word, "来る") || reading == "くる":
+		return models.ConjugationIrregular
+	case strings.HasSuffix(word, "だ"):
+		return models.ConjugationNaAdjective
+	case last == 'い' && len(runes) > 1:
+		return models.ConjugationIAdjective
+	case last == 'る' && len(runes) > 1 && isIchidanStem(runes[len(runes)-2], word) && isIchidanStem(runes[len(runes)-2], reading):
+		return models.ConjugationIchidan
+	}
+	if _, ok := godanRows[last]; ok {
+		return models.ConjugationGodan
+	}
+	return ""
+}
+
+// okuriganaMatches reports whether the kana trailing a word's kanji also ends
+// its reading (落ちる/おちる, not 落ちる/おとる)
+func okuriganaMatches(word, reading string) bool {
+	runes := []rune(word)
+	i := len(runes)
+	for i > 0 && !isKanjiRune(runes[i-1]) {
+		i--
+	}
+	return strings.HasSuffix(reading, string(runes[i:]))
+}
+
+// ConjugateWord generates every form in GetConjugationForms (plus the polite
+// variants, the adjective forms and, for verbs, keigo) that applies to a verb,
+// adjective or copula. group may be empty to infer it from the ending; reading
+// defaults to the word itself.
+func ConjugateWord(word, reading, group string) (*models.ConjugationTable, error) {
+	word, reading = strings.TrimSpace(word), strings.TrimSpace(reading)
+	if word == "" {
+		return nil, fmt.Errorf("word is required")
+	}
+	if reading == "" {
+		reading = word
+	}
+	if !okuriganaMatches(word, reading) {
+		return nil, fmt.Errorf("reading %s doesn't match %s", reading, word)
+	}
+	if group == "" {
+		group = InferConjugationGroup(word, reading)
+		if group == "" {
+			return nil, fmt.Errorf("cannot tell how %s conjugates", word)
+		}
+	}
+
+	traits := traitsOf(word, reading)
+	written := conjugateSurface(word, group, traits)
+	if written == nil {
+		return nil, fmt.Errorf("cannot conjugate %s as %s", word, group)
+	}
+	kana := conjugateSurface(reading, group, traits)
+
+	category := "verb"
+	switch group {
+	case models.ConjugationIAdjective, models.ConjugationNaAdjective:
+		category = "adjective"
+	case models.ConjugationCopula:
+		category = "copula"
+	}
+	table := &models.ConjugationTable{
+		BaseForm: word,
+		Reading:  reading,
+		Group:    group,
+		Category: category,
+	}
+	forms := append(models.GetConjugationForms(), models.GetPoliteVariantForms()...)
+	forms = append(forms, models.GetAdjectiveForms()...)
+	for _, f := range forms {
+		surfaces, ok := written[f.Name]
+		if !ok {
+			continue
+		}
+		cf := models.ConjugatedForm{
+			Form:         f.Name,
+			Surface:      surfaces[0],
+			Alternatives: surfaces[1:],
+			Hint:         conjugationHint(word, surfaces[0], group, f.Name, traits),
+		}
+		if r := kana[f.Name]; len(r) > 0 {
+			cf.Reading = r[0]
+		}
+		table.Forms = append(table.Forms, cf)
+	}
+	table.Forms = append(table.Forms, keigoForms(word, reading, group, written)...)
+	return table, nil
+}
+
+// conjugateSurface conjugates one spelling of a word. Each form maps to its
+// surfaces, the first being the canonical answer. Forms that don't apply to the
+// word (an adjective's passive) are left out.
+func conjugateSurface(word, group string, t wordTraits) map[string][]string {
+	runes := []rune(word)
+	if len(runes) == 0 {
+		return nil
+	}
+	switch group {
+	case models.ConjugationIAdjective:
+		return conjugateIAdjective(word, t)
+	case models.ConjugationNaAdjective:
+		return conjugateNaAdjective(word)
+	case models.ConjugationCopula:
+		return conjugateCopula(word)
+	}
+
+	last := runes[len(runes)-1]
+	prefix := string(runes[:len(runes)-1])
+	out := map[string][]string{}
+	set := func(masu, nai, te, ta, potential, passive, causative, imperative, conditional, volitional string) {
+		out["polite"] = []string{masu + "ます"}
+		out["polite_negative"] = []string{masu + "ません"}
+		out["polite_past"] = []string{masu + "ました"}
+		out["polite_past_negative"] = []string{masu + "ませんでした"}
+		out["polite_volitional"] = []string{masu + "ましょう"}
+		out["te"] = []string{te}
+		out["ta"] = []string{ta}
+		out["nai"] = []string{nai + "ない"}
+		out["nakatta"] = []string{nai + "なかった"}
+		out["potential"] = []string{potential}
+		out["passive"] = []string{passive}
+		out["causative"] = []string{causative}
+		out["imperative"] = []string{imperative}
+		out["conditional"] = []string{conditional}
+		out["volitional"] = []string{volitional}
+	}
+
+	switch group {
+	case models.ConjugationIrregular:
+		switch {
+		case strings.HasSuffix(word, "する"):
+			p := strings.TrimSuffix(word, "する")
+			set(p+"し", p+"し", p+"して", p+"した", p+"できる", p+"される", p+"させる", p+"しろ", p+"すれば", p+"しよう")
+		case strings.HasSuffix(word, "来る"):
+			p := strings.TrimSuffix(word, "来る")
+			set(p+"来", p+"来", p+"来て", p+"来た", p+"来られる", p+"来られる", p+"来させる", p+"来い", p+"来れば", p+"来よう")
+		case strings.HasSuffix(word, "くる"):
+			p := strings.TrimSuffix(word, "くる")
+			set(p+"き", p+"こ", p+"きて", p+"きた", p+"こられる", p+"こられる", p+"こさせる", p+"こい", p+"くれば", p+"こよう")
+		default:
+			return nil
+		}
+	case models.ConjugationIchidan:
+		if last != 'る' || len(runes) < 2 {
+			return nil
+		}
+		imperative := prefix + "ろ"
+		if strings.HasSuffix(word, "くれる") || strings.HasSuffix(word, "呉れる") {
+			imperative = prefix // くれ
+		}
+		set(prefix, prefix, prefix+"て", prefix+"た", prefix+"られる", prefix+"られる", prefix+"させる", imperative, prefix+"れば", prefix+"よう")
+	case models.ConjugationGodan:
+		row, ok := godanRows[last]
+		if !ok {
+			return nil
+		}
+		te := godanTe[last]
+		if t.iku && last == 'く' {
+			te = [2]string{"って", "った"}
+		}
+		if t.tou && last == 'う' {
+			te = [2]string{"うて", "うた"}
+		}
+		masu, imperative := prefix+row[1], prefix+row[2]
+		if t.honorific && last == 'る' {
+			masu, imperative = prefix+"い", prefix+"い"
+		}
+		nai := prefix + row[0]
+		set(masu, nai, prefix+te[0], prefix+te[1], prefix+row[2]+"る", nai+"れる", nai+"せる", imperative, prefix+row[2]+"ば", prefix+row[3]+"う")
+		if t.aru {
+			out["nai"] = []string{"ない"}
+			out["nakatta"] = []string{"なかった"}
+			delete(out, "potential")
+			delete(out, "passive")
+		}
+	default:
+		return nil
+	}
+	return out
+}
+
+// conjugateIAdjective conjugates 高い-type adjectives; いい borrows its forms from よい
+func conjugateIAdjective(word string, t wordTraits) map[string][]string {
+	runes := []rune(word)
+	if len(runes) < 2 || runes[len(runes)-1] != 'い' {
+		return nil
+	}
+	stem := string(runes[:len(runes)-1])
+	if t.ii && strings.HasSuffix(word, "いい") {
+		stem = strings.TrimSuffix(word, "いい") + "よ"
+	}
+	return map[string][]string{
+		"polite":               {word + "です"},
+		"te":                   {stem + "くて"},
+		"ta":                   {stem + "かった"},
+		"nai":                  {stem + "くない"},
+		"nakatta":              {stem + "くなかった"},
+		"conditional":          {stem + "ければ"},
+		"polite_negative":      {stem + "くありません", stem + "くないです"},
+		"polite_past":          {stem + "かったです"},
+		"polite_past_negative": {stem + "くありませんでした", stem + "くなかったです"},
+		"adj_negative":         {stem + "くない"},
+		"adj_past":             {stem + "かった"},
+		"adj_negative_past":    {stem + "くなかった"},
+		"adj_te":               {stem + "くて"},
+		"adj_adverbial":        {stem + "く"},
+		"adj_conditional":      {stem + "ければ", word + "なら"},
+		"adj_nominal":          {stem + "さ"},
+	}
+}
+
+// conjugateNaAdjective conjugates 静か(だ)-type adjectives, which inflect through the copula
+func conjugateNaAdjective(word string) map[string][]string {
+	stem := strings.TrimSuffix(strings.TrimSuffix(word, "だ"), "な")
+	if stem == "" {
+		return nil
+	}
+	return map[string][]string{
+		"polite":               {stem + "です"},
+		"te":                   {stem + "で"},
+		"ta":                   {stem + "だった"},
+		"nai":                  {stem + "ではない", stem + "じゃない"},
+		"nakatta":              {stem + "ではなかった", stem + "じゃなかった"},
+		"conditional":          {stem + "なら", stem + "ならば"},
+		"polite_negative":      {stem + "ではありません", stem + "じゃありません", stem + "ではないです", stem + "じゃないです"},
+		"polite_past":          {stem + "でした"},
+		"polite_past_negative": {stem + "ではありませんでした", stem + "じゃありませんでした"},
+		"adj_negative":         {stem + "ではない", stem + "じゃない"},
+		"adj_past":             {stem + "だった"},
+		"adj_negative_past":    {stem + "ではなかった", stem + "じゃなかった"},
+		"adj_te":               {stem + "で"},
+		"adj_adverbial":        {stem + "に"},
+		"adj_conditional":      {stem + "なら", stem + "ならば", stem + "であれば"},
+		"adj_nominal":          {stem + "さ"},
+	}
+}
+
+// isCopula reports whether a word is the plain or polite copula
+func isCopula(word string) bool {
+	return word == "だ" || word == "です"
+}
+
+// conjugateCopula conjugates だ and です. The copula has no adverbial or さ noun,
+// and です has no conditional of its own.
+func conjugateCopula(word string) map[string][]string {
+	switch word {
+	case "だ":
+		return map[string][]string{
+			"polite":            {"です"},
+			"adj_negative":      {"ではない", "じゃない"},
+			"adj_past":          {"だった"},
+			"adj_negative_past": {"ではなかった", "じゃなかった"},
+			"adj_te":            {"で"},
+			"adj_conditional":   {"なら", "ならば", "であれば"},
+		}
+	case "です":
+		return map[string][]string{
+			"adj_negative":      {"ではありません", "じゃありません", "ではないです", "じゃないです"},
+			"adj_past":          {"でした"},
+			"adj_negative_past": {"ではありませんでした", "じゃありませんでした", "ではなかったです", "じゃなかったです"},
+			"adj_te":            {"でして"},
+		}
+	}
+	return nil
+}
+
+// conjugationEnding is the part of a conjugated form that differs from the
+// dictionary form (行く → 行かない gives かない)
+func conjugationEnding(base, surface string) string {
+	b, s := []rune(base), []rune(surface)
+	i := 0
+	for i < len(b) && i < len(s) && b[i] == s[i] {
+		i++
+	}
+	return string(s[i:])
+}
+
+// conjugationHint explains the rule behind one generated form, in the style of the seeded drills
+func conjugationHint(base, surface, group, form string, t wordTraits) string {
+	runes := []rune(base)
+	switch group {
+	case models.ConjugationGodan:
+		last := runes[len(runes)-1]
+		prefix := string(runes[:len(runes)-1])
+		ending := strings.TrimPrefix(surface, prefix)
+		switch {
+		case t.iku && (form == "te" || form == "ta"):
+			wrong := "いて"
+			if form == "ta" {
+				wrong = "いた"
+			}
+			return fmt.Sprintf("EXCEPTION: %s→%s (not %s)", base, surface, prefix+wrong)
+		case t.tou && (form == "te" || form == "ta"):
+			wrong := "って"
+			if form == "ta" {
+				wrong = "った"
+			}
+			return fmt.Sprintf("EXCEPTION: %s→%s (not %s)", base, surface, prefix+wrong)
+		case t.aru && (form == "nai" || form == "nakatta"):
+			return fmt.Sprintf("EXCEPTION: %s→%s (not あら%s)", base, surface, surface)
+		case t.honorific && (strings.HasPrefix(form, "polite") || form == "imperative"):
+			return fmt.Sprintf("EXCEPTION: %s→%s (い-stem, not り)", base, surface)
+		}
+		return fmt.Sprintf("Group 1: %c→%s (%s)", last, ending, godanStemLabels[form])
+	case models.ConjugationIchidan:
+		return fmt.Sprintf("Group 2: Drop る + %s", strings.TrimPrefix(surface, string(runes[:len(runes)-1])))
+	case models.ConjugationIrregular:
+		return fmt.Sprintf("IRREGULAR: %s→%s", base, surface)
+	case models.ConjugationIAdjective:
+		if t.ii {
+			return fmt.Sprintf("EXCEPTION: いい conjugates as よい (%s)", surface)
+		}
+		if strings.HasPrefix(surface, base) {
+			return fmt.Sprintf("い-adjective: Keep い + %s", strings.TrimPrefix(surface, base))
+		}
+		return fmt.Sprintf("い-adjective: Drop い + %s", strings.TrimPrefix(surface, string(runes[:len(runes)-1])))
+	case models.ConjugationNaAdjective:
+		stem := strings.TrimSuffix(strings.TrimSuffix(base, "だ"), "な")
+		return fmt.Sprintf("な-adjective: %s + %s", stem, strings.TrimPrefix(surface, stem))
+	case models.ConjugationCopula:
+		return fmt.Sprintf("Copula: %s→%s", base, surface)
+	}
+	return ""
+}
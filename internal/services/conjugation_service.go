@@ -2,6 +2,10 @@ package services
 
 import (
 	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,13 +14,35 @@ import (
 )
 
 type ConjugationService struct {
-	conjRepo *repository.ConjugationRepository
+	conjRepo    *repository.ConjugationRepository
+	vocabRepo   *repository.VocabRepository
+	deinflector *Deinflector
+
+	// Challenges generated from the vocabulary, by form and level. The
+	// vocabulary only changes when seeds load at startup, so they're built
+	// once; each is saved the first time it's handed out.
+	poolMu sync.Mutex
+	pools  map[string][]*models.ConjugationChallenge
+	saved  map[string]bool
 }
 
-func NewConjugationService(conjRepo *repository.ConjugationRepository) *ConjugationService {
-	return &ConjugationService{conjRepo: conjRepo}
+func NewConjugationService(conjRepo *repository.ConjugationRepository, vocabRepo *repository.VocabRepository, deinflector *Deinflector) *ConjugationService {
+	return &ConjugationService{
+		conjRepo:    conjRepo,
+		vocabRepo:   vocabRepo,
+		deinflector: deinflector,
+		pools:       make(map[string][]*models.ConjugationChallenge),
+		saved:       make(map[string]bool),
+	}
 }
 
+// A form counts as completed, unlocking the next one in its track, after this
+// many attempts at this accuracy or better
+const (
+	formCompletedAttempts = 10
+	formCompletedMastery  = 80.0
+)
+
 // StartDrillSession starts a new conjugation drill session for a user (backward compatible)
 func (s *ConjugationService) StartDrillSession(userID string, targetForm string) (*models.ConjugationSessionResponse, error) {
 	return s.StartDrillSessionWithLevel(userID, targetForm, "N5")
@@ -31,27 +57,32 @@ func (s *ConjugationService) StartDrillSessionWithLevel(userID string, targetFor
 		maxLevel = "N5" // Default to N5 if invalid
 	}
 
+	session := &models.ConjugationSession{
+		UserID:      userID,
+		CurrentForm: targetForm,
+		Mode:        models.ConjugationModePractice,
+		MaxLevel:    maxLevel,
+	}
+	return s.startSession(session, maxLevel, 10)
+}
+
+// startSession picks up to limit challenges for the session's form and saves it
+func (s *ConjugationService) startSession(session *models.ConjugationSession, maxLevel string, limit int) (*models.ConjugationSessionResponse, error) {
+	targetForm := session.CurrentForm
+
 	// Get challenges for the form up to maxLevel
-	challenges, err := s.conjRepo.GetChallengesByFormUpToLevel(targetForm, maxLevel, 10)
+	challenges, err := s.conjRepo.GetChallengesByFormUpToLevel(targetForm, maxLevel, limit)
 	if err != nil {
 		return nil, err
 	}
+	challenges = filterConjugationTrack(challenges, targetForm)
+	challenges = s.topUpChallenges(challenges, targetForm, maxLevel, limit)
 	if len(challenges) == 0 {
 		return nil, fmt.Errorf("no challenges found for form: %s", targetForm)
 	}
+	describeChainSteps(challenges)
 
-	// Create session
-	session := &models.ConjugationSession{
-		ID:             uuid.New().String(),
-		UserID:         userID,
-		CurrentForm:    targetForm,
-		CurrentIndex:   0,
-		TotalQuestions: len(challenges),
-		StartTime:      time.Now(),
-		LastActive:     time.Now(),
-	}
-
-	if err := s.conjRepo.CreateSession(session); err != nil {
+	if err := s.createSession(session, challenges); err != nil {
 		return nil, err
 	}
 
@@ -72,29 +103,86 @@ func (s *ConjugationService) StartDrillSessionWithLevel(userID string, targetFor
 	}, nil
 }
 
+// createSession saves a new session along with the challenges it drills, so it
+// can be resumed with the same list
+func (s *ConjugationService) createSession(session *models.ConjugationSession, challenges []*models.ConjugationChallenge) error {
+	now := time.Now()
+	session.ID = uuid.New().String()
+	session.Status = models.ConjugationSessionActive
+	session.CurrentIndex = 0
+	session.TotalQuestions = len(challenges)
+	session.StartTime = now
+	session.LastActive = now
+	if session.TimeLimitSec > 0 {
+		endsAt := now.Add(time.Duration(session.TimeLimitSec) * time.Second)
+		session.EndsAt = &endsAt
+	}
+	session.ChallengeIDs = make([]string, len(challenges))
+	for i, c := range challenges {
+		session.ChallengeIDs[i] = c.ID
+	}
+	return s.conjRepo.CreateSession(session)
+}
+
+// StartChainSession starts a drill where each challenge stacks several forms
+// (causative → passive → nai → ta), answered step by step
+func (s *ConjugationService) StartChainSession(userID string, forms []string, maxLevel string) (*models.ConjugationSessionResponse, error) {
+	if err := validateConjugationChain(forms); err != nil {
+		return nil, err
+	}
+	return s.StartDrillSessionWithLevel(userID, models.ConjugationChainKey(forms), maxLevel)
+}
+
+// StartKeigoSession starts a drill converting verbs to and from sonkeigo and
+// kenjougo. Keigo is N2 material, so vocabulary up to N2 is drilled by default.
+func (s *ConjugationService) StartKeigoSession(userID, form, maxLevel string) (*models.ConjugationSessionResponse, error) {
+	if !isKeigoForm(form) {
+		return nil, fmt.Errorf("not a keigo form: %s", form)
+	}
+	if _, ok := curriculumLevels[maxLevel]; !ok {
+		maxLevel = "N2"
+	}
+	return s.StartDrillSessionWithLevel(userID, form, maxLevel)
+}
+
+// GetKeigoReference lists the keigo forms and the verbs with special keigo
+func (s *ConjugationService) GetKeigoReference() *models.KeigoReference {
+	return keigoReference()
+}
+
+// GetChains lists the commonly drilled form chains
+func (s *ConjugationService) GetChains() []models.ConjugationChainType {
+	return models.GetConjugationChains()
+}
+
 // GetNextChallenge gets the next challenge in a session
 func (s *ConjugationService) GetNextChallenge(sessionID, userID string) (*models.ConjugationChallengeResponse, error) {
 	// Get session
-	session, err := s.conjRepo.GetActiveSession(userID)
-	if err != nil || session == nil || session.ID != sessionID {
-		return nil, fmt.Errorf("session not found or expired")
+	session, err := s.loadSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSessionOpen(session); err != nil {
+		return nil, err
 	}
 
-	// Get challenges for current form
-	challenges, err := s.conjRepo.GetChallengesByForm(session.CurrentForm, "N4", 20)
+	// Get the session's challenges
+	challenges, err := s.sessionChallenges(session)
 	if err != nil {
 		return nil, err
 	}
 
-	if session.CurrentIndex >= len(challenges) {
+	challenge := currentChallenge(session, challenges)
+	if challenge == nil {
 		// Form completed, could advance to next form
 		return nil, fmt.Errorf("form completed")
 	}
+	describeChainSteps([]*models.ConjugationChallenge{challenge})
 
 	formInfo := s.getFormInfo(session.CurrentForm)
 
 	return &models.ConjugationChallengeResponse{
-		Challenge: challenges[session.CurrentIndex],
+		Challenge: challenge,
 		Progress: &models.ConjugationProgress{
 			CurrentForm:     session.CurrentForm,
 			CurrentStreak:   session.Streak,
@@ -108,7 +196,8 @@ func (s *ConjugationService) GetNextChallenge(sessionID, userID string) (*models
 }
 
 // SubmitAnswer checks user's answer and returns result
-func (s *ConjugationService) SubmitAnswer(userID, sessionID, challengeID, answer string, timeSpentMs int) (*models.ConjugationSubmitResponse, error) {
+// stepAnswers are the intermediate results of a chained challenge and may be nil.
+func (s *ConjugationService) SubmitAnswer(userID, sessionID, challengeID, answer string, stepAnswers []string, timeSpentMs int) (*models.ConjugationSubmitResponse, error) {
 	// Get challenge
 	challenge, err := s.conjRepo.GetChallengeByID(challengeID)
 	if err != nil {
@@ -116,13 +205,69 @@ func (s *ConjugationService) SubmitAnswer(userID, sessionID, challengeID, answer
 	}
 
 	// Get session
-	session, err := s.conjRepo.GetActiveSession(userID)
-	if err != nil || session == nil {
-		return nil, fmt.Errorf("session not found")
+	session, err := s.loadSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Timed runs are clocked by the server from when the previous answer (or the
+	// start) was processed; other sessions trust the client's timing if sent
+	now := time.Now()
+	responseMs := int(now.Sub(session.LastActive).Milliseconds())
+	timed := session.Mode == models.ConjugationModeTimed && session.EndsAt != nil
+	if timed && session.Status == models.ConjugationSessionCompleted {
+		run, err := s.speedRunResult(session, now)
+		if err != nil {
+			return nil, err
+		}
+		remaining := 0
+		return &models.ConjugationSubmitResponse{
+			CorrectAnswer:   challenge.FullAnswer,
+			Explanation:     "Time is up. This answer was not counted.",
+			FormCompleted:   true,
+			SessionID:       session.ID,
+			TimeRemainingMs: &remaining,
+			TimeUp:          true,
+			SpeedRun:        run,
+		}, nil
+	}
+	if err := checkSessionOpen(session); err != nil {
+		return nil, err
 	}
 
-	// Check answer (flexible matching)
-	isCorrect := s.checkAnswer(answer, challenge.FullAnswer, challenge.TargetEnding)
+	// Answers must follow the session's own list, so a second device or a
+	// stale tab can't answer a challenge the session has moved past
+	challenges, err := s.sessionChallenges(session)
+	if err != nil {
+		return nil, err
+	}
+	if len(session.ChallengeIDs) > 0 {
+		if current := currentChallenge(session, challenges); current == nil || current.ID != challengeID {
+			return nil, fmt.Errorf("challenge %s is not the current challenge of this session", challengeID)
+		}
+	}
+
+	if !timed && timeSpentMs > 0 {
+		responseMs = timeSpentMs
+	} else if !timed && responseMs > maxUntimedResponseMs {
+		responseMs = 0 // Probably left idle, not thinking
+	}
+
+	// Check answer, classifying what went wrong if it isn't right. Chained
+	// challenges are checked per step and remember the first failing one.
+	var verdict conjugationVerdict
+	var steps []models.ConjugationStepResult
+	var failedStep *int
+	if forms := models.ConjugationChainForms(challenge.TargetForm); forms != nil {
+		cv := checkChainAnswer(challenge, forms, stepAnswers, answer)
+		verdict, steps = cv.conjugationVerdict, cv.Steps
+		if cv.FailedStep >= 0 {
+			failedStep = &cv.FailedStep
+		}
+	} else {
+		verdict = checkConjugationAnswer(challenge, answer)
+	}
+	isCorrect := verdict.Correct
 
 	// Update session
 	session.TotalQuestions++
@@ -137,7 +282,10 @@ func (s *ConjugationService) SubmitAnswer(userID, sessionID, challengeID, answer
 		session.Streak = 0
 	}
 	session.CurrentIndex++
-	session.LastActive = time.Now()
+	session.LastActive = now
+	if !timed && session.CurrentIndex >= len(challenges) {
+		session.Status = models.ConjugationSessionCompleted
+	}
 
 	// Record attempt
 	attempt := &models.ConjugationAttempt{
@@ -149,8 +297,11 @@ func (s *ConjugationService) SubmitAnswer(userID, sessionID, challengeID, answer
 		BaseForm:     challenge.BaseForm,
 		UserAnswer:   answer,
 		IsCorrect:    isCorrect,
-		TimeSpentSec: timeSpentMs / 1000,
-		CreatedAt:    time.Now(),
+		ErrorCategory: verdict.Category,
+		FailedStep:   failedStep,
+		TimeSpentSec: responseMs / 1000,
+		TimeSpentMs:  responseMs,
+		CreatedAt:    now,
 	}
 
 	if err := s.conjRepo.RecordAttempt(attempt); err != nil {
@@ -167,9 +318,9 @@ func (s *ConjugationService) SubmitAnswer(userID, sessionID, challengeID, answer
 	formCompleted := false
 	allFormsCompleted := false
 
-	challenges, err := s.conjRepo.GetChallengesByForm(session.CurrentForm, "N4", 20)
-	if err == nil && session.CurrentIndex < len(challenges) {
-		nextChallenge = challenges[session.CurrentIndex]
+	if next := currentChallenge(session, challenges); next != nil {
+		nextChallenge = next
+		describeChainSteps([]*models.ConjugationChallenge{nextChallenge})
 		nextFormInfo = s.getFormInfo(session.CurrentForm)
 	} else {
 		formCompleted = true
@@ -177,11 +328,23 @@ func (s *ConjugationService) SubmitAnswer(userID, sessionID, challengeID, answer
 	}
 
 	explanation := s.getExplanation(challenge, isCorrect)
+	if !isCorrect && verdict.Explanation != "" {
+		explanation = verdict.Explanation
+	}
+
+	var remaining *int
+	if timed {
+		ms := max(int(session.EndsAt.Sub(now).Milliseconds()), 0)
+		remaining = &ms
+	}
 
 	return &models.ConjugationSubmitResponse{
 		IsCorrect:         isCorrect,
 		CorrectAnswer:     challenge.FullAnswer,
+		ErrorCategory:     verdict.Category,
 		Explanation:       explanation,
+		Steps:             steps,
+		FailedStep:        failedStep,
 		NextChallenge:     nextChallenge,
 		NextFormInfo:      nextFormInfo,
 		FormCompleted:     formCompleted,
@@ -194,66 +357,79 @@ func (s *ConjugationService) SubmitAnswer(userID, sessionID, challengeID, answer
 			DailyGoal:        20,
 			DailyCompleted:   session.CorrectCount,
 		},
-		SessionID: session.ID,
+		SessionID:       session.ID,
+		ResponseMs:      responseMs,
+		TimeRemainingMs: remaining,
 	}, nil
 }
 
-// GetProgress retrieves user's conjugation progress
-func (s *ConjugationService) GetProgress(userID string) (*models.ConjugationProgress, error) {
-	return s.conjRepo.GetProgressStats(userID)
+// Conjugate returns every form of a verb or adjective
+func (s *ConjugationService) Conjugate(word, reading, group string) (*models.ConjugationTable, error) {
+	return ConjugateWord(word, reading, group)
 }
 
-// Helper: check answer with flexible matching
-func (s *ConjugationService) checkAnswer(userAnswer, correctAnswer, targetEnding string) bool {
-	// Normalize
-	user := []rune(userAnswer)
-	correct := []rune(correctAnswer)
+// Analyze explains which dictionary form an inflected word comes from and how
+func (s *ConjugationService) Analyze(word string) (*models.DeinflectionResult, error) {
+	return s.deinflector.Deinflect(word)
+}
 
-	// Check full match
-	if string(user) == string(correct) {
-		return true
+// GetProgress retrieves user's conjugation progress
+func (s *ConjugationService) GetProgress(userID string) (*models.ConjugationProgress, error) {
+	progress, err := s.conjRepo.GetProgressStats(userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if user provided just the ending correctly
-	if len(user) > 0 {
-		// Extract ending from correct answer
-		// For 食べて, ending is て; for 行かない, ending is ない
-		endingOK := false
-		if string(user) == targetEnding {
-			endingOK = true
-		}
-
-		// Also accept full form
-		if endingOK {
-			return true
-		}
+	// Verb and adjective forms unlock separately, each in learning order
+	tracks := map[string][]models.ConjugationFormType{
+		models.ConjugationTrackVerb:      models.GetConjugationForms(),
+		models.ConjugationTrackAdjective: models.GetAdjectiveForms(),
+	}
+	for _, name := range []string{models.ConjugationTrackVerb, models.ConjugationTrackAdjective} {
+		track := conjugationTrack(name, tracks[name], progress)
+		progress.FormsUnlocked = append(progress.FormsUnlocked, track.FormsUnlocked...)
+		progress.FormsCompleted = append(progress.FormsCompleted, track.FormsCompleted...)
+		progress.Tracks = append(progress.Tracks, track)
 	}
+	return progress, nil
+}
 
-	// Allow small typos (one character difference)
-	if len(user) == len(correct) {
-		diff := 0
-		for i := range user {
-			if user[i] != correct[i] {
-				diff++
-			}
+// conjugationTrack works out which of a track's forms are completed (enough
+// attempts at high enough accuracy) and unlocked (the first form, and every
+// form after a completed one)
+func conjugationTrack(name string, forms []models.ConjugationFormType, progress *models.ConjugationProgress) models.ConjugationTrack {
+	sort.SliceStable(forms, func(i, j int) bool { return forms[i].Order < forms[j].Order })
+
+	track := models.ConjugationTrack{Name: name, FormsUnlocked: []string{}, FormsCompleted: []string{}}
+	unlocked := true
+	total := 0.0
+	for _, f := range forms {
+		total += progress.FormMastery[f.Name]
+		if !unlocked {
+			continue
 		}
-		if diff <= 1 {
-			return true
+		track.FormsUnlocked = append(track.FormsUnlocked, f.Name)
+		if progress.FormAttempts[f.Name] >= formCompletedAttempts && progress.FormMastery[f.Name] >= formCompletedMastery {
+			track.FormsCompleted = append(track.FormsCompleted, f.Name)
+			continue
 		}
+		if track.NextForm == "" {
+			track.NextForm = f.Name
+		}
+		unlocked = false
 	}
-
-	return false
+	if len(forms) > 0 {
+		track.Mastery = total / float64(len(forms))
+	}
+	return track
 }
 
 // Helper: get form info
 func (s *ConjugationService) getFormInfo(formName string) *models.ConjugationFormType {
-	forms := models.GetConjugationForms()
-	for _, f := range forms {
-		if f.Name == formName {
-			return &f
-		}
+	if chain := models.ConjugationChainForms(formName); chain != nil {
+		return chainFormInfo(chain)
 	}
-	return nil
+	return conjugationFormInfo(formName)
 }
 
 // Helper: generate explanation
@@ -272,6 +448,18 @@ func (s *ConjugationService) GetWeakPointsAnalysis(userID string) (*models.WeakP
 		return nil, err
 	}
 	
+	// Why answers went wrong, per form
+	errorsByForm, err := s.conjRepo.GetErrorCategoriesByForm(userID)
+	if err != nil {
+		return nil, err
+	}
+	errorCategories := map[string]int{}
+	for _, counts := range errorsByForm {
+		for category, n := range counts {
+			errorCategories[category] += n
+		}
+	}
+
 	// Identify weak forms (accuracy < 70%)
 	var weakForms []models.WeakForm
 	var strongForms []models.WeakForm
@@ -284,6 +472,11 @@ func (s *ConjugationService) GetWeakPointsAnalysis(userID string) (*models.WeakP
 			Form:     form,
 			Accuracy: accuracy,
 			Total:    total,
+			Errors:   errorsByForm[form],
+		}
+		if chain := models.ConjugationChainForms(form); chain != nil {
+			failedSteps, _ := data["failed_steps"].(map[int]int)
+			wf.Transitions = chainTransitions(chain, failedSteps, total)
 		}
 		
 		if accuracy < 70.0 && total >= 5 {
@@ -306,6 +499,7 @@ func (s *ConjugationService) GetWeakPointsAnalysis(userID string) (*models.WeakP
 		WeakForms:   weakForms,
 		StrongForms: strongForms,
 		TotalForms:  len(weakPoints),
+		ErrorCategories: errorCategories,
 	}, nil
 }
 
@@ -330,25 +524,25 @@ func (s *ConjugationService) GenerateWeakPointDrill(userID string) (*models.Conj
 	if err != nil {
 		return nil, err
 	}
+	challenges = filterConjugationTrack(challenges, targetForm)
+	challenges = s.topUpChallenges(challenges, targetForm, "N1", 10)
 	
 	if len(challenges) == 0 {
 		return nil, fmt.Errorf("no challenges available for form: %s", targetForm)
 	}
+	describeChainSteps(challenges)
 	
 	// Create session
 	session := &models.ConjugationSession{
-		ID:             uuid.New().String(),
-		UserID:         userID,
-		CurrentForm:    targetForm,
-		CurrentIndex:   0,
-		TotalQuestions: len(challenges),
-		StartTime:      time.Now(),
-		LastActive:     time.Now(),
+		UserID:           userID,
+		CurrentForm:      targetForm,
+		Mode:             models.ConjugationModePractice,
+		MaxLevel:         "N1",
 		IsWeakPointDrill: true,
-		TargetWeakForm: targetForm,
+		TargetWeakForm:   targetForm,
 	}
 	
-	if err := s.conjRepo.CreateSession(session); err != nil {
+	if err := s.createSession(session, challenges); err != nil {
 		return nil, err
 	}
 	
@@ -370,3 +564,162 @@ func (s *ConjugationService) GenerateWeakPointDrill(userID string) (*models.Conj
 		FormInfo: formInfo,
 	}, nil
 }
+
+// topUpChallenges fills a short challenge list with drills generated from the
+// vocabulary table. Generated challenges are saved under a stable ID so answers
+// can be checked (and weak points tracked) like seeded ones.
+func (s *ConjugationService) topUpChallenges(challenges []*models.ConjugationChallenge, form, maxLevel string, limit int) []*models.ConjugationChallenge {
+	if len(challenges) >= limit || s.vocabRepo == nil {
+		return challenges
+	}
+
+	seen := map[string]bool{}
+	for _, c := range challenges {
+		seen[c.BaseForm] = true
+	}
+
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	pool := s.challengePool(form, maxLevel)
+	for _, i := range rand.Perm(len(pool)) {
+		if len(challenges) >= limit {
+			break
+		}
+		c := pool[i]
+		if seen[c.BaseForm] {
+			continue
+		}
+		if !s.saved[c.ID] {
+			if err := s.conjRepo.CreateOrUpdateChallenge(c); err != nil {
+				continue
+			}
+			s.saved[c.ID] = true
+		}
+		seen[c.BaseForm] = true
+		challenge := *c
+		challenges = append(challenges, &challenge)
+	}
+	return challenges
+}
+
+// challengePool generates the challenges for a form from the vocabulary up to
+// a level, or returns them from the last time. A chained form is generated by
+// running each word through every step of the chain. The caller holds poolMu.
+func (s *ConjugationService) challengePool(form, maxLevel string) []*models.ConjugationChallenge {
+	key := form + "|" + maxLevel
+	if pool, ok := s.pools[key]; ok {
+		return pool
+	}
+
+	formInfo := s.getFormInfo(form)
+	if formInfo == nil {
+		return nil
+	}
+	forms := models.ConjugationChainForms(form)
+	if forms == nil {
+		forms = []string{form}
+	}
+
+	seen := map[string]bool{}
+	var pool []*models.ConjugationChallenge
+	for _, level := range []string{"N5", "N4", "N3", "N2", "N1"} {
+		for _, v := range s.conjugatableVocab(level) {
+			table, err := ConjugateWord(v.written, v.kana, v.group)
+			if err != nil || seen[table.BaseForm] {
+				continue
+			}
+			// Adjectives are drilled on their own forms, apart from verbs
+			if !fitsConjugationTrack(table.Category, form) {
+				continue
+			}
+			steps, err := conjugateChain(table.BaseForm, table.Reading, table.Group, forms)
+			if err != nil {
+				continue
+			}
+			seen[table.BaseForm] = true
+			// A word is only as easy as the harder of itself and the form
+			jlptLevel := level
+			if curriculumLevels[formInfo.Level] < curriculumLevels[level] {
+				jlptLevel = formInfo.Level
+			}
+			answer := steps[len(steps)-1].Expected
+			hints := make([]string, len(steps))
+			for i, step := range steps {
+				hints[i] = step.Hint
+			}
+			pool = append(pool, &models.ConjugationChallenge{
+				ID:           "gen-" + v.id + "-" + form,
+				BaseForm:     table.BaseForm,
+				Reading:      table.Reading,
+				Group:        table.Group,
+				TargetForm:   form,
+				TargetEnding: conjugationEnding(table.BaseForm, answer),
+				FullAnswer:   answer,
+				Hint:         strings.Join(hints, " → "),
+				Difficulty:   formInfo.Level,
+				JLPTLevel:    jlptLevel,
+				Category:     table.Category,
+				CreatedAt:    time.Now(),
+			})
+		}
+		if level == maxLevel {
+			break
+		}
+	}
+	s.pools[key] = pool
+	return pool
+}
+
+// describeChainSteps spells out the steps of chained challenges for the client
+func describeChainSteps(challenges []*models.ConjugationChallenge) {
+	for _, c := range challenges {
+		forms := models.ConjugationChainForms(c.TargetForm)
+		if forms == nil || len(c.Steps) > 0 {
+			continue
+		}
+		if steps, err := conjugateChain(c.BaseForm, c.Reading, c.Group, forms); err == nil {
+			c.Steps = steps
+		}
+	}
+}
+
+type conjugatableWord struct {
+	id, written, kana, group string
+}
+
+// conjugatableVocab picks the verbs and adjectives out of a level's vocabulary.
+// Verbs are glossed "to ...", い-adjectives end in い after a kanji stem, and
+// な-adjectives are only known when the word type says so.
+func (s *ConjugationService) conjugatableVocab(level string) []conjugatableWord {
+	vocab, err := s.vocabRepo.GetAllByLevel(level)
+	if err != nil {
+		return nil
+	}
+	var words []conjugatableWord
+	for i := range vocab {
+		v := &vocab[i]
+		written, kana := vocabForms(v)
+		meaning := strings.ToLower(strings.TrimSpace(v.ShortMeaning))
+		wordType := strings.ToLower(v.WordType)
+
+		var group string
+		switch {
+		case strings.Contains(wordType, "na-adj") || strings.Contains(wordType, "な"):
+			group = models.ConjugationNaAdjective
+		case strings.HasPrefix(meaning, "to "):
+			group = InferConjugationGroup(written, kana)
+			if group != models.ConjugationGodan && group != models.ConjugationIchidan && group != models.ConjugationIrregular {
+				continue
+			}
+		case strings.HasSuffix(written, "い") && strings.HasSuffix(kana, "い") && containsKanji(written):
+			group = InferConjugationGroup(written, kana)
+		default:
+			continue
+		}
+		if group == "" {
+			continue
+		}
+		words = append(words, conjugatableWord{id: v.ID, written: written, kana: kana, group: group})
+	}
+	return words
+}
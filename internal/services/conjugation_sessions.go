@@ -0,0 +1,263 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+const (
+	// Active sessions expire after a day without answers, paused ones after a week
+	activeSessionTTL = 24 * time.Hour
+	pausedSessionTTL = 7 * 24 * time.Hour
+
+	// Sessions created before challenge lists were stored are drilled from a
+	// fresh pick of up to this many challenges
+	legacySessionChallenges = 20
+)
+
+// sessionExpiry is when an unfinished session expires if left alone
+func sessionExpiry(session *models.ConjugationSession) *time.Time {
+	var at time.Time
+	switch {
+	case session.Mode == models.ConjugationModeTimed && session.EndsAt != nil:
+		at = *session.EndsAt
+	case session.Status == models.ConjugationSessionActive:
+		at = session.LastActive.Add(activeSessionTTL)
+	case session.Status == models.ConjugationSessionPaused:
+		at = session.LastActive.Add(pausedSessionTTL)
+	default:
+		return nil
+	}
+	return &at
+}
+
+// settleSession moves a session whose time has run out to its final status:
+// a timed run past its clock is completed, anything else left idle expires.
+// It reports whether the status changed.
+func settleSession(session *models.ConjugationSession, now time.Time) bool {
+	if session.Status != models.ConjugationSessionActive && session.Status != models.ConjugationSessionPaused {
+		return false
+	}
+	expiry := sessionExpiry(session)
+	if expiry == nil || now.Before(*expiry) {
+		return false
+	}
+	if session.Mode == models.ConjugationModeTimed && session.EndsAt != nil {
+		session.Status = models.ConjugationSessionCompleted
+	} else {
+		session.Status = models.ConjugationSessionExpired
+	}
+	return true
+}
+
+// loadSession gets one of a user's sessions by ID, settling it first
+func (s *ConjugationService) loadSession(userID, sessionID string) (*models.ConjugationSession, error) {
+	session, err := s.conjRepo.GetSessionByID(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settleSession(session, time.Now()) {
+		if err := s.conjRepo.UpdateSession(session); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// sessionChallenges returns the challenges a session drills, in order
+func (s *ConjugationService) sessionChallenges(session *models.ConjugationSession) ([]*models.ConjugationChallenge, error) {
+	if len(session.ChallengeIDs) > 0 {
+		return s.conjRepo.GetChallengesByIDs(session.ChallengeIDs)
+	}
+	challenges, err := s.conjRepo.GetChallengesByForm(session.CurrentForm, "N4", legacySessionChallenges)
+	if err != nil {
+		return nil, err
+	}
+	return s.topUpChallenges(challenges, session.CurrentForm, "N4", legacySessionChallenges), nil
+}
+
+// currentChallenge is the challenge a session expects an answer to next, or
+// nil when a practice session has run through its list. A timed run loops
+// over its list until the clock stops it.
+func currentChallenge(session *models.ConjugationSession, challenges []*models.ConjugationChallenge) *models.ConjugationChallenge {
+	if len(challenges) == 0 {
+		return nil
+	}
+	if session.Mode == models.ConjugationModeTimed {
+		return challenges[session.CurrentIndex%len(challenges)]
+	}
+	if session.CurrentIndex >= len(challenges) {
+		return nil
+	}
+	return challenges[session.CurrentIndex]
+}
+
+// remainingChallenges lists the challenges still to be answered, starting
+// with the current one
+func remainingChallenges(session *models.ConjugationSession, challenges []*models.ConjugationChallenge) []*models.ConjugationChallenge {
+	if len(challenges) == 0 {
+		return nil
+	}
+	if session.Mode == models.ConjugationModeTimed {
+		i := session.CurrentIndex % len(challenges)
+		return append(append([]*models.ConjugationChallenge{}, challenges[i:]...), challenges[:i]...)
+	}
+	if session.CurrentIndex >= len(challenges) {
+		return nil
+	}
+	return challenges[session.CurrentIndex:]
+}
+
+// checkSessionOpen explains why a session can't take answers, if it can't
+func checkSessionOpen(session *models.ConjugationSession) error {
+	switch session.Status {
+	case models.ConjugationSessionActive:
+		return nil
+	case models.ConjugationSessionPaused:
+		return fmt.Errorf("session is paused; resume it first")
+	case models.ConjugationSessionCompleted:
+		return fmt.Errorf("session is already completed")
+	default:
+		return fmt.Errorf("session has expired")
+	}
+}
+
+// sessionProgress summarises a session's answers so far
+func sessionProgress(session *models.ConjugationSession) *models.ConjugationProgress {
+	progress := &models.ConjugationProgress{
+		CurrentForm:      session.CurrentForm,
+		TotalAttempts:    session.CorrectCount + session.WrongCount,
+		CurrentStreak:    session.Streak,
+		BestStreak:       session.MaxStreak,
+		DailyGoal:        20,
+		DailyCompleted:   session.CorrectCount,
+		IsWeakPointDrill: session.IsWeakPointDrill,
+	}
+	if progress.TotalAttempts > 0 {
+		progress.AccuracyRate = float64(session.CorrectCount) / float64(progress.TotalAttempts) * 100
+	}
+	return progress
+}
+
+// GetSession returns a session with the challenges still to be answered, so a
+// drill can be picked up where it was left, on any device
+func (s *ConjugationService) GetSession(userID, sessionID string) (*models.ConjugationSessionResponse, error) {
+	session, err := s.loadSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sessionResponse(session)
+}
+
+// PauseSession stops the inactivity clock of a practice session for a week
+func (s *ConjugationService) PauseSession(userID, sessionID string) (*models.ConjugationSession, error) {
+	session, err := s.loadSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Mode == models.ConjugationModeTimed {
+		return nil, fmt.Errorf("a timed run can't be paused")
+	}
+	if session.Status == models.ConjugationSessionPaused {
+		return session, nil
+	}
+	if err := checkSessionOpen(session); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session.Status = models.ConjugationSessionPaused
+	session.PausedAt = &now
+	session.LastActive = now
+	if err := s.conjRepo.UpdateSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ResumeSession reopens a paused session and returns what is left of it
+func (s *ConjugationService) ResumeSession(userID, sessionID string) (*models.ConjugationSessionResponse, error) {
+	session, err := s.loadSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == models.ConjugationSessionPaused {
+		session.Status = models.ConjugationSessionActive
+		session.PausedAt = nil
+		session.LastActive = time.Now() // Time spent paused isn't response time
+		if err := s.conjRepo.UpdateSession(session); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkSessionOpen(session); err != nil {
+		return nil, err
+	}
+	return s.sessionResponse(session)
+}
+
+// ListSessions lists a user's sessions with their status, most recent first.
+// Sessions left idle are expired before listing.
+func (s *ConjugationService) ListSessions(userID, status string, limit int) ([]models.ConjugationSessionSummary, error) {
+	switch status {
+	case "", models.ConjugationSessionActive, models.ConjugationSessionPaused,
+		models.ConjugationSessionCompleted, models.ConjugationSessionExpired:
+	default:
+		return nil, fmt.Errorf("unknown session status: %s", status)
+	}
+
+	now := time.Now()
+	if err := s.conjRepo.ExpireSessions(userID, now.Add(-activeSessionTTL), now.Add(-pausedSessionTTL)); err != nil {
+		return nil, err
+	}
+	sessions, err := s.conjRepo.ListSessions(userID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]models.ConjugationSessionSummary, 0, len(sessions))
+	for _, session := range sessions {
+		// Timed runs end on their clock rather than by idling
+		if settleSession(session, now) {
+			if err := s.conjRepo.UpdateSession(session); err != nil {
+				return nil, err
+			}
+			if status != "" && session.Status != status {
+				continue
+			}
+		}
+
+		summary := models.ConjugationSessionSummary{
+			Session:   session,
+			FormInfo:  s.getFormInfo(session.CurrentForm),
+			Accuracy:  sessionProgress(session).AccuracyRate,
+			ExpiresAt: sessionExpiry(session),
+		}
+		if session.Status != models.ConjugationSessionActive && session.Status != models.ConjugationSessionPaused {
+			summary.ExpiresAt = nil
+		}
+		if session.Mode != models.ConjugationModeTimed {
+			summary.Remaining = max(len(session.ChallengeIDs)-session.CurrentIndex, 0)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// sessionResponse builds the full state of a session for the client
+func (s *ConjugationService) sessionResponse(session *models.ConjugationSession) (*models.ConjugationSessionResponse, error) {
+	challenges, err := s.sessionChallenges(session)
+	if err != nil {
+		return nil, err
+	}
+	remaining := remainingChallenges(session, challenges)
+	describeChainSteps(remaining)
+
+	return &models.ConjugationSessionResponse{
+		Session:    session,
+		Challenges: remaining,
+		Progress:   sessionProgress(session),
+		FormInfo:   s.getFormInfo(session.CurrentForm),
+	}, nil
+}
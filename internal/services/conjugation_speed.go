@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+const (
+	defaultSpeedRunSec = 60
+	minSpeedRunSec     = 15
+	maxSpeedRunSec     = 600
+	speedRunChallenges = 50
+	recentSpeedWindow  = 20 // Correct answers behind RecentMedianMs
+	defaultSpeedDays   = 30
+
+	// maxUntimedResponseMs caps server timing outside timed runs; anything
+	// longer was the learner stepping away
+	maxUntimedResponseMs = 5 * 60 * 1000
+)
+
+// StartTimedSession starts a speed-run: as many challenges as possible before
+// the clock, kept by the server, runs out
+func (s *ConjugationService) StartTimedSession(userID, form string, durationSec int, maxLevel string) (*models.ConjugationSessionResponse, error) {
+	if durationSec == 0 {
+		durationSec = defaultSpeedRunSec
+	}
+	if durationSec < minSpeedRunSec || durationSec > maxSpeedRunSec {
+		return nil, fmt.Errorf("duration must be between %d and %d seconds", minSpeedRunSec, maxSpeedRunSec)
+	}
+	if _, ok := curriculumLevels[maxLevel]; !ok {
+		maxLevel = "N5"
+	}
+
+	session := &models.ConjugationSession{
+		UserID:       userID,
+		CurrentForm:  form,
+		Mode:         models.ConjugationModeTimed,
+		TimeLimitSec: durationSec,
+	}
+	return s.startSession(session, maxLevel, speedRunChallenges)
+}
+
+// GetSpeedRun summarises a timed session, finished or still running
+func (s *ConjugationService) GetSpeedRun(userID, sessionID string) (*models.SpeedRunResult, error) {
+	session, err := s.loadSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Mode != models.ConjugationModeTimed || session.EndsAt == nil {
+		return nil, fmt.Errorf("not a timed session")
+	}
+	return s.speedRunResult(session, time.Now())
+}
+
+// speedRunResult scores a timed session against the user's earlier runs of the
+// same form and length
+func (s *ConjugationService) speedRunResult(session *models.ConjugationSession, now time.Time) (*models.SpeedRunResult, error) {
+	times, err := s.conjRepo.GetSessionResponseTimes(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	best, err := s.conjRepo.GetBestSpeedRunScore(session.UserID, session.CurrentForm, session.TimeLimitSec, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	answered := session.CorrectCount + session.WrongCount
+	accuracy := 0.0
+	if answered > 0 {
+		accuracy = float64(session.CorrectCount) / float64(answered) * 100
+	}
+	finished := !now.Before(*session.EndsAt)
+	return &models.SpeedRunResult{
+		SessionID:       session.ID,
+		Form:            session.CurrentForm,
+		TimeLimitSec:    session.TimeLimitSec,
+		Score:           session.CorrectCount,
+		Answered:        answered,
+		Accuracy:        accuracy,
+		MedianMs:        medianMs(times),
+		PersonalBest:    max(best, session.CorrectCount),
+		NewPersonalBest: finished && session.CorrectCount > best,
+		Finished:        finished,
+		EndsAt:          *session.EndsAt,
+	}, nil
+}
+
+// GetSpeedReport shows per-form response times over the last days: medians of
+// correct answers, a daily trend and the best timed runs. An empty form covers
+// every form practised in the period.
+func (s *ConjugationService) GetSpeedReport(userID, form string, days int) (*models.ConjugationSpeedReport, error) {
+	if days <= 0 {
+		days = defaultSpeedDays
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	times, err := s.conjRepo.GetResponseTimes(userID, form, since)
+	if err != nil {
+		return nil, err
+	}
+	bests, err := s.conjRepo.GetBestSpeedRuns(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byForm := map[string][]models.ResponseTime{}
+	var forms []string
+	for _, t := range times {
+		if _, ok := byForm[t.Form]; !ok {
+			forms = append(forms, t.Form)
+		}
+		byForm[t.Form] = append(byForm[t.Form], t)
+	}
+
+	report := &models.ConjugationSpeedReport{Days: days, Forms: []models.FormSpeedStats{}}
+	for _, f := range forms {
+		stats := formSpeedStats(f, byForm[f])
+		for _, b := range bests {
+			if b.Form == f {
+				stats.BestRuns = append(stats.BestRuns, b)
+			}
+		}
+		report.Forms = append(report.Forms, stats)
+	}
+	sort.SliceStable(report.Forms, func(i, j int) bool {
+		return report.Forms[i].Attempts > report.Forms[j].Attempts
+	})
+	return report, nil
+}
+
+// formSpeedStats summarises one form's attempts, oldest first
+func formSpeedStats(form string, attempts []models.ResponseTime) models.FormSpeedStats {
+	stats := models.FormSpeedStats{Form: form, Attempts: len(attempts), Trend: []models.SpeedTrendPoint{}}
+
+	var correct []int
+	type day struct {
+		times            []int
+		attempts, rights int
+	}
+	days := map[string]*day{}
+	var dates []string
+	for _, a := range attempts {
+		date := a.CreatedAt.Format("2006-01-02")
+		d, ok := days[date]
+		if !ok {
+			d = &day{}
+			days[date] = d
+			dates = append(dates, date)
+		}
+		d.attempts++
+		if !a.IsCorrect {
+			continue
+		}
+		d.rights++
+		d.times = append(d.times, a.Ms)
+		correct = append(correct, a.Ms)
+		if stats.FastestMs == 0 || a.Ms < stats.FastestMs {
+			stats.FastestMs = a.Ms
+		}
+	}
+
+	stats.MedianMs = medianMs(correct)
+	stats.RecentMedianMs = medianMs(correct[max(len(correct)-recentSpeedWindow, 0):])
+	for _, date := range dates {
+		d := days[date]
+		stats.Trend = append(stats.Trend, models.SpeedTrendPoint{
+			Date:     date,
+			MedianMs: medianMs(d.times),
+			Attempts: d.attempts,
+			Accuracy: float64(d.rights) / float64(d.attempts) * 100,
+		})
+	}
+	return stats
+}
+
+// medianMs is the median of response times, 0 when there are none
+func medianMs(times []int) int {
+	if len(times) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), times...)
+	sort.Ints(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -17,14 +17,16 @@ import (
 // ConversationService handles conversation business logic
 type ConversationService struct {
 	repo       *repository.ConversationRepository
+	detector   *GrammarDetector
 	llmAPIKey  string
 	llmBaseURL string
 }
 
 // NewConversationService creates a new conversation service
-func NewConversationService(repo *repository.ConversationRepository) *ConversationService {
+func NewConversationService(repo *repository.ConversationRepository, detector *GrammarDetector) *ConversationService {
 	return &ConversationService{
 		repo:       repo,
+		detector:   detector,
 		llmAPIKey:  os.Getenv("LLM_API_KEY"),
 		llmBaseURL: os.Getenv("LLM_BASE_URL"),
 	}
@@ -169,12 +171,20 @@ func (s *ConversationService) SendMessage(sessionID string, userID string, messa
 	// Generate alternative phrasings
 	alternatives := s.generateAlternatives(message, session.Level)
 
-	return &models.ChatResponse{
+	response := &models.ChatResponse{
 		Message:          *userMsg,
 		AIResponse:       *aiMsg,
 		NaturalnessScore: naturalnessScore,
 		Suggestions:      alternatives,
-	}, nil
+	}
+
+	// Grammar highlighting is best effort; a failed lookup shouldn't lose the reply
+	if s.detector != nil {
+		response.DetectedGrammar, _ = s.detector.Detect(message, "")
+		response.ResponseGrammar, _ = s.detector.Detect(aiResponse, "")
+	}
+
+	return response, nil
 }
 
 // analyzeMessage checks user input for grammar and naturalness
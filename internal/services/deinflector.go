@@ -0,0 +1,525 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"github.com/erwinwahyura/daily-kotoba/internal/repository"
+)
+
+// Word types a surface can have while it's being deinflected
+const (
+	deinflectIchidan uint = 1 << iota
+	deinflectGodan
+	deinflectSuru
+	deinflectKuru
+	deinflectIAdjective
+	deinflectFinal // て, た, ます... nothing inflects any further
+)
+
+var deinflectGroups = map[uint]string{
+	deinflectIchidan:    models.ConjugationIchidan,
+	deinflectGodan:      models.ConjugationGodan,
+	deinflectSuru:       models.ConjugationIrregular,
+	deinflectKuru:       models.ConjugationIrregular,
+	deinflectIAdjective: models.ConjugationIAdjective,
+}
+
+// deinflectNextGroup is the group a form's result conjugates as
+// (食べさせる is an ichidan verb, 食べない an い-adjective)
+var deinflectNextGroup = map[string]string{
+	"causative": models.ConjugationIchidan,
+	"passive":   models.ConjugationIchidan,
+	"potential": models.ConjugationIchidan,
+	"nai":       models.ConjugationIAdjective,
+}
+
+// godanFrequency orders godan endings from most to least common
+const godanFrequency = "るうくすむつぐぶぬ"
+
+const (
+	deinflectMaxSteps      = 8
+	deinflectMaxCandidates = 10
+	deinflectMaxTail       = 12 // Kana after a kanji run tried as inflection in text
+)
+
+// deinflectRule undoes one conjugation: a surface ending in from, inflecting as
+// cond, came from a word ending in to of type result via form
+type deinflectRule struct {
+	from, to     string
+	cond, result uint
+	form         string
+}
+
+var deinflectRules = buildDeinflectRules()
+
+// buildDeinflectRules mirrors conjugateSurface so every form the engine
+// generates can be undone
+func buildDeinflectRules() []deinflectRule {
+	var rules []deinflectRule
+	add := func(from, to string, cond, result uint, form string) {
+		rules = append(rules, deinflectRule{from: from, to: to, cond: cond, result: result, form: form})
+	}
+	polite := []struct{ suffix, form string }{
+		{"ます", "polite"}, {"ません", "polite_negative"}, {"ました", "polite_past"},
+		{"ませんでした", "polite_past_negative"}, {"ましょう", "polite_volitional"},
+	}
+	verb := func(dict string, result uint, masu, nai, te, ta, potential, passive, causative, imperative, conditional, volitional string) {
+		for _, p := range polite {
+			add(masu+p.suffix, dict, deinflectFinal, result, p.form)
+		}
+		add(te, dict, deinflectFinal, result, "te")
+		add(ta, dict, deinflectFinal, result, "ta")
+		add(nai+"ない", dict, deinflectIAdjective, result, "nai")
+		add(potential, dict, deinflectIchidan, result, "potential")
+		add(passive, dict, deinflectIchidan, result, "passive")
+		add(causative, dict, deinflectIchidan, result, "causative")
+		add(imperative, dict, deinflectFinal, result, "imperative")
+		add(conditional, dict, deinflectFinal, result, "conditional")
+		add(volitional, dict, deinflectFinal, result, "volitional")
+	}
+
+	for _, u := range uRow {
+		row, te := godanRows[u], godanTe[u]
+		verb(string(u), deinflectGodan, row[1], row[0], te[0], te[1], row[2]+"る", row[0]+"れる", row[0]+"せる", row[2], row[2]+"ば", row[3]+"う")
+	}
+	verb("る", deinflectIchidan, "", "", "て", "た", "られる", "られる", "させる", "ろ", "れば", "よう")
+	verb("する", deinflectSuru, "し", "し", "して", "した", "できる", "される", "させる", "しろ", "すれば", "しよう")
+	verb("くる", deinflectKuru, "き", "こ", "きて", "きた", "こられる", "こられる", "こさせる", "こい", "くれば", "こよう")
+	verb("来る", deinflectKuru, "来", "来", "来て", "来た", "来られる", "来られる", "来させる", "来い", "来れば", "来よう")
+
+	// 行って, 問うて, くださいます: the replay through the engine rejects other verbs
+	add("って", "く", deinflectFinal, deinflectGodan, "te")
+	add("った", "く", deinflectFinal, deinflectGodan, "ta")
+	add("うて", "う", deinflectFinal, deinflectGodan, "te")
+	add("うた", "う", deinflectFinal, deinflectGodan, "ta")
+	for _, p := range polite {
+		add("い"+p.suffix, "る", deinflectFinal, deinflectGodan, p.form)
+	}
+	add("い", "る", deinflectFinal, deinflectGodan, "imperative")
+
+	add("かった", "い", deinflectFinal, deinflectIAdjective, "ta")
+	add("くない", "い", deinflectIAdjective, deinflectIAdjective, "nai")
+	add("くて", "い", deinflectFinal, deinflectIAdjective, "te")
+	add("ければ", "い", deinflectFinal, deinflectIAdjective, "conditional")
+	add("です", "", deinflectFinal, deinflectIAdjective, "polite")
+	add("かったです", "い", deinflectFinal, deinflectIAdjective, "polite_past")
+	add("くありません", "い", deinflectFinal, deinflectIAdjective, "polite_negative")
+	add("くありませんでした", "い", deinflectFinal, deinflectIAdjective, "polite_past_negative")
+	return rules
+}
+
+// conjugationFormInfo finds a drill form, polite variant or keigo form by name
+func conjugationFormInfo(name string) *models.ConjugationFormType {
+	for _, forms := range [][]models.ConjugationFormType{models.GetConjugationForms(), models.GetPoliteVariantForms(), models.GetAdjectiveForms(), models.GetKeigoForms()} {
+		for i := range forms {
+			if forms[i].Name == name {
+				return &forms[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Deinflector turns conjugated words back into dictionary forms
+type Deinflector struct {
+	vocabRepo *repository.VocabRepository
+}
+
+// NewDeinflector creates a new deinflector
+func NewDeinflector(vocabRepo *repository.VocabRepository) *Deinflector {
+	return &Deinflector{vocabRepo: vocabRepo}
+}
+
+// Deinflect returns the dictionary forms an inflected word may come from, each
+// with the chain of forms that produces it (食べさせられなかった: causative →
+// passive → nai → ta). Readings backed by the vocabulary table win; without
+// one, every chain the conjugation engine can reproduce is listed.
+func (d *Deinflector) Deinflect(word string) (*models.DeinflectionResult, error) {
+	word = strings.TrimSpace(word)
+	candidates := deinflectCandidates(word)
+
+	forms := []string{word}
+	for _, c := range candidates {
+		forms = append(forms, c.BaseForm)
+	}
+	byForm, err := d.lookup(forms)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DeinflectionResult{
+		Word:       word,
+		Candidates: rankDeinflections(word, candidates, byForm),
+	}, nil
+}
+
+// AnalyzeText finds the conjugated vocabulary words in a text. A word starts at
+// a run of kanji and takes as much of the following kana as deinflects to a
+// known word; kana-only verbs (する, いる) are left to grammar detection.
+func (d *Deinflector) AnalyzeText(text string) ([]models.InflectedWord, error) {
+	type token struct {
+		start    int
+		prefixes []string // Longest first
+	}
+	runes := []rune(text)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		if !isKanjiRune(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && isKanjiRune(runes[i]) {
+			i++
+		}
+		stem := i
+		for i < len(runes) && i-stem < deinflectMaxTail && unicode.Is(unicode.Hiragana, runes[i]) {
+			i++
+		}
+		t := token{start: start}
+		for end := i; end > stem; end-- {
+			t.prefixes = append(t.prefixes, string(runes[start:end]))
+		}
+		if len(t.prefixes) > 0 {
+			tokens = append(tokens, t)
+		}
+	}
+
+	candidates := map[string][]models.DeinflectionCandidate{}
+	var forms []string
+	for _, t := range tokens {
+		for _, p := range t.prefixes {
+			if _, done := candidates[p]; done {
+				continue
+			}
+			candidates[p] = deinflectCandidates(p)
+			for _, c := range candidates[p] {
+				forms = append(forms, c.BaseForm)
+			}
+		}
+	}
+	byForm, err := d.lookup(forms)
+	if err != nil {
+		return nil, err
+	}
+
+	words := []models.InflectedWord{}
+	for _, t := range tokens {
+		for _, p := range t.prefixes {
+			ranked := rankDeinflections(p, candidates[p], byForm)
+			if len(ranked) == 0 || ranked[0].Vocabulary == nil {
+				continue
+			}
+			if len(ranked[0].Chain) == 0 {
+				break // A dictionary form, nothing to explain
+			}
+			best := ranked[0]
+			words = append(words, models.InflectedWord{
+				Start:        t.start,
+				End:          t.start + len([]rune(p)),
+				Text:         p,
+				BaseForm:     best.BaseForm,
+				Group:        best.Group,
+				Chain:        best.Chain,
+				VocabularyID: best.Vocabulary.ID,
+				Meaning:      best.Vocabulary.ShortMeaning,
+			})
+			break
+		}
+	}
+	return words, nil
+}
+
+// lookup maps dictionary forms to vocabulary, preferring a match on the written form
+func (d *Deinflector) lookup(forms []string) (map[string]*models.Vocabulary, error) {
+	byForm := map[string]*models.Vocabulary{}
+	if d.vocabRepo == nil {
+		return byForm, nil
+	}
+
+	seen := map[string]bool{}
+	var unique []string
+	for _, f := range forms {
+		if f != "" && !seen[f] {
+			seen[f] = true
+			unique = append(unique, f)
+		}
+	}
+
+	var vocab []models.Vocabulary
+	for len(unique) > 0 {
+		n := min(len(unique), 200)
+		batch, err := d.vocabRepo.GetByWords(unique[:n])
+		if err != nil {
+			return nil, err
+		}
+		vocab = append(vocab, batch...)
+		unique = unique[n:]
+	}
+
+	for i := range vocab {
+		written, _ := vocabForms(&vocab[i])
+		if _, ok := byForm[written]; !ok {
+			byForm[written] = &vocab[i]
+		}
+	}
+	for i := range vocab {
+		_, kana := vocabForms(&vocab[i])
+		if _, ok := byForm[kana]; !ok {
+			byForm[kana] = &vocab[i]
+		}
+	}
+	return byForm, nil
+}
+
+// deinflectCandidates undoes conjugations breadth first, keeping only the
+// chains the conjugation engine reproduces exactly
+func deinflectCandidates(word string) []models.DeinflectionCandidate {
+	type state struct {
+		word  string
+		types uint
+		chain []models.DeinflectionStep
+	}
+	all := deinflectIchidan | deinflectGodan | deinflectSuru | deinflectKuru | deinflectIAdjective | deinflectFinal
+
+	var candidates []models.DeinflectionCandidate
+	seen := map[string]bool{}
+	queue := []state{{word: word, types: all}}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if len(s.chain) >= deinflectMaxSteps {
+			continue
+		}
+		for _, r := range deinflectRules {
+			if s.types&r.cond == 0 || !strings.HasSuffix(s.word, r.from) {
+				continue
+			}
+			base := strings.TrimSuffix(s.word, r.from) + r.to
+			if len([]rune(base)) < 2 {
+				continue
+			}
+			chain := append([]models.DeinflectionStep{{
+				Form:     r.form,
+				FormInfo: conjugationFormInfo(r.form),
+				Result:   s.word,
+			}}, s.chain...)
+			group := deinflectGroups[r.result]
+			key := base + "|" + group
+			for _, step := range chain {
+				key += "|" + step.Form
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if !replayDeinflection(base, group, chain) {
+				continue
+			}
+			candidates = append(candidates, models.DeinflectionCandidate{
+				BaseForm: base,
+				Group:    group,
+				Chain:    chain,
+			})
+			queue = append(queue, state{word: base, types: r.result, chain: chain})
+		}
+	}
+	return candidates
+}
+
+// replayDeinflection conjugates base through chain and checks every step lands
+// on the expected word
+func replayDeinflection(base, group string, chain []models.DeinflectionStep) bool {
+	// 来る only ever conjugates irregularly, whatever a suffix rule guessed
+	if InferConjugationGroup(base, "") == models.ConjugationIrregular && group != models.ConjugationIrregular {
+		return false
+	}
+	current := base
+	for i, step := range chain {
+		table, err := ConjugateWord(current, "", group)
+		if err != nil {
+			return false
+		}
+		found := false
+		for _, f := range table.Forms {
+			if f.Form != step.Form {
+				continue
+			}
+			for _, surface := range append([]string{f.Surface}, f.Alternatives...) {
+				if surface == step.Result {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+		next, ok := deinflectNextGroup[step.Form]
+		if !ok && i < len(chain)-1 {
+			return false
+		}
+		current, group = step.Result, next
+	}
+	return true
+}
+
+// rankDeinflections links candidates to vocabulary and orders them. When any
+// candidate is a known word, only known words are returned. Otherwise words that
+// are just a step on the way to another candidate (食べさせる inside 食べさせられる)
+// are dropped, except for potential forms, which are often words in their own
+// right (書ける may be 書く or its own ichidan verb).
+func rankDeinflections(word string, candidates []models.DeinflectionCandidate, byForm map[string]*models.Vocabulary) []models.DeinflectionCandidate {
+	ranked := []models.DeinflectionCandidate{}
+	if v, ok := byForm[word]; ok {
+		ranked = append(ranked, models.DeinflectionCandidate{
+			BaseForm:   word,
+			Group:      InferConjugationGroup(word, ""),
+			Chain:      []models.DeinflectionStep{},
+			Vocabulary: v,
+		})
+	}
+
+	known := len(ranked) > 0
+	for i := range candidates {
+		if v, ok := byForm[candidates[i].BaseForm]; ok {
+			candidates[i].Vocabulary = v
+			known = true
+		}
+	}
+
+	intermediate := map[string]bool{}
+	for _, c := range candidates {
+		for _, step := range c.Chain[:max(len(c.Chain)-1, 0)] {
+			if step.Form != "potential" {
+				intermediate[step.Result] = true
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		if known && c.Vocabulary == nil {
+			continue
+		}
+		if !known && (intermediate[c.BaseForm] || !plausibleDictionaryForm(c.BaseForm, c.Group)) {
+			continue
+		}
+		ranked = append(ranked, c)
+	}
+
+	// Passive reads more naturally than potential for られる, short chains beat
+	// long ones, a kanji compound + し is a する-verb while one kanji + し is a
+	// godan す-verb (話して is 話す), an い-row stem is a godan masu stem more
+	// often than not (読みます is 読む, not 読みる), and common godan endings
+	// beat rare ones (走る over 走つ)
+	potentials := func(c models.DeinflectionCandidate) int {
+		n := 0
+		for _, step := range c.Chain {
+			if step.Form == "potential" {
+				n++
+			}
+		}
+		return n
+	}
+	groupRank := func(c models.DeinflectionCandidate) int {
+		runes := []rune(c.BaseForm)
+		n := len(runes)
+		switch {
+		case c.Group == models.ConjugationIrregular && strings.HasSuffix(c.BaseForm, "する") && n > 3 && isKanjiRune(runes[n-3]):
+			if isKanjiRune(runes[n-4]) {
+				return 0
+			}
+			return 2
+		case c.Group == models.ConjugationIrregular && strings.HasSuffix(c.BaseForm, "する") && n == 3 && isKanjiRune(runes[0]):
+			return 2
+		case c.Group == models.ConjugationIchidan && n > 1 && strings.ContainsRune(iRow, runes[n-2]):
+			return 2
+		}
+		return 1
+	}
+	ending := func(c models.DeinflectionCandidate) int {
+		runes := []rune(c.BaseForm)
+		return strings.IndexRune(godanFrequency, runes[len(runes)-1])
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if pi, pj := potentials(ranked[i]), potentials(ranked[j]); pi != pj {
+			return pi < pj
+		}
+		if len(ranked[i].Chain) != len(ranked[j].Chain) {
+			return len(ranked[i].Chain) < len(ranked[j].Chain)
+		}
+		if gi, gj := groupRank(ranked[i]), groupRank(ranked[j]); gi != gj {
+			return gi < gj
+		}
+		if ei, ej := ending(ranked[i]), ending(ranked[j]); ei != ej {
+			return ei < ej
+		}
+		return ranked[i].BaseForm < ranked[j].BaseForm
+	})
+	if len(ranked) > deinflectMaxCandidates {
+		ranked = ranked[:deinflectMaxCandidates]
+	}
+	return ranked
+}
+
+// plausibleDictionaryForm rejects bases no dictionary would list, for when the
+// vocabulary table can't vouch for any candidate: 走っる, 食べす (godan verbs
+// don't have え-row okurigana), 勉強る and 勉強しる (kanji compounds only take
+// する), 起くる (来る is written in kanji).
+func plausibleDictionaryForm(base, group string) bool {
+	runes := []rune(base)
+	n := len(runes)
+	if n < 2 || runes[n-2] == 'っ' || runes[n-2] == 'ん' {
+		return false
+	}
+	last := -1
+	for i, r := range runes {
+		if isKanjiRune(r) {
+			last = i
+		}
+	}
+	if last < 0 {
+		return true // Kana-only words can't be judged by shape
+	}
+	if strings.HasSuffix(base, "する") {
+		return last == n-3
+	}
+	if group == models.ConjugationIrregular && strings.HasSuffix(base, "くる") {
+		return false
+	}
+	if last == n-1 {
+		return false
+	}
+	kanjiRun := 0
+	for i := last; i >= 0 && isKanjiRune(runes[i]); i-- {
+		kanjiRun++
+	}
+	okurigana := runes[last+1 : n-1]
+
+	switch group {
+	case models.ConjugationIAdjective:
+		return len(okurigana) <= 3
+	case models.ConjugationIchidan:
+		if len(okurigana) == 0 {
+			return kanjiRun == 1 // 見る, 寝る
+		}
+		if kanjiRun > 1 && string(okurigana) == "し" {
+			return false
+		}
+		end := okurigana[len(okurigana)-1]
+		return len(okurigana) <= 2 && (strings.ContainsRune(iRow, end) || strings.ContainsRune(eRow, end))
+	case models.ConjugationGodan:
+		if kanjiRun > 1 || len(okurigana) > 2 {
+			return false
+		}
+		if len(okurigana) > 0 {
+			end := okurigana[len(okurigana)-1]
+			if strings.ContainsRune(eRow, end) || (strings.ContainsRune(iRow, end) && runes[n-1] != 'る') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// Conjugation forms count as learned at the same bar as drill "strong points"
+const (
+	curriculumConjugationAccuracy = 80.0
+	curriculumConjugationAttempts = 5
+)
+
+// curriculumLevels orders JLPT levels from easiest (higher number) to hardest
+var curriculumLevels = map[string]int{"N5": 5, "N4": 4, "N3": 3, "N2": 2, "N1": 1}
+
+// GetCurriculumTree shows which patterns of a level are learned, unlocked or locked
+func (s *GrammarService) GetCurriculumTree(userID, level string) (*models.CurriculumTree, error) {
+	level, nodes, err := s.buildCurriculum(userID, level)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &models.CurriculumTree{Level: level, Nodes: nodes}
+	for _, n := range nodes {
+		switch n.State {
+		case models.CurriculumLearned:
+			tree.Learned++
+		case models.CurriculumUnlocked:
+			tree.Unlocked++
+		default:
+			tree.Locked++
+		}
+	}
+	return tree, nil
+}
+
+// GetCurriculumPattern serves the next unlocked pattern in index order.
+// Patterns being learned or never seen come first; skipped ones are offered
+// again only once nothing else is unlocked.
+func (s *GrammarService) GetCurriculumPattern(userID, level string) (*models.CurriculumNextResponse, error) {
+	level, nodes, err := s.buildCurriculum(userID, level)
+	if err != nil {
+		return nil, err
+	}
+
+	var next *models.CurriculumNode
+	unlocked, locked := 0, 0
+	for i := range nodes {
+		switch nodes[i].State {
+		case models.CurriculumUnlocked:
+			unlocked++
+			if next == nil && nodes[i].Status != models.GrammarStatusSkipped {
+				next = &nodes[i]
+			}
+		case models.CurriculumLocked:
+			locked++
+		}
+	}
+	if next == nil {
+		for i := range nodes {
+			if nodes[i].State == models.CurriculumUnlocked {
+				next = &nodes[i]
+				break
+			}
+		}
+	}
+	if next == nil {
+		if locked > 0 {
+			return nil, fmt.Errorf("no unlocked %s patterns left - learn their prerequisites first", level)
+		}
+		return nil, fmt.Errorf("all %s patterns learned", level)
+	}
+
+	pattern, err := s.grammarRepo.GetByID(next.PatternID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.progressRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CurriculumNextResponse{
+		Pattern:       pattern,
+		Prerequisites: next.Prerequisites,
+		Progress:      s.grammarProgress(userID, progress, len(nodes)),
+		Unlocked:      unlocked,
+		Locked:        locked,
+	}, nil
+}
+
+// buildCurriculum evaluates every pattern of a level (default: the user's level)
+// against its prerequisites. Grammar prerequisites from a level easier than the
+// user's current one count as learned, since placement already vouched for them.
+func (s *GrammarService) buildCurriculum(userID, level string) (string, []models.CurriculumNode, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if level == "" {
+		level = user.CurrentLevel
+	}
+	if _, ok := curriculumLevels[level]; !ok {
+		return "", nil, fmt.Errorf("invalid level: %s", level)
+	}
+
+	patterns, err := s.grammarRepo.GetRangeByLevel(level, 0, 500)
+	if err != nil {
+		return "", nil, err
+	}
+	prereqs, err := s.prereqRepo.GetByLevel(level)
+	if err != nil {
+		return "", nil, err
+	}
+	statuses, err := s.progressRepo.GetGrammarStatusMap(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	all, err := s.grammarRepo.GetAll()
+	if err != nil {
+		return "", nil, err
+	}
+	byID := make(map[string]*models.GrammarPattern, len(all))
+	for i := range all {
+		byID[all[i].ID] = &all[i]
+	}
+
+	var conjStats map[string]map[string]interface{}
+	satisfied := func(p models.GrammarPrerequisite) bool {
+		switch p.PrerequisiteType {
+		case models.PrerequisiteGrammar:
+			if statuses[p.PrerequisiteID] == models.GrammarStatusKnown {
+				return true
+			}
+			if pre, ok := byID[p.PrerequisiteID]; ok {
+				return curriculumLevels[pre.JLPTLevel] > curriculumLevels[user.CurrentLevel]
+			}
+			return false
+		case models.PrerequisiteVocabulary:
+			known, err := s.progressRepo.HasVocabStatus(userID, p.PrerequisiteID, "known")
+			return err == nil && known
+		case models.PrerequisiteConjugation:
+			if conjStats == nil {
+				if conjStats, err = s.conjRepo.GetWeakPointsByForm(userID); err != nil {
+					conjStats = map[string]map[string]interface{}{}
+				}
+			}
+			stat, ok := conjStats[p.PrerequisiteID]
+			if !ok {
+				return false
+			}
+			total, _ := stat["total"].(int)
+			accuracy, _ := stat["accuracy"].(float64)
+			return total >= curriculumConjugationAttempts && accuracy >= curriculumConjugationAccuracy
+		}
+		return false
+	}
+
+	nodes := make([]models.CurriculumNode, 0, len(patterns))
+	for _, p := range patterns {
+		node := models.CurriculumNode{
+			PatternID:     p.ID,
+			Pattern:       p.Pattern,
+			Meaning:       p.Meaning,
+			JLPTLevel:     p.JLPTLevel,
+			IndexPosition: p.IndexPosition,
+			Status:        statuses[p.ID],
+			Prerequisites: []models.CurriculumPrerequisite{},
+		}
+
+		open := true
+		for _, pre := range prereqs[p.ID] {
+			label := pre.Label
+			if label == "" {
+				if target, ok := byID[pre.PrerequisiteID]; ok {
+					label = target.Pattern
+				} else {
+					label = pre.PrerequisiteID
+				}
+			}
+			ok := satisfied(pre)
+			open = open && ok
+			node.Prerequisites = append(node.Prerequisites, models.CurriculumPrerequisite{
+				Type:      pre.PrerequisiteType,
+				ID:        pre.PrerequisiteID,
+				Label:     label,
+				Note:      pre.Note,
+				Satisfied: ok,
+			})
+		}
+
+		switch {
+		case node.Status == models.GrammarStatusKnown:
+			node.State = models.CurriculumLearned
+		case open:
+			node.State = models.CurriculumUnlocked
+		default:
+			node.State = models.CurriculumLocked
+		}
+		nodes = append(nodes, node)
+	}
+
+	return level, nodes, nil
+}
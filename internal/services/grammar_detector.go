@@ -0,0 +1,347 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"github.com/erwinwahyura/daily-kotoba/internal/repository"
+)
+
+// leftContext reports whether a rune may directly precede a pattern
+type leftContext func(prev rune) bool
+
+// grammarMatchRule is a pattern compiled for matching: every inflected surface
+// plus what its conjugation rules allow in front of it
+type grammarMatchRule struct {
+	pattern  *models.GrammarPattern
+	variants []patternVariant
+	anyLeft  bool
+	left     []leftContext
+}
+
+var (
+	uRow = "うくぐすつぬぶむる"
+	// Particles rarely end a verb or adjective stem; they'd make short patterns match everywhere
+	stemParticles = "はがもをにでとへやのかね"
+)
+
+func isKanjiRune(r rune) bool { return unicode.Is(unicode.Han, r) }
+
+func runeIn(set string) leftContext {
+	return func(prev rune) bool { return strings.ContainsRune(set, prev) }
+}
+
+// verbStem accepts the end of a masu stem or conjugation stem (書き, 食べ, 書い, 読ん, 来)
+func verbStem(prev rune) bool {
+	if isKanjiRune(prev) || prev == 'っ' || prev == 'ん' {
+		return true
+	}
+	if strings.ContainsRune(stemParticles, prev) {
+		return false
+	}
+	return strings.ContainsRune(iRow, prev) || strings.ContainsRune(eRow, prev)
+}
+
+// negativeStem accepts the end of a nai-stem (書か, 食べ, し, 来)
+func negativeStem(prev rune) bool {
+	if isKanjiRune(prev) || strings.ContainsRune("しこ", prev) {
+		return true
+	}
+	if strings.ContainsRune(stemParticles, prev) {
+		return false
+	}
+	return strings.ContainsRune("あかがさたなばまらわ", prev) || strings.ContainsRune(eRow, prev) || strings.ContainsRune(iRow, prev)
+}
+
+// adjectiveStem accepts anything that could end a word (高, きれ, 静か, 学生, コーヒー)
+func adjectiveStem(prev rune) bool {
+	return isKanjiRune(prev) || (unicode.Is(unicode.Hiragana, prev) && !strings.ContainsRune(stemParticles, prev)) ||
+		unicode.Is(unicode.Katakana, prev) || prev == 'ー'
+}
+
+// GrammarDetector finds grammar patterns in arbitrary Japanese text.
+// Patterns are compiled once from the database and cached; they only
+// change when seeds load at startup.
+type GrammarDetector struct {
+	grammarRepo *repository.GrammarRepository
+	deinflector *Deinflector
+
+	mu    sync.RWMutex
+	rules []grammarMatchRule
+}
+
+// NewGrammarDetector creates a new detector
+func NewGrammarDetector(grammarRepo *repository.GrammarRepository, deinflector *Deinflector) *GrammarDetector {
+	return &GrammarDetector{grammarRepo: grammarRepo, deinflector: deinflector}
+}
+
+// Detect returns the grammar patterns used in text, in order of appearance.
+// level limits matches to one JLPT level; empty means all levels.
+func (d *GrammarDetector) Detect(text, level string) ([]models.GrammarMatch, error) {
+	rules, err := d.compiledRules()
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		rule    *grammarMatchRule
+		variant patternVariant
+		start   int // rune offsets
+		end     int
+	}
+	var candidates []candidate
+
+	for i := range rules {
+		rule := &rules[i]
+		if level != "" && rule.pattern.JLPTLevel != level {
+			continue
+		}
+		for _, v := range rule.variants {
+			for offset := 0; offset < len(text); {
+				idx := strings.Index(text[offset:], v.Surface)
+				if idx < 0 {
+					break
+				}
+				byteStart := offset + idx
+				offset = byteStart + len(v.Surface)
+
+				var prev rune
+				if byteStart > 0 {
+					prev, _ = utf8.DecodeLastRuneInString(text[:byteStart])
+				}
+				if !rule.allows(prev) {
+					continue
+				}
+				start := utf8.RuneCountInString(text[:byteStart])
+				candidates = append(candidates, candidate{
+					rule:    rule,
+					variant: v,
+					start:   start,
+					end:     start + utf8.RuneCountInString(v.Surface),
+				})
+			}
+		}
+	}
+
+	// Longest match wins: ようにしている hides the ように inside it
+	sort.SliceStable(candidates, func(i, j int) bool {
+		li, lj := candidates[i].end-candidates[i].start, candidates[j].end-candidates[j].start
+		if li != lj {
+			return li > lj
+		}
+		return candidates[i].start < candidates[j].start
+	})
+
+	var kept []candidate
+	for _, c := range candidates {
+		contained := false
+		for _, k := range kept {
+			if c.start >= k.start && c.end <= k.end {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			kept = append(kept, c)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].start < kept[j].start })
+
+	matches := make([]models.GrammarMatch, 0, len(kept))
+	for _, k := range kept {
+		matches = append(matches, models.GrammarMatch{
+			PatternID: k.rule.pattern.ID,
+			Pattern:   k.rule.pattern.Pattern,
+			Meaning:   k.rule.pattern.Meaning,
+			JLPTLevel: k.rule.pattern.JLPTLevel,
+			Start:     k.start,
+			End:       k.end,
+			Text:      k.variant.Surface,
+			Form:      k.variant.Form,
+		})
+	}
+	return matches, nil
+}
+
+// Analyze wraps Detect with a per-text summary for the API, including the
+// conjugated words it contains
+func (d *GrammarDetector) Analyze(text, level string) (*models.GrammarDetectResult, error) {
+	matches, err := d.Detect(text, level)
+	if err != nil {
+		return nil, err
+	}
+	distinct := map[string]bool{}
+	for _, m := range matches {
+		distinct[m.PatternID] = true
+	}
+	result := &models.GrammarDetectResult{
+		Text:     text,
+		Matches:  matches,
+		Patterns: len(distinct),
+		Words:    []models.InflectedWord{},
+	}
+	if d.deinflector != nil {
+		if result.Words, err = d.deinflector.AnalyzeText(text); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (d *GrammarDetector) compiledRules() ([]grammarMatchRule, error) {
+	d.mu.RLock()
+	rules := d.rules
+	d.mu.RUnlock()
+	if rules != nil {
+		return rules, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.rules != nil {
+		return d.rules, nil
+	}
+
+	patterns, err := d.grammarRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	rules = make([]grammarMatchRule, 0, len(patterns))
+	for i := range patterns {
+		if rule, ok := compileGrammarRule(&patterns[i]); ok {
+			rules = append(rules, rule)
+		}
+	}
+	d.rules = rules
+	return rules, nil
+}
+
+// compileGrammarRule turns a pattern's PlainForm and ConjugationRules into a match rule
+func compileGrammarRule(p *models.GrammarPattern) (grammarMatchRule, bool) {
+	variants := patternVariants(p)
+	if len(variants) == 0 {
+		return grammarMatchRule{}, false
+	}
+	rule := grammarMatchRule{pattern: p, variants: variants}
+	rule.anyLeft, rule.left = parseConjugationRules(p.ConjugationRules)
+
+	// A one-kana pattern (て, た) with no attachment rule would match almost anywhere
+	if rule.anyLeft && len(rule.left) == 0 {
+		for _, v := range variants {
+			if utf8.RuneCountInString(v.Surface) < 2 {
+				rule.anyLeft = false
+				rule.left = []leftContext{verbStem}
+				break
+			}
+		}
+	}
+	return rule, true
+}
+
+// parseConjugationRules reads attachment rules written like
+// "Verb (dictionary form) + ことにする / な-adjective + な + はず / Noun + らしい".
+// anyLeft means the pattern has no rules and may follow anything.
+func parseConjugationRules(rules string) (anyLeft bool, left []leftContext) {
+	if strings.TrimSpace(rules) == "" {
+		return true, nil
+	}
+	lower := strings.ToLower(rules)
+
+	// Inflection patterns (て形, ない形) describe how verbs change rather than what they attach to
+	if strings.Contains(lower, "group 1") || strings.Contains(lower, "group 2") {
+		if strings.Contains(lower, "ない") {
+			return false, []leftContext{negativeStem}
+		}
+		return false, []leftContext{verbStem}
+	}
+
+	for _, segment := range strings.Split(lower, " / ") {
+		// "Verb/い-adjective (plain form) / Noun + な + のに": alternatives share the final "+ のに"
+		before := segment
+		if plus := strings.LastIndex(segment, "+"); plus >= 0 {
+			before = segment[:plus]
+			// "な-adjective + な" ends in its connecting kana rather than the pattern
+			if joint := strings.TrimSpace(segment[plus+1:]); joint == "な" || joint == "の" || joint == "だ" {
+				left = append(left, runeIn(joint))
+				continue
+			}
+		}
+		before = strings.TrimSpace(before)
+
+		switch {
+		case strings.HasSuffix(before, "+ な"):
+			left = append(left, runeIn("な"))
+			continue
+		case strings.HasSuffix(before, "+ の"):
+			left = append(left, runeIn("の"))
+			continue
+		case strings.HasSuffix(before, "+ だ"):
+			left = append(left, runeIn("だ"))
+			continue
+		}
+
+		matched := false
+		if strings.Contains(before, "stem") {
+			left = append(left, verbStem)
+			matched = true
+		}
+		if strings.Contains(before, "drop") {
+			left = append(left, adjectiveStem)
+			matched = true
+		}
+		if strings.Contains(before, "dictionary") || strings.Contains(before, "potential") {
+			left = append(left, runeIn(uRow))
+			matched = true
+		}
+		if strings.Contains(before, "negative") || strings.Contains(before, "nai") {
+			left = append(left, runeIn("い"))
+			matched = true
+		}
+		if strings.Contains(before, "past") {
+			left = append(left, runeIn("ただ"))
+			matched = true
+		}
+		if strings.Contains(before, "plain") {
+			left = append(left, runeIn(uRow+"いただ"))
+			matched = true
+		}
+		if strings.Contains(before, "te-form") {
+			left = append(left, runeIn("てで"), runeIn(uRow)) // 〜ているところ ends in る
+			matched = true
+		}
+		if matched {
+			continue
+		}
+		// An adjective or noun the pattern follows directly: 高いそうだ, 学生らしい
+		if strings.Contains(before, "い-adjective") {
+			left = append(left, runeIn("い"))
+		}
+		if strings.Contains(before, "な-adjective") || strings.Contains(before, "noun") {
+			left = append(left, adjectiveStem)
+		}
+	}
+
+	if len(left) == 0 {
+		left = []leftContext{verbStem}
+	}
+	return false, left
+}
+
+func (r *grammarMatchRule) allows(prev rune) bool {
+	if r.anyLeft {
+		return true
+	}
+	if prev == 0 {
+		return false
+	}
+	for _, ok := range r.left {
+		if ok(prev) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"github.com/erwinwahyura/daily-kotoba/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultGrammarDrillCount = 10
+	maxGrammarDrillCount     = 30
+	grammarDrillOptionCount  = 4
+)
+
+// GrammarDrillService generates cloze drills from grammar usage examples
+type GrammarDrillService struct {
+	grammarRepo  *repository.GrammarRepository
+	drillRepo    *repository.GrammarDrillRepository
+	progressRepo *repository.ProgressRepository
+	userRepo     *repository.UserRepository
+}
+
+// NewGrammarDrillService creates a new drill service
+func NewGrammarDrillService(
+	grammarRepo *repository.GrammarRepository,
+	drillRepo *repository.GrammarDrillRepository,
+	progressRepo *repository.ProgressRepository,
+	userRepo *repository.UserRepository,
+) *GrammarDrillService {
+	return &GrammarDrillService{
+		grammarRepo:  grammarRepo,
+		drillRepo:    drillRepo,
+		progressRepo: progressRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// GetDrill builds cloze questions for one pattern, the user's weak patterns,
+// or the patterns the user has reached in a level (default: their current level).
+func (s *GrammarDrillService) GetDrill(userID, patternID, level, mode string, count int, weakOnly bool) (*models.GrammarDrillSet, error) {
+	if mode == "" {
+		mode = models.GrammarDrillChoice
+	}
+	if mode != models.GrammarDrillChoice && mode != models.GrammarDrillTyped {
+		return nil, fmt.Errorf("invalid mode: %s", mode)
+	}
+	if count <= 0 {
+		count = defaultGrammarDrillCount
+	}
+	if count > maxGrammarDrillCount {
+		count = maxGrammarDrillCount
+	}
+
+	var patterns []models.GrammarPattern
+	switch {
+	case patternID != "":
+		p, err := s.grammarRepo.GetByID(patternID)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, *p)
+		level = p.JLPTLevel
+	case weakOnly:
+		analysis, err := s.GetWeakPoints(userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(analysis.WeakPatterns) == 0 {
+			return nil, fmt.Errorf("no weak patterns found - you're doing great!")
+		}
+		for _, wp := range analysis.WeakPatterns {
+			if p, err := s.grammarRepo.GetByID(wp.PatternID); err == nil {
+				patterns = append(patterns, *p)
+			}
+		}
+		level = ""
+	default:
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if level == "" {
+			level = user.CurrentLevel
+		}
+		all, err := s.grammarRepo.GetRangeByLevel(level, 0, 500)
+		if err != nil {
+			return nil, err
+		}
+		// Only drill patterns the user has already reached in their own level
+		if level == user.CurrentLevel {
+			if progress, err := s.progressRepo.GetByUserID(userID); err == nil {
+				for _, p := range all {
+					if p.IndexPosition <= progress.CurrentGrammarIndex {
+						patterns = append(patterns, p)
+					}
+				}
+			}
+		}
+		if len(patterns) == 0 {
+			patterns = all
+		}
+	}
+
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no grammar patterns available")
+	}
+
+	allPatterns, err := s.grammarRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var questions []models.GrammarDrillQuestion
+	for i := range patterns {
+		for idx := range patterns[i].UsageExamples {
+			q, ok := s.buildCloze(r, &patterns[i], idx, mode, allPatterns)
+			if ok {
+				questions = append(questions, q)
+			}
+		}
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("no usage examples contain these patterns")
+	}
+
+	r.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+	if len(questions) > count {
+		questions = questions[:count]
+	}
+
+	return &models.GrammarDrillSet{
+		Questions: questions,
+		Mode:      mode,
+		Level:     level,
+	}, nil
+}
+
+// SubmitAnswer checks a cloze answer and records the attempt
+func (s *GrammarDrillService) SubmitAnswer(userID, patternID string, exampleIndex int, mode, answer string) (*models.GrammarDrillResult, error) {
+	if mode == "" {
+		mode = models.GrammarDrillChoice
+	}
+	pattern, err := s.grammarRepo.GetByID(patternID)
+	if err != nil {
+		return nil, err
+	}
+	if exampleIndex < 0 || exampleIndex >= len(pattern.UsageExamples) {
+		return nil, fmt.Errorf("example not found")
+	}
+
+	example := pattern.UsageExamples[exampleIndex]
+	variants := patternVariants(pattern)
+	_, matched, ok := findPatternInSentence(variants, example.Japanese)
+	if !ok {
+		return nil, fmt.Errorf("pattern does not appear in this example")
+	}
+
+	given := normalizeGrammarAnswer(answer)
+	isCorrect := given == matched.Surface
+	if !isCorrect && mode == models.GrammarDrillTyped {
+		// Accept other spellings of the same form (過ぎた vs すぎた)
+		for _, v := range variants {
+			if v.Form == matched.Form && v.Surface == given {
+				isCorrect = true
+				break
+			}
+		}
+	}
+
+	attempt := &models.GrammarDrillAttempt{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		PatternID:     patternID,
+		ExampleIndex:  exampleIndex,
+		Mode:          mode,
+		UserAnswer:    answer,
+		CorrectAnswer: matched.Surface,
+		IsCorrect:     isCorrect,
+	}
+	if err := s.drillRepo.RecordAttempt(attempt); err != nil {
+		return nil, fmt.Errorf("failed to record attempt: %w", err)
+	}
+
+	explanation := example.Nuance
+	if explanation == "" {
+		explanation = fmt.Sprintf("%s: %s", pattern.Pattern, pattern.Meaning)
+	}
+
+	return &models.GrammarDrillResult{
+		IsCorrect:     isCorrect,
+		CorrectAnswer: matched.Surface,
+		FullSentence:  example.Japanese,
+		Explanation:   explanation,
+	}, nil
+}
+
+// GetWeakPoints analyzes drill accuracy per pattern
+func (s *GrammarDrillService) GetWeakPoints(userID string) (*models.GrammarWeakPointsAnalysis, error) {
+	stats, err := s.drillRepo.GetAccuracyByPattern(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Same thresholds as conjugation weak points
+	weak := []models.WeakPattern{}
+	strong := []models.WeakPattern{}
+	for _, wp := range stats {
+		if wp.Accuracy < 70.0 && wp.Total >= 5 {
+			weak = append(weak, wp)
+		} else if wp.Accuracy >= 80.0 {
+			strong = append(strong, wp)
+		}
+	}
+
+	return &models.GrammarWeakPointsAnalysis{
+		WeakPatterns:   weak,
+		StrongPatterns: strong,
+		TotalPatterns:  len(stats),
+	}, nil
+}
+
+// buildCloze blanks the pattern out of one usage example; ok is false if the
+// example doesn't contain the pattern.
+func (s *GrammarDrillService) buildCloze(r *rand.Rand, p *models.GrammarPattern, exampleIndex int, mode string, allPatterns []models.GrammarPattern) (models.GrammarDrillQuestion, bool) {
+	example := p.UsageExamples[exampleIndex]
+	variants := patternVariants(p)
+	start, matched, ok := findPatternInSentence(variants, example.Japanese)
+	if !ok {
+		return models.GrammarDrillQuestion{}, false
+	}
+
+	q := models.GrammarDrillQuestion{
+		PatternID:    p.ID,
+		ExampleIndex: exampleIndex,
+		Sentence:     example.Japanese[:start] + models.GrammarClozeBlank + example.Japanese[start+len(matched.Surface):],
+		Meaning:      example.Meaning,
+		Context:      example.Context,
+		Form:         matched.Form,
+		Mode:         mode,
+	}
+
+	if mode == models.GrammarDrillTyped {
+		// Typed answers need the base pattern; in choice mode it would give the answer away
+		q.Pattern = p.Pattern
+	} else {
+		options := s.clozeOptions(r, p, matched, variants, allPatterns)
+		if len(options) < 2 {
+			return models.GrammarDrillQuestion{}, false
+		}
+		q.Options = options
+	}
+
+	return q, true
+}
+
+// clozeOptions picks distractors from the pattern's related (confusable) patterns,
+// inflected to the same form as the answer, then tops up from the same level.
+func (s *GrammarDrillService) clozeOptions(r *rand.Rand, p *models.GrammarPattern, answer patternVariant, own []patternVariant, allPatterns []models.GrammarPattern) []string {
+	options := []string{answer.Surface}
+	seen := map[string]bool{}
+	for _, v := range own {
+		seen[v.Surface] = true
+	}
+
+	addFrom := func(candidate *models.GrammarPattern) {
+		if len(options) >= grammarDrillOptionCount {
+			return
+		}
+		surface := variantForForm(patternVariants(candidate), answer.Form)
+		if surface == "" || seen[surface] {
+			return
+		}
+		seen[surface] = true
+		options = append(options, surface)
+	}
+
+	for _, rp := range p.RelatedPatterns {
+		addFrom(&models.GrammarPattern{Pattern: rp.Pattern})
+	}
+
+	var sameLevel []models.GrammarPattern
+	for _, other := range allPatterns {
+		if other.JLPTLevel == p.JLPTLevel && other.ID != p.ID {
+			sameLevel = append(sameLevel, other)
+		}
+	}
+	r.Shuffle(len(sameLevel), func(i, j int) { sameLevel[i], sameLevel[j] = sameLevel[j], sameLevel[i] })
+	for i := range sameLevel {
+		addFrom(&sameLevel[i])
+	}
+
+	r.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+	return options
+}
+
+func normalizeGrammarAnswer(answer string) string {
+	answer = strings.TrimSpace(answer)
+	answer = strings.Trim(answer, "〜～~")
+	answer = strings.ReplaceAll(answer, " ", "")
+	return strings.ReplaceAll(answer, "　", "")
+}
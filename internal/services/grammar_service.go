@@ -1,27 +1,39 @@
 package services
 
 import (
+	"fmt"
 	"math"
+	"strings"
 
 	"github.com/erwinwahyura/daily-kotoba/internal/models"
 	"github.com/erwinwahyura/daily-kotoba/internal/repository"
+	"github.com/google/uuid"
 )
 
 type GrammarService struct {
-	grammarRepo  *repository.GrammarRepository
-	progressRepo *repository.ProgressRepository
-	userRepo     *repository.UserRepository
+	grammarRepo    *repository.GrammarRepository
+	comparisonRepo *repository.GrammarComparisonRepository
+	prereqRepo     *repository.GrammarPrerequisiteRepository
+	progressRepo   *repository.ProgressRepository
+	conjRepo       *repository.ConjugationRepository
+	userRepo       *repository.UserRepository
 }
 
 func NewGrammarService(
 	grammarRepo *repository.GrammarRepository,
+	comparisonRepo *repository.GrammarComparisonRepository,
+	prereqRepo *repository.GrammarPrerequisiteRepository,
 	progressRepo *repository.ProgressRepository,
+	conjRepo *repository.ConjugationRepository,
 	userRepo *repository.UserRepository,
 ) *GrammarService {
 	return &GrammarService{
-		grammarRepo:  grammarRepo,
-		progressRepo: progressRepo,
-		userRepo:     userRepo,
+		grammarRepo:    grammarRepo,
+		comparisonRepo: comparisonRepo,
+		prereqRepo:     prereqRepo,
+		progressRepo:   progressRepo,
+		conjRepo:       conjRepo,
+		userRepo:       userRepo,
 	}
 }
 
@@ -47,12 +59,8 @@ func (s *GrammarService) GetDailyPattern(userID string) (*models.GrammarPatternR
 	}
 
 	return &models.GrammarPatternResponse{
-		Pattern: pattern,
-		Progress: &models.GrammarProgress{
-			CurrentIndex:    progress.CurrentGrammarIndex,
-			TotalPatterns:   totalPatterns,
-			PatternsLearned: progress.GrammarLearnedCount,
-		},
+		Pattern:  pattern,
+		Progress: s.grammarProgress(userID, progress, totalPatterns),
 	}, nil
 }
 
@@ -90,15 +98,20 @@ func (s *GrammarService) BulkCreatePatterns(patterns []models.GrammarPattern) er
 	return s.grammarRepo.BulkCreate(patterns)
 }
 
+// SkipToNextPattern records the current pattern's status and advances to the next one.
+// "studied" is accepted as an alias of learning for older clients.
 func (s *GrammarService) SkipToNextPattern(userID, patternID, status string) (*models.GrammarPatternResponse, error) {
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Mark current pattern status (placeholder for future tracking)
-	_ = patternID
-	_ = status
+	if status == "studied" {
+		status = models.GrammarStatusLearning
+	}
+	if _, err := s.markStatus(userID, patternID, status); err != nil {
+		return nil, err
+	}
 
 	// Increment grammar index
 	progress, err := s.progressRepo.IncrementGrammarIndex(userID)
@@ -124,211 +137,307 @@ func (s *GrammarService) SkipToNextPattern(userID, patternID, status string) (*m
 		return nil, err
 	}
 
+	// markStatus may have changed the learned count after progress was read
+	if fresh, err := s.progressRepo.GetByUserID(userID); err == nil {
+		progress = fresh
+	}
+
 	return &models.GrammarPatternResponse{
-		Pattern: nextPattern,
-		Progress: &models.GrammarProgress{
-			CurrentIndex:    progress.CurrentGrammarIndex,
-			TotalPatterns:   totalPatterns,
-			PatternsLearned: progress.GrammarLearnedCount,
-		},
+		Pattern:  nextPattern,
+		Progress: s.grammarProgress(userID, progress, totalPatterns),
 	}, nil
 }
 
-// ComparisonPair represents two related patterns for side-by-side study
-type ComparisonPair struct {
-	ID          string                    `json:"id"`
-	Name        string                    `json:"name"`
-	Description string                    `json:"description"`
-	PatternA    *models.GrammarPattern    `json:"pattern_a"`
-	PatternB    *models.GrammarPattern    `json:"pattern_b"`
-	KeyDifferences []string               `json:"key_differences"`
-	UsageBoundaries string                 `json:"usage_boundaries"`
+// SetPatternStatus marks a pattern learning, known or skipped without moving the daily index
+func (s *GrammarService) SetPatternStatus(userID, patternID, status string) (*models.GrammarStatusResponse, error) {
+	pattern, err := s.grammarRepo.GetByID(patternID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.markStatus(userID, patternID, status)
+	if err != nil {
+		return nil, err
+	}
+	return s.statusResponse(userID, pattern, current)
 }
 
-// DetailedComparison for two specific patterns
-type DetailedComparison struct {
-	PatternA        *models.GrammarPattern    `json:"pattern_a"`
-	PatternB        *models.GrammarPattern    `json:"pattern_b"`
-	KeyDifferences  []DifferencePoint        `json:"key_differences"`
-	UsageBoundaries []BoundaryRule           `json:"usage_boundaries"`
-	CommonErrors    []CommonError            `json:"common_errors"`
-	DecisionTree    string                   `json:"decision_tree"`
-}
+// RevisitPattern puts a known or skipped pattern back into learning
+func (s *GrammarService) RevisitPattern(userID, patternID string) (*models.GrammarStatusResponse, error) {
+	pattern, err := s.grammarRepo.GetByID(patternID)
+	if err != nil {
+		return nil, err
+	}
 
-// DifferencePoint highlights one specific contrast
-type DifferencePoint struct {
-	Aspect      string `json:"aspect"`       // e.g., "politeness", "restriction", "meaning"
-	PatternA    string `json:"pattern_a"`    // How pattern A behaves
-	PatternB    string `json:"pattern_b"`    // How pattern B behaves
-	ExampleA    string `json:"example_a"`    // Example for A
-	ExampleB    string `json:"example_b"`    // Example for B
-}
+	previous, _ := s.progressRepo.GetGrammarStatus(userID, patternID)
+	if err := s.progressRepo.RevisitGrammar(uuid.New().String(), userID, patternID); err != nil {
+		return nil, fmt.Errorf("failed to revisit pattern: %w", err)
+	}
+	if previous != nil && previous.Status == models.GrammarStatusKnown {
+		if err := s.progressRepo.DecrementGrammarLearned(userID); err != nil {
+			return nil, err
+		}
+	}
 
-// BoundaryRule defines when to use which pattern
-type BoundaryRule struct {
-	Situation   string `json:"situation"`    // When this applies
-	UsePattern  string `json:"use_pattern"`  // "A" or "B"
-	Explanation string `json:"explanation"`  // Why
+	current, err := s.progressRepo.GetGrammarStatus(userID, patternID)
+	if err != nil {
+		return nil, err
+	}
+	return s.statusResponse(userID, pattern, current)
 }
 
-// CommonError shows mistakes learners make
-type CommonError struct {
-	Error       string `json:"error"`        // The mistake
-	Correction  string `json:"correction"`   // How to fix it
-	Explanation string `json:"explanation"`  // Why it's wrong
+// ResetPattern forgets the user's status for a pattern
+func (s *GrammarService) ResetPattern(userID, patternID string) (*models.GrammarStatusResponse, error) {
+	pattern, err := s.grammarRepo.GetByID(patternID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, _ := s.progressRepo.GetGrammarStatus(userID, patternID)
+	if previous == nil {
+		return s.statusResponse(userID, pattern, nil)
+	}
+	if err := s.progressRepo.DeleteGrammarStatus(userID, patternID); err != nil {
+		return nil, fmt.Errorf("failed to reset pattern: %w", err)
+	}
+	if previous.Status == models.GrammarStatusKnown {
+		if err := s.progressRepo.DecrementGrammarLearned(userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.statusResponse(userID, pattern, nil)
 }
 
-// GetComparisonPairs returns related patterns grouped for comparison study
-func (s *GrammarService) GetComparisonPairs(userID, level string) ([]ComparisonPair, error) {
-	// Get all patterns for the level
-	patterns, _, err := s.grammarRepo.GetByLevel(level, 1, 100)
+// GetLearnedPatterns lists the user's marked patterns. status defaults to known;
+// "all" lists every status.
+func (s *GrammarService) GetLearnedPatterns(userID, level, status string, page, limit int) (*models.LearnedGrammarResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	switch status {
+	case "":
+		status = models.GrammarStatusKnown
+	case "all":
+		status = ""
+	case models.GrammarStatusLearning, models.GrammarStatusKnown, models.GrammarStatusSkipped:
+	default:
+		return nil, fmt.Errorf("invalid status: %s", status)
+	}
+
+	patterns, total, err := s.progressRepo.ListGrammarStatuses(userID, level, status, page, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	// Build comparison pairs based on related_patterns in data
-	pairs := []ComparisonPair{}
-	seen := make(map[string]bool)
-
-	for _, p := range patterns {
-		// Skip if already in a pair
-		if seen[p.ID] {
+	counts := map[string]int{
+		models.GrammarStatusLearning: 0,
+		models.GrammarStatusKnown:    0,
+		models.GrammarStatusSkipped:  0,
+	}
+	byLevel, err := s.progressRepo.GetGrammarStatusCounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	for lvl, statuses := range byLevel {
+		if level != "" && lvl != level {
 			continue
 		}
+		for st, n := range statuses {
+			counts[st] += n
+		}
+	}
+
+	return &models.LearnedGrammarResponse{
+		Patterns:       patterns,
+		CountsByStatus: counts,
+		Pagination: models.PaginationResponse{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		},
+	}, nil
+}
+
+// markStatus stores a status and keeps grammar_learned_count in step with known patterns
+func (s *GrammarService) markStatus(userID, patternID, status string) (*models.UserGrammarStatus, error) {
+	switch status {
+	case models.GrammarStatusLearning, models.GrammarStatusKnown, models.GrammarStatusSkipped:
+	default:
+		return nil, fmt.Errorf("invalid status: %s", status)
+	}
 
-		// Look for related patterns marked for comparison
-		for _, related := range p.RelatedPatterns {
-			if related.Relationship == "contrast" || related.Relationship == "opposite" {
-				// Find the related pattern by pattern name
-				relatedPattern, err := s.grammarRepo.GetByPattern(related.Pattern, level)
-				if err != nil {
-					continue
-				}
-
-				pairID := p.ID + "_vs_" + relatedPattern.ID
-				if seen[relatedPattern.ID] || seen[p.ID] {
-					continue
-				}
-
-				pair := ComparisonPair{
-					ID:              pairID,
-					Name:            p.Pattern + " vs " + relatedPattern.Pattern,
-					Description:     related.KeyDifference,
-					PatternA:        &p,
-					PatternB:        relatedPattern,
-					KeyDifferences:  []string{related.KeyDifference},
-					UsageBoundaries: "See detailed comparison for usage rules",
-				}
-				pairs = append(pairs, pair)
-				seen[p.ID] = true
-				seen[relatedPattern.ID] = true
-				break // One pair per pattern for now
-			}
+	previous, _ := s.progressRepo.GetGrammarStatus(userID, patternID)
+	if err := s.progressRepo.MarkGrammarStatus(uuid.New().String(), userID, patternID, status); err != nil {
+		return nil, fmt.Errorf("failed to mark pattern status: %w", err)
+	}
+
+	wasKnown := previous != nil && previous.Status == models.GrammarStatusKnown
+	isKnown := status == models.GrammarStatusKnown
+	switch {
+	case isKnown && !wasKnown:
+		if err := s.progressRepo.IncrementGrammarLearned(userID); err != nil {
+			return nil, err
 		}
+	case wasKnown && !isKnown:
+		if err := s.progressRepo.DecrementGrammarLearned(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.progressRepo.GetGrammarStatus(userID, patternID)
+}
+
+func (s *GrammarService) statusResponse(userID string, pattern *models.GrammarPattern, status *models.UserGrammarStatus) (*models.GrammarStatusResponse, error) {
+	progress, err := s.progressRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
 	}
+	total, err := s.grammarRepo.GetTotalCountByLevel(pattern.JLPTLevel)
+	if err != nil {
+		return nil, err
+	}
+	return &models.GrammarStatusResponse{
+		Pattern:  pattern,
+		Status:   status,
+		Progress: s.grammarProgress(userID, progress, total),
+	}, nil
+}
 
-	// If no pairs found from relationships, create some defaults
-	if len(pairs) == 0 && len(patterns) >= 2 {
-		// Create pairs from consecutive patterns (simple fallback)
-		for i := 0; i < len(patterns)-1 && i < 6; i += 2 {
-			pair := ComparisonPair{
-				ID:          patterns[i].ID + "_vs_" + patterns[i+1].ID,
-				Name:        patterns[i].Pattern + " vs " + patterns[i+1].Pattern,
-				Description: "Compare these related patterns",
-				PatternA:    &patterns[i],
-				PatternB:    &patterns[i+1],
-				KeyDifferences: []string{
-					patterns[i].NuanceNotes,
-					patterns[i+1].NuanceNotes,
-				},
-				UsageBoundaries: "Review pattern examples for usage differences",
-			}
-			pairs = append(pairs, pair)
+// grammarProgress builds the progress block, with known pattern counts per level
+func (s *GrammarService) grammarProgress(userID string, progress *models.UserProgress, totalPatterns int) *models.GrammarProgress {
+	mastery := map[string]int{}
+	if counts, err := s.progressRepo.GetGrammarStatusCounts(userID); err == nil {
+		for level, statuses := range counts {
+			mastery[level] = statuses[models.GrammarStatusKnown]
 		}
 	}
+	return &models.GrammarProgress{
+		CurrentIndex:    progress.CurrentGrammarIndex,
+		TotalPatterns:   totalPatterns,
+		PatternsLearned: progress.GrammarLearnedCount,
+		MasteryByLevel:  mastery,
+	}
+}
 
-	return pairs, nil
+// GetComparisonPairs returns the curated comparisons for a level
+func (s *GrammarService) GetComparisonPairs(userID, level string) ([]models.GrammarComparison, error) {
+	comparisons, err := s.comparisonRepo.GetByLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	for i := range comparisons {
+		s.attachComparisonPatterns(&comparisons[i])
+	}
+	return comparisons, nil
 }
 
-// ComparePatterns returns detailed analysis between two patterns
-func (s *GrammarService) ComparePatterns(patternAID, patternBID string) (*DetailedComparison, error) {
-	patternA, err := s.grammarRepo.GetByID(patternAID)
+// ComparePatterns returns the curated comparison for two patterns, in either order
+func (s *GrammarService) ComparePatterns(patternAID, patternBID string) (*models.GrammarComparison, error) {
+	comparison, err := s.comparisonRepo.GetByPair(patternAID, patternBID)
 	if err != nil {
 		return nil, err
 	}
+	s.attachComparisonPatterns(comparison)
+	return comparison, nil
+}
 
-	patternB, err := s.grammarRepo.GetByID(patternBID)
+// GetComparison returns one curated comparison
+func (s *GrammarService) GetComparison(comparisonID string) (*models.GrammarComparison, error) {
+	comparison, err := s.comparisonRepo.GetByID(comparisonID)
 	if err != nil {
 		return nil, err
 	}
+	s.attachComparisonPatterns(comparison)
+	return comparison, nil
+}
 
-	// Build key differences from usage examples
-	differences := []DifferencePoint{}
-	
-	// Compare meanings
-	if patternA.Meaning != patternB.Meaning {
-		differences = append(differences, DifferencePoint{
-			Aspect:   "core_meaning",
-			PatternA: patternA.Meaning,
-			PatternB: patternB.Meaning,
-		})
-	}
-
-	// Compare nuance
-	if patternA.NuanceNotes != "" && patternB.NuanceNotes != "" {
-		differences = append(differences, DifferencePoint{
-			Aspect:   "nuance",
-			PatternA: patternA.NuanceNotes,
-			PatternB: patternB.NuanceNotes,
-		})
-	}
-
-	// Extract examples for comparison
-	if len(patternA.UsageExamples) > 0 && len(patternB.UsageExamples) > 0 {
-		differences = append(differences, DifferencePoint{
-			Aspect:   "example_contrast",
-			PatternA: patternA.UsageExamples[0].Japanese + " (" + patternA.UsageExamples[0].Meaning + ")",
-			PatternB: patternB.UsageExamples[0].Japanese + " (" + patternB.UsageExamples[0].Meaning + ")",
-		})
-	}
-
-	// Usage boundaries
-	boundaries := []BoundaryRule{
-		{
-			Situation:   "When expressing " + patternA.Meaning,
-			UsePattern:  "A",
-			Explanation: patternA.NuanceNotes,
-		},
-		{
-			Situation:   "When expressing " + patternB.Meaning,
-			UsePattern:  "B",
-			Explanation: patternB.NuanceNotes,
-		},
+// GetComparisonQuiz returns "which pattern fits?" questions for a comparison
+func (s *GrammarService) GetComparisonQuiz(comparisonID string) (*models.ComparisonQuiz, error) {
+	comparison, err := s.GetComparison(comparisonID)
+	if err != nil {
+		return nil, err
+	}
+	if len(comparison.Quiz) == 0 {
+		return nil, fmt.Errorf("this comparison has no quiz questions")
 	}
 
-	// Common errors
-	errors := []CommonError{}
-	if patternA.CommonMistakes != "" {
-		errors = append(errors, CommonError{
-			Error:       "Using " + patternA.Pattern + " when " + patternB.Pattern + " is needed",
-			Correction:  "Use " + patternB.Pattern + " instead for " + patternB.Meaning,
-			Explanation: patternA.CommonMistakes,
-		})
+	options := comparisonOptions(comparison)
+	questions := make([]models.ComparisonQuizQuestion, len(comparison.Quiz))
+	for i, item := range comparison.Quiz {
+		questions[i] = models.ComparisonQuizQuestion{
+			Index:    i,
+			Context:  item.Context,
+			Sentence: item.Sentence,
+			Meaning:  item.Meaning,
+			Options:  options,
+		}
 	}
 
-	return &DetailedComparison{
-		PatternA:        patternA,
-		PatternB:        patternB,
-		KeyDifferences:  differences,
-		UsageBoundaries: boundaries,
-		CommonErrors:    errors,
-		DecisionTree:    s.buildDecisionTree(patternA, patternB),
+	return &models.ComparisonQuiz{
+		ComparisonID: comparison.ID,
+		Title:        comparison.Title,
+		Questions:    questions,
 	}, nil
 }
 
-// buildDecisionTree creates a decision guide for choosing between patterns
-func (s *GrammarService) buildDecisionTree(a, b *models.GrammarPattern) string {
-	return "Choose " + a.Pattern + " when: " + a.Meaning + ". Choose " + b.Pattern + " when: " + b.Meaning + "."
+// CheckComparisonAnswer grades one quiz answer. answer may be the side ("A"/"B")
+// or the pattern text shown as the option.
+func (s *GrammarService) CheckComparisonAnswer(comparisonID string, index int, answer string) (*models.ComparisonQuizResult, error) {
+	comparison, err := s.GetComparison(comparisonID)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(comparison.Quiz) {
+		return nil, fmt.Errorf("quiz question not found")
+	}
+	item := comparison.Quiz[index]
+
+	options := comparisonOptions(comparison)
+	side := strings.ToUpper(strings.TrimSpace(answer))
+	for i, option := range options {
+		if strings.TrimSpace(answer) == option {
+			side = []string{models.ComparisonSideA, models.ComparisonSideB}[i]
+		}
+	}
+	if side != models.ComparisonSideA && side != models.ComparisonSideB {
+		return nil, fmt.Errorf("answer must be A, B or one of the options")
+	}
+
+	correctOption := options[0]
+	if item.Answer == models.ComparisonSideB {
+		correctOption = options[1]
+	}
+
+	return &models.ComparisonQuizResult{
+		IsCorrect:     side == item.Answer,
+		CorrectAnswer: item.Answer,
+		CorrectOption: correctOption,
+		Explanation:   item.Explanation,
+	}, nil
+}
+
+func (s *GrammarService) attachComparisonPatterns(c *models.GrammarComparison) {
+	if p, err := s.grammarRepo.GetByID(c.PatternAID); err == nil {
+		c.PatternA = p
+	}
+	if p, err := s.grammarRepo.GetByID(c.PatternBID); err == nil {
+		c.PatternB = p
+	}
+}
+
+// comparisonOptions returns the two patterns as quiz options, A first
+func comparisonOptions(c *models.GrammarComparison) []string {
+	options := []string{models.ComparisonSideA, models.ComparisonSideB}
+	if c.PatternA != nil {
+		options[0] = c.PatternA.Pattern
+	}
+	if c.PatternB != nil {
+		options[1] = c.PatternB.Pattern
+	}
+	return options
 }
 
 // SearchGrammar searches grammar patterns by query string
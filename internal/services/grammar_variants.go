@@ -0,0 +1,272 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// patternVariant is one inflected surface form of a grammar pattern
+type patternVariant struct {
+	Form    string // plain, polite, past, te, negative, ...
+	Surface string
+}
+
+var patternAnnotation = regexp.MustCompile(`\s*[（(][^）)]*[）)]`)
+
+// godanRows maps a godan dictionary ending to its a/i/e/o-row kana
+var godanRows = map[rune][4]string{
+	'う': {"わ", "い", "え", "お"},
+	'く': {"か", "き", "け", "こ"},
+	'ぐ': {"が", "ぎ", "げ", "ご"},
+	'す': {"さ", "し", "せ", "そ"},
+	'つ': {"た", "ち", "て", "と"},
+	'ぬ': {"な", "に", "ね", "の"},
+	'ぶ': {"ば", "び", "べ", "ぼ"},
+	'む': {"ま", "み", "め", "も"},
+	'る': {"ら", "り", "れ", "ろ"},
+}
+
+// godanTe maps a godan dictionary ending to its te/ta euphonic form
+var godanTe = map[rune][2]string{
+	'う': {"って", "った"},
+	'つ': {"って", "った"},
+	'る': {"って", "った"},
+	'む': {"んで", "んだ"},
+	'ぶ': {"んで", "んだ"},
+	'ぬ': {"んで", "んだ"},
+	'く': {"いて", "いた"},
+	'ぐ': {"いで", "いだ"},
+	'す': {"して", "した"},
+}
+
+// godanRuVerbs are る-verbs that look ichidan but conjugate as godan
+var godanRuVerbs = []string{
+	"帰る", "入る", "はいる", "走る", "はしる", "知る", "しる", "切る", "要る", "減る",
+	"限る", "かぎる", "しゃべる", "滑る", "すべる", "参る", "まいる", "蹴る", "握る",
+	"焦る", "あせる", "散る", "湿る", "茂る", "照る", "練る", "遮る", "蘇る", "罵る", "嘲る",
+}
+
+// naAdjectivesEndingInI look like i-adjectives but conjugate with だ
+var naAdjectivesEndingInI = []string{"みたい", "きれい", "綺麗", "きらい", "嫌い"}
+
+var (
+	iRow = "いきぎしじちぢにひびぴみりゐ"
+	eRow = "えけげせぜてでねへべぺめれゑ"
+)
+
+// patternBaseForms extracts the bare forms of a pattern ("〜やすい/〜にくい" → やすい, にくい),
+// from both the plain form and the display pattern so kanji and kana spellings match.
+func patternBaseForms(p *models.GrammarPattern) []string {
+	var forms []string
+	seen := map[string]bool{}
+	for _, src := range []string{p.PlainForm, p.Pattern} {
+		src = patternAnnotation.ReplaceAllString(src, "")
+		for _, part := range strings.FieldsFunc(src, func(r rune) bool { return r == '/' || r == '／' }) {
+			part = strings.TrimSpace(strings.Trim(strings.TrimSpace(part), "〜～~"))
+			// Labels like "て形" describe a form rather than spell it
+			part = strings.TrimSuffix(part, "形")
+			if part != "" && !seen[part] {
+				seen[part] = true
+				forms = append(forms, part)
+			}
+		}
+	}
+	return forms
+}
+
+// patternVariants returns every inflected form of a pattern, longest first
+func patternVariants(p *models.GrammarPattern) []patternVariant {
+	var variants []patternVariant
+	seen := map[string]bool{}
+	for _, base := range patternBaseForms(p) {
+		for _, v := range inflectBase(base) {
+			if !seen[v.Surface] {
+				seen[v.Surface] = true
+				variants = append(variants, v)
+			}
+		}
+	}
+	// Longest first so ようにしている wins over ようにする/ように
+	for i := 1; i < len(variants); i++ {
+		for j := i; j > 0 && len([]rune(variants[j].Surface)) > len([]rune(variants[j-1].Surface)); j-- {
+			variants[j], variants[j-1] = variants[j-1], variants[j]
+		}
+	}
+	return variants
+}
+
+// inflectBase conjugates a pattern's final word. Patterns end in a verb
+// (ことにする), an i-adjective-like ending (らしい, わけにはいかない),
+// the copula (ものだ), or something that doesn't inflect (ように).
+func inflectBase(base string) []patternVariant {
+	runes := []rune(base)
+	if len(runes) == 0 {
+		return nil
+	}
+	variants := []patternVariant{{Form: "plain", Surface: base}}
+	add := func(form, surface string) {
+		variants = append(variants, patternVariant{Form: form, Surface: surface})
+	}
+
+	// みたい and friends end in い but inflect like the copula (みたいだった, not みたかった)
+	for _, na := range naAdjectivesEndingInI {
+		if strings.HasSuffix(base, na) {
+			return append([]patternVariant{{Form: "plain", Surface: base}}, inflectBase(base+"だ")...)
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(base, "です"):
+		stem := strings.TrimSuffix(base, "です")
+		add("past", stem+"でした")
+		add("plain", stem+"だ")
+	case strings.HasSuffix(base, "だ"):
+		stem := strings.TrimSuffix(base, "だ")
+		add("polite", stem+"です")
+		add("past", stem+"だった")
+		add("polite_past", stem+"でした")
+		add("negative", stem+"ではない")
+		add("negative", stem+"じゃない")
+		add("te", stem+"で")
+		add("attributive", stem+"な")
+		add("adverbial", stem+"に")
+	case strings.HasSuffix(base, "ます"):
+		stem := strings.TrimSuffix(base, "ます")
+		add("polite_past", stem+"ました")
+		add("polite_negative", stem+"ません")
+		add("volitional", stem+"ましょう")
+	case runes[len(runes)-1] == 'い' && len(runes) > 1:
+		stem := string(runes[:len(runes)-1])
+		add("polite", base+"です")
+		add("past", stem+"かった")
+		if !strings.HasSuffix(base, "ない") {
+			add("negative", stem+"くない")
+		}
+		add("te", stem+"くて")
+		add("adverbial", stem+"く")
+		add("conditional", stem+"ければ")
+		// Verb negatives (いかない) also take polite verb forms (いきません)
+		if strings.HasSuffix(base, "ない") && len(runes) > 2 {
+			if masu, ok := negativeToMasuStem(string(runes[:len(runes)-2])); ok {
+				add("polite", masu+"ません")
+				add("polite_past", masu+"ませんでした")
+			}
+		}
+	default:
+		variants = append(variants, inflectVerb(base)...)
+	}
+	return variants
+}
+
+// negativeToMasuStem turns a verb's negative stem (いか) into its masu stem (いき)
+func negativeToMasuStem(stem string) (string, bool) {
+	runes := []rune(stem)
+	if len(runes) == 0 {
+		return "", false
+	}
+	last := runes[len(runes)-1]
+	prefix := string(runes[:len(runes)-1])
+	// A particle or adjective stem before ない means it isn't a verb (わけがない, 高くない)
+	if strings.ContainsRune("がはもでにとく", last) {
+		return "", false
+	}
+	switch {
+	case last == 'し' || last == 'こ':
+		// しない → しません, こない → きません
+		if last == 'こ' {
+			return prefix + "き", true
+		}
+		return stem, true
+	case strings.ContainsRune(iRow, last) || strings.ContainsRune(eRow, last):
+		return stem, true // ichidan: 食べない → 食べません
+	}
+	for _, row := range godanRows {
+		if string(last) == row[0] {
+			return prefix + row[1], true
+		}
+	}
+	return "", false
+}
+
+// inflectVerb conjugates a pattern ending in a verb
+func inflectVerb(base string) []patternVariant {
+	runes := []rune(base)
+	last := runes[len(runes)-1]
+	prefix := string(runes[:len(runes)-1])
+
+	var out []patternVariant
+	add := func(form, surface string) {
+		out = append(out, patternVariant{Form: form, Surface: surface})
+	}
+	stemForms := func(masu, te, ta, nai, vol, cond string) {
+		add("polite", masu+"ます")
+		add("polite_past", masu+"ました")
+		add("polite_negative", masu+"ません")
+		add("te", te)
+		add("past", ta)
+		add("negative", nai+"ない")
+		add("past_negative", nai+"なかった")
+		add("volitional", vol)
+		add("conditional", cond)
+	}
+
+	switch {
+	case strings.HasSuffix(base, "する"):
+		p := strings.TrimSuffix(base, "する")
+		stemForms(p+"し", p+"して", p+"した", p+"し", p+"しよう", p+"すれば")
+	case strings.HasSuffix(base, "来る"):
+		p := strings.TrimSuffix(base, "来る")
+		stemForms(p+"来", p+"来て", p+"来た", p+"来", p+"来よう", p+"来れば")
+	case strings.HasSuffix(base, "くる") && len(runes) == 2:
+		stemForms("き", "きて", "きた", "こ", "こよう", "くれば")
+	case last == 'る' && len(runes) > 1 && isIchidanStem(runes[len(runes)-2], base):
+		stemForms(prefix, prefix+"て", prefix+"た", prefix, prefix+"よう", prefix+"れば")
+	default:
+		row, ok := godanRows[last]
+		if !ok {
+			return nil // doesn't inflect (ように, ところ, て)
+		}
+		te := godanTe[last]
+		if strings.HasSuffix(base, "いく") || strings.HasSuffix(base, "行く") {
+			te = [2]string{"って", "った"} // 行く is the one irregular く-verb
+		}
+		stemForms(prefix+row[1], prefix+te[0], prefix+te[1], prefix+row[0], prefix+row[3]+"う", prefix+row[2]+"ば")
+	}
+	return out
+}
+
+func isIchidanStem(prev rune, base string) bool {
+	for _, verb := range godanRuVerbs {
+		if strings.HasSuffix(base, verb) {
+			return false
+		}
+	}
+	return strings.ContainsRune(iRow, prev) || strings.ContainsRune(eRow, prev)
+}
+
+// findPatternInSentence locates the longest pattern variant in a sentence.
+// Ties go to the last occurrence, since grammar patterns usually close the clause.
+func findPatternInSentence(variants []patternVariant, sentence string) (start int, v patternVariant, ok bool) {
+	for _, cand := range variants {
+		if idx := strings.LastIndex(sentence, cand.Surface); idx >= 0 {
+			return idx, cand, true
+		}
+	}
+	return 0, patternVariant{}, false
+}
+
+// variantForForm returns the surface of a pattern in the given form, falling back to plain
+func variantForForm(variants []patternVariant, form string) string {
+	plain := ""
+	for _, v := range variants {
+		if v.Form == form {
+			return v.Surface
+		}
+		if v.Form == "plain" && plain == "" {
+			plain = v.Surface
+		}
+	}
+	return plain
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"math"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// The full JLPT is scored out of 180 at every level
+const jlptMaxScore = 180
+
+// Reference grades: A from 67% correct, B from 34%
+const (
+	jlptGradeA = 67.0
+	jlptGradeB = 34.0
+)
+
+// jlptScoringSection is a section scores are reported for, with the
+// question skills it covers
+type jlptScoringSection struct {
+	key      string
+	name     string
+	maxScore int
+	passMark int // Sectional minimum
+	skills   []string
+}
+
+// jlptScoring is how a level is scored: its sections and the overall pass
+// mark out of 180
+type jlptScoring struct {
+	sections []jlptScoringSection
+	passMark int
+}
+
+var (
+	// N1-N3 score reading on its own
+	jlptThreeSections = []jlptScoringSection{
+		{key: models.JLPTScoreLanguage, name: "言語知識（文字・語彙・文法）", maxScore: 60, passMark: 19, skills: []string{"vocab", "kanji", "grammar"}},
+		{key: models.JLPTScoreReading, name: "読解", maxScore: 60, passMark: 19, skills: []string{"reading"}},
+		{key: models.JLPTScoreListening, name: "聴解", maxScore: 60, passMark: 19, skills: []string{"listening"}},
+	}
+	// N4 and N5 score it with language knowledge
+	jlptTwoSections = []jlptScoringSection{
+		{key: models.JLPTScoreLanguageReading, name: "言語知識（文字・語彙・文法）・読解", maxScore: 120, passMark: 38, skills: []string{"vocab", "kanji", "grammar", "reading"}},
+		{key: models.JLPTScoreListening, name: "聴解", maxScore: 60, passMark: 19, skills: []string{"listening"}},
+	}
+
+	jlptScoringByLevel = map[string]jlptScoring{
+		"N1": {sections: jlptThreeSections, passMark: 100},
+		"N2": {sections: jlptThreeSections, passMark: 90},
+		"N3": {sections: jlptThreeSections, passMark: 95},
+		"N4": {sections: jlptTwoSections, passMark: 90},
+		"N5": {sections: jlptTwoSections, passMark: 80},
+	}
+
+	// The reference information on the official report
+	jlptReferenceSkills = []struct {
+		name   string
+		skills []string
+	}{
+		{"文字・語彙", []string{"vocab", "kanji"}},
+		{"文法", []string{"grammar"}},
+	}
+)
+
+// scoringSectionFor finds the section a skill is scored in. Skills no
+// section lists count as language knowledge, the first section.
+func scoringSectionFor(sections []jlptScoringSection, skill string) int {
+	for i, sec := range sections {
+		for _, s := range sec.skills {
+			if s == skill {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// scoreJLPT works out a level's scaled scores from a session's answers. The
+// real exam equates raw scores across sittings; here a section's raw points
+// are scaled linearly to its maximum. Sections a test has no questions for
+// are left out, and the overall pass mark is scaled down to the sections
+// that were tested. It returns nil for a level without JLPT scoring.
+func scoreJLPT(level string, questions []models.JLPTQuestion, answers map[string]int) *models.JLPTScoreReport {
+	scoring, ok := jlptScoringByLevel[level]
+	if !ok {
+		return nil
+	}
+
+	report := &models.JLPTScoreReport{
+		Level:          level,
+		Sections:       make([]models.JLPTSectionScore, len(scoring.sections)),
+		FailedSections: []string{},
+		Reference:      []models.JLPTReferenceGrade{},
+	}
+	for i, sec := range scoring.sections {
+		report.Sections[i] = models.JLPTSectionScore{
+			Section:  sec.key,
+			Name:     sec.name,
+			Skills:   sec.skills,
+			MaxScore: sec.maxScore,
+			PassMark: sec.passMark,
+		}
+	}
+
+	skillCorrect := make(map[string]int)
+	skillTotal := make(map[string]int)
+	for _, q := range questions {
+		sec := &report.Sections[scoringSectionFor(scoring.sections, q.SkillTested)]
+		answer, answered := answers[q.ID]
+		correct := answered && answer == q.CorrectIndex
+
+		sec.Total++
+		sec.RawMax += q.PointValue
+		skillTotal[q.SkillTested]++
+		if correct {
+			sec.Correct++
+			sec.RawScore += q.PointValue
+			skillCorrect[q.SkillTested]++
+		}
+	}
+
+	for i := range report.Sections {
+		sec := &report.Sections[i]
+		if sec.Total == 0 {
+			continue
+		}
+		sec.Tested = true
+		if sec.RawMax > 0 {
+			sec.ScaledScore = int(math.Round(float64(sec.RawScore) / float64(sec.RawMax) * float64(sec.MaxScore)))
+		}
+		sec.Passed = sec.ScaledScore >= sec.PassMark
+		if !sec.Passed {
+			report.FailedSections = append(report.FailedSections, sec.Section)
+		}
+		report.TotalScore += sec.ScaledScore
+		report.MaxScore += sec.MaxScore
+	}
+
+	report.Partial = report.MaxScore < jlptMaxScore
+	report.PassMark = int(math.Ceil(float64(scoring.passMark) * float64(report.MaxScore) / jlptMaxScore))
+	report.Passed = report.MaxScore > 0 && report.TotalScore >= report.PassMark && len(report.FailedSections) == 0
+
+	for _, ref := range jlptReferenceSkills {
+		grade := models.JLPTReferenceGrade{Name: ref.name}
+		for _, skill := range ref.skills {
+			grade.Correct += skillCorrect[skill]
+			grade.Total += skillTotal[skill]
+		}
+		if grade.Total == 0 {
+			continue
+		}
+		switch pct := float64(grade.Correct) / float64(grade.Total) * 100; {
+		case pct >= jlptGradeA:
+			grade.Grade = "A"
+		case pct >= jlptGradeB:
+			grade.Grade = "B"
+		default:
+			grade.Grade = "C"
+		}
+		report.Reference = append(report.Reference, grade)
+	}
+	return report
+}
+
+// skillBreakdown scores a session's answers by the skill each question tests
+func skillBreakdown(questions []models.JLPTQuestion, answers map[string]int) map[string]models.SectionScore {
+	breakdown := make(map[string]models.SectionScore)
+	for _, q := range questions {
+		skill := q.SkillTested
+		if skill == "" {
+			skill = "other"
+		}
+		score := breakdown[skill]
+		score.Total++
+		if answer, ok := answers[q.ID]; ok && answer == q.CorrectIndex {
+			score.Correct++
+		}
+		breakdown[skill] = score
+	}
+	for skill, score := range breakdown {
+		score.Percentage = float64(score.Correct) / float64(score.Total) * 100
+		breakdown[skill] = score
+	}
+	return breakdown
+}
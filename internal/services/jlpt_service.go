@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -27,15 +28,16 @@ func (s *JLPTService) GetLevelInfo() []map[string]interface{} {
 	return models.GetJLPTLevelInfo()
 }
 
-// StartTest begins a new test session
-func (s *JLPTService) StartTest(userID string, level, section string) (*models.UserTestSession, []models.JLPTQuestion, error) {
+// StartTest begins a new test session. The clock starts now: the first
+// section's and the whole test's time limits run from here.
+func (s *JLPTService) StartTest(userID string, level, section string) (*models.UserTestSession, []models.JLPTQuestion, []models.JLPTTestSection, error) {
 	// Get available tests for level
 	tests, err := s.jlptRepo.GetTestsByLevel(level)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	if len(tests) == 0 {
-		return nil, nil, fmt.Errorf("no tests available for level %s", level)
+		return nil, nil, nil, fmt.Errorf("no tests available for level %s", level)
 	}
 
 	// Find test by section or use first available
@@ -53,42 +55,69 @@ func (s *JLPTService) StartTest(userID string, level, section string) (*models.U
 	// Get questions
 	questions, err := s.jlptRepo.GetQuestionsByTestID(selectedTest.ID)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+	sections, err := s.testSections(selectedTest)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	// Create session
+	now := time.Now()
 	session := &models.UserTestSession{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		TestID:    selectedTest.ID,
-		Level:     level,
-		StartedAt: time.Now(),
-		Status:    "in_progress",
-		Answers:   make(map[string]int),
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		TestID:         selectedTest.ID,
+		Level:          level,
+		StartedAt:      now,
+		Status:         models.TestSessionInProgress,
+		Answers:        make(map[string]int),
+		CurrentSection: sections[0].SectionNum,
 	}
+	scheduleSections(session, sections, now)
 
 	if err := s.jlptRepo.CreateTestSession(session); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	return session, questions, nil
+	return session, questions, sections, nil
 }
 
-// SubmitAnswer records an answer during a test
-func (s *JLPTService) SubmitAnswer(sessionID, questionID string, answerIndex int) error {
-	return s.jlptRepo.UpdateSessionAnswer(sessionID, questionID, answerIndex)
-}
+// SubmitAnswer records an answer during a test. Only questions in the
+// current section can be answered, and none once the time is up.
+func (s *JLPTService) SubmitAnswer(userID, sessionID, questionID string, answerIndex int) error {
+	session, _, sections, err := s.loadTestSession(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if err := checkTestOpen(session); err != nil {
+		return err
+	}
 
-// CompleteTest finishes a test and calculates results
-func (s *JLPTService) CompleteTest(sessionID string, finalAnswers map[string]int) (*models.TestResult, error) {
-	// Get session
-	session, err := s.jlptRepo.GetTestSession(sessionID)
+	questions, err := s.jlptRepo.GetQuestionsByTestID(session.TestID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	for _, q := range questions {
+		if q.ID != questionID {
+			continue
+		}
+		if err := checkSectionOpen(session, sections, q.SectionNum); err != nil {
+			return err
+		}
+		if answerIndex >= len(q.Options) {
+			return fmt.Errorf("answer_index must be below %d", len(q.Options))
+		}
+		return s.jlptRepo.UpdateSessionAnswer(sessionID, questionID, answerIndex)
 	}
+	return fmt.Errorf("question is not part of this test")
+}
 
-	// Get test and questions
-	test, err := s.jlptRepo.GetTestByID(session.TestID)
+// CompleteTest finishes a test and calculates results. Answers sent with
+// it are added to those recorded, for the current section only; a test the
+// server has already submitted gets its results as they were.
+func (s *JLPTService) CompleteTest(userID, sessionID string, finalAnswers map[string]int) (*models.TestResult, error) {
+	session, test, sections, err := s.loadTestSession(userID, sessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -98,13 +127,73 @@ func (s *JLPTService) CompleteTest(sessionID string, finalAnswers map[string]int
 		return nil, err
 	}
 
+	switch session.Status {
+	case models.TestSessionCompleted:
+		return buildTestResult(session, test, sections, questions), nil
+	case models.TestSessionInProgress:
+	default:
+		return nil, checkTestOpen(session)
+	}
+
+	if session.Answers == nil {
+		session.Answers = make(map[string]int)
+	}
+	for _, q := range questions {
+		answer, ok := finalAnswers[q.ID]
+		if ok && answer >= 0 && answer < len(q.Options) && q.SectionNum == session.CurrentSection {
+			session.Answers[q.ID] = answer
+		}
+	}
+	return s.finishTest(session, test, sections, questions, false)
+}
+
+// finishTest scores a session's recorded answers and marks it completed.
+// Time spent stops at the deadline for a test the server submits.
+func (s *JLPTService) finishTest(session *models.UserTestSession, test *models.JLPTTest, sections []models.JLPTTestSection, questions []models.JLPTQuestion, autoSubmitted bool) (*models.TestResult, error) {
+	end := time.Now()
+	if session.EndsAt != nil && end.After(*session.EndsAt) {
+		end = *session.EndsAt
+	}
+	session.TimeSpentSec = int(end.Sub(session.StartedAt).Seconds())
+	session.Status = models.TestSessionCompleted
+	session.AutoSubmitted = autoSubmitted
+
+	result := buildTestResult(session, test, sections, questions)
+	session.Score, session.CorrectCount = result.Score, result.CorrectCount
+	session.Passed = &result.Passed
+	if result.ScoreReport != nil {
+		session.ScaledScore = &result.ScoreReport.TotalScore
+	}
+
+	// Mark complete
+	if err := s.jlptRepo.CompleteTestSession(session); err != nil {
+		if !errors.Is(err, repository.ErrTestSessionClosed) {
+			return nil, err
+		}
+		// Submitted at the same moment by the expiry sweep or another
+		// request; its results stand
+		stored, err := s.jlptRepo.GetTestSession(session.ID)
+		if err != nil {
+			return nil, err
+		}
+		*session = *stored
+		if session.Status != models.TestSessionCompleted {
+			return nil, checkTestOpen(session)
+		}
+		return buildTestResult(session, test, sections, questions), nil
+	}
+	return result, nil
+}
+
+// buildTestResult scores a session's answers
+func buildTestResult(session *models.UserTestSession, test *models.JLPTTest, sections []models.JLPTTestSection, questions []models.JLPTQuestion) *models.TestResult {
 	// Calculate score
 	correctCount := 0
 	score := 0
 	reviewItems := []models.ReviewItem{}
 
 	for _, q := range questions {
-		userAnswer, hasAnswer := finalAnswers[q.ID]
+		userAnswer, hasAnswer := session.Answers[q.ID]
 		if !hasAnswer {
 			userAnswer = -1 // No answer
 		}
@@ -136,27 +225,25 @@ func (s *JLPTService) CompleteTest(sessionID string, finalAnswers map[string]int
 		}
 	}
 
-	// Calculate time spent
-	timeSpent := int(time.Since(session.StartedAt).Seconds())
-
-	// Mark complete
-	if err := s.jlptRepo.CompleteTestSession(sessionID, score, correctCount, timeSpent); err != nil {
-		return nil, err
-	}
-
 	// Build result
 	percentage := 0.0
 	if len(questions) > 0 {
 		percentage = float64(correctCount) / float64(len(questions)) * 100
 	}
 
+	// JLPT levels pass on the scaled score and every sectional minimum
 	passed := score >= test.PassingScore
+	report := scoreJLPT(session.Level, questions, session.Answers)
+	if report != nil {
+		passed = report.Passed
+	}
 
 	// Format time
+	timeSpent := session.TimeSpentSec
 	timeSpentStr := fmt.Sprintf("%d:%02d", timeSpent/60, timeSpent%60)
 
 	return &models.TestResult{
-		SessionID:       sessionID,
+		SessionID:       session.ID,
 		Level:           session.Level,
 		Score:           score,
 		TotalQuestions:  len(questions),
@@ -165,9 +252,13 @@ func (s *JLPTService) CompleteTest(sessionID string, finalAnswers map[string]int
 		Percentage:      percentage,
 		Passed:          passed,
 		TimeSpent:       timeSpentStr,
-		TimeLimit:       test.TimeLimit,
+		TimeLimit:       sectionsTimeLimit(sections),
 		ReviewQuestions: reviewItems,
-	}, nil
+		AutoSubmitted:   session.AutoSubmitted,
+
+		SectionBreakdown: skillBreakdown(questions, session.Answers),
+		ScoreReport:      report,
+	}
 }
 
 // GetUserHistory returns test history for a user
@@ -175,17 +266,18 @@ func (s *JLPTService) GetUserHistory(userID string) ([]models.UserTestSession, e
 	return s.jlptRepo.GetUserTestHistory(userID)
 }
 
-// GetTestProgress returns current progress for an active test
-func (s *JLPTService) GetTestProgress(sessionID string) (answeredCount, totalCount int, err error) {
-	session, err := s.jlptRepo.GetTestSession(sessionID)
+// GetTestProgress returns current progress for an active test, with the
+// time left in the current section and in the whole test
+func (s *JLPTService) GetTestProgress(userID, sessionID string) (*models.TestProgress, error) {
+	session, _, sections, err := s.loadTestSession(userID, sessionID)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
 
 	questions, err := s.jlptRepo.GetQuestionsByTestID(session.TestID)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
 
-	return len(session.Answers), len(questions), nil
+	return testProgress(session, sections, questions, time.Now()), nil
 }
\ No newline at end of file
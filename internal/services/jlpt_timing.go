@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// testSections returns a test's timed sections, or the whole test as a
+// single section when it isn't split
+func (s *JLPTService) testSections(test *models.JLPTTest) ([]models.JLPTTestSection, error) {
+	sections, err := s.jlptRepo.GetTestSections(test.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		sections = []models.JLPTTestSection{{TestID: test.ID, SectionNum: 1, Name: test.Section, TimeLimit: test.TimeLimit}}
+	}
+	return sections, nil
+}
+
+// sectionsTimeLimit is a test's whole time limit in minutes
+func sectionsTimeLimit(sections []models.JLPTTestSection) int {
+	total := 0
+	for _, sec := range sections {
+		total += sec.TimeLimit
+	}
+	return total
+}
+
+func sectionDuration(sec models.JLPTTestSection) time.Duration {
+	return time.Duration(sec.TimeLimit) * time.Minute
+}
+
+// sectionIndex finds a section by number; an unknown number is taken as the
+// first section
+func sectionIndex(sections []models.JLPTTestSection, num int) int {
+	for i, sec := range sections {
+		if sec.SectionNum == num {
+			return i
+		}
+	}
+	return 0
+}
+
+// scheduleSections brings a session's clock up to a moment. Each section
+// runs for its time limit from when the one before it ended, so a session
+// left alone moves on through sections that have run out since it was last
+// seen; it stays on the last one, which runs out with the whole test. It
+// reports whether anything changed.
+func scheduleSections(session *models.UserTestSession, sections []models.JLPTTestSection, now time.Time) bool {
+	i := sectionIndex(sections, session.CurrentSection)
+	start := session.StartedAt
+	if session.SectionStartedAt != nil {
+		start = *session.SectionStartedAt
+	}
+	for i < len(sections)-1 {
+		end := start.Add(sectionDuration(sections[i]))
+		if now.Before(end) {
+			break
+		}
+		start = end
+		i++
+	}
+
+	sectionEnds := start.Add(sectionDuration(sections[i]))
+	ends := sectionEnds
+	for _, sec := range sections[i+1:] {
+		ends = ends.Add(sectionDuration(sec))
+	}
+
+	changed := session.CurrentSection != sections[i].SectionNum ||
+		session.SectionStartedAt == nil || !session.SectionStartedAt.Equal(start) ||
+		session.SectionEndsAt == nil || !session.SectionEndsAt.Equal(sectionEnds) ||
+		session.EndsAt == nil || !session.EndsAt.Equal(ends)
+	session.CurrentSection = sections[i].SectionNum
+	session.SectionStartedAt = &start
+	session.SectionEndsAt = &sectionEnds
+	session.EndsAt = &ends
+	return changed
+}
+
+// settleTestSession brings a session in progress up to date: it moves on
+// to the section it should be on, or, once the time is up, is submitted
+// with the answers recorded so far
+func (s *JLPTService) settleTestSession(session *models.UserTestSession) (*models.JLPTTest, []models.JLPTTestSection, error) {
+	test, err := s.jlptRepo.GetTestByID(session.TestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	sections, err := s.testSections(test)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.Status != models.TestSessionInProgress {
+		return test, sections, nil
+	}
+
+	now := time.Now()
+	changed := scheduleSections(session, sections, now)
+	if now.Before(*session.EndsAt) {
+		if changed {
+			if err := s.jlptRepo.UpdateSessionSchedule(session); err != nil {
+				return nil, nil, err
+			}
+		}
+		return test, sections, nil
+	}
+
+	if err := s.jlptRepo.UpdateSessionSchedule(session); err != nil {
+		return nil, nil, err
+	}
+	questions, err := s.jlptRepo.GetQuestionsByTestID(session.TestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.finishTest(session, test, sections, questions, true); err != nil {
+		return nil, nil, err
+	}
+	return test, sections, nil
+}
+
+// loadTestSession gets one of a user's sessions by ID, settling it first
+func (s *JLPTService) loadTestSession(userID, sessionID string) (*models.UserTestSession, *models.JLPTTest, []models.JLPTTestSection, error) {
+	session, err := s.jlptRepo.GetTestSession(sessionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if session.UserID != userID {
+		return nil, nil, nil, fmt.Errorf("session not found")
+	}
+	test, sections, err := s.settleTestSession(session)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return session, test, sections, nil
+}
+
+// checkTestOpen explains why a session can't take answers, if it can't
+func checkTestOpen(session *models.UserTestSession) error {
+	switch {
+	case session.Status == models.TestSessionInProgress:
+		return nil
+	case session.AutoSubmitted:
+		return fmt.Errorf("time is up; the test was submitted with the answers recorded so far")
+	case session.Status == models.TestSessionCompleted:
+		return fmt.Errorf("test is already completed")
+	default:
+		return fmt.Errorf("test was abandoned")
+	}
+}
+
+// checkSectionOpen explains why a section's questions can't be answered,
+// if they can't: earlier sections are closed and later ones not yet begun
+func checkSectionOpen(session *models.UserTestSession, sections []models.JLPTTestSection, sectionNum int) error {
+	current := sectionIndex(sections, session.CurrentSection)
+	i := sectionIndex(sections, sectionNum)
+	switch {
+	case i < current:
+		return fmt.Errorf("section %d is over; its answers are final", sectionNum)
+	case i > current:
+		return fmt.Errorf("section %d hasn't started yet", sectionNum)
+	}
+	return nil
+}
+
+// NextSection ends the current section early and starts the next, whose
+// time limit runs from now. The answers of the section left are final.
+func (s *JLPTService) NextSection(userID, sessionID string) (*models.TestProgress, error) {
+	session, _, sections, err := s.loadTestSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTestOpen(session); err != nil {
+		return nil, err
+	}
+	i := sectionIndex(sections, session.CurrentSection)
+	if i == len(sections)-1 {
+		return nil, fmt.Errorf("this is the last section; complete the test to finish")
+	}
+
+	now := time.Now()
+	session.CurrentSection = sections[i+1].SectionNum
+	session.SectionStartedAt = &now
+	scheduleSections(session, sections, now)
+	if err := s.jlptRepo.UpdateSessionSchedule(session); err != nil {
+		return nil, err
+	}
+
+	questions, err := s.jlptRepo.GetQuestionsByTestID(session.TestID)
+	if err != nil {
+		return nil, err
+	}
+	return testProgress(session, sections, questions, now), nil
+}
+
+// ExpireSessions submits every test whose time has run out, so tests left
+// unfinished don't stay in progress. It returns how many it submitted; a
+// session that can't be settled doesn't hold up the rest, and the errors are
+// returned together.
+func (s *JLPTService) ExpireSessions() (int, error) {
+	ids, err := s.jlptRepo.GetDueTestSessionIDs(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	submitted := 0
+	var errs []error
+	for _, id := range ids {
+		session, err := s.jlptRepo.GetTestSession(id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to load session %s: %w", id, err))
+			continue
+		}
+		// Sessions started before deadlines were kept may still have time
+		if _, _, err := s.settleTestSession(session); err != nil {
+			errs = append(errs, fmt.Errorf("failed to settle session %s: %w", id, err))
+			continue
+		}
+		if session.Status == models.TestSessionCompleted {
+			submitted++
+		}
+	}
+	return submitted, errors.Join(errs...)
+}
+
+// testProgress summarises a session's answers and time left at a moment
+func testProgress(session *models.UserTestSession, sections []models.JLPTTestSection, questions []models.JLPTQuestion, now time.Time) *models.TestProgress {
+	progress := &models.TestProgress{
+		SessionID:      session.ID,
+		Status:         session.Status,
+		Answered:       len(session.Answers),
+		Total:          len(questions),
+		CurrentSection: session.CurrentSection,
+		AutoSubmitted:  session.AutoSubmitted,
+		Sections:       make([]models.TestSectionProgress, len(sections)),
+	}
+	progress.Remaining = progress.Total - progress.Answered
+
+	current := sectionIndex(sections, session.CurrentSection)
+	for i, sec := range sections {
+		status := models.TestSectionUpcoming
+		switch {
+		case session.Status != models.TestSessionInProgress || i < current:
+			status = models.TestSectionDone
+		case i == current:
+			status = models.TestSectionCurrent
+		}
+		progress.Sections[i] = models.TestSectionProgress{
+			SectionNum: sec.SectionNum,
+			Name:       sec.Name,
+			TimeLimit:  sec.TimeLimit,
+			Status:     status,
+		}
+	}
+	for _, q := range questions {
+		sec := &progress.Sections[sectionIndex(sections, q.SectionNum)]
+		sec.Total++
+		if _, ok := session.Answers[q.ID]; ok {
+			sec.Answered++
+		}
+	}
+
+	if session.Status == models.TestSessionInProgress {
+		progress.EndsAt, progress.SectionEndsAt = session.EndsAt, session.SectionEndsAt
+		progress.RemainingSec = max(0, int(session.EndsAt.Sub(now).Seconds()))
+		progress.SectionRemainingSec = max(0, int(session.SectionEndsAt.Sub(now).Seconds()))
+	}
+	return progress
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// containsKanji reports whether s has at least one Han character
+func containsKanji(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// vocabForms returns the written (kanji) form and the kana reading of a word.
+// Seed data isn't consistent about which of word/reading holds the kanji, so
+// pick whichever field actually contains it.
+func vocabForms(v *models.Vocabulary) (written, kana string) {
+	switch {
+	case containsKanji(v.Reading) && !containsKanji(v.Word):
+		return v.Reading, v.Word
+	case containsKanji(v.Word) && !containsKanji(v.Reading):
+		return v.Word, v.Reading
+	case v.Reading == "":
+		return v.Word, v.Word
+	default:
+		return v.Word, v.Reading
+	}
+}
+
+// kanaEditDistance is the Levenshtein distance between two strings, in runes
+func kanaEditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// romajiKana maps romaji syllables to hiragana; longer spellings are tried first
+var romajiKana = map[string]string{
+	"a": "あ", "i": "い", "u": "う", "e": "え", "o": "お",
+	"ka": "か", "ki": "き", "ku": "く", "ke": "け", "ko": "こ",
+	"ga": "が", "gi": "ぎ", "gu": "ぐ", "ge": "げ", "go": "ご",
+	"sa": "さ", "si": "し", "shi": "し", "su": "す", "se": "せ", "so": "そ",
+	"za": "ざ", "zi": "じ", "ji": "じ", "zu": "ず", "ze": "ぜ", "zo": "ぞ",
+	"ta": "た", "ti": "ち", "chi": "ち", "tu": "つ", "tsu": "つ", "te": "て", "to": "と",
+	"da": "だ", "di": "ぢ", "du": "づ", "de": "で", "do": "ど",
+	"na": "な", "ni": "に", "nu": "ぬ", "ne": "ね", "no": "の",
+	"ha": "は", "hi": "ひ", "hu": "ふ", "fu": "ふ", "he": "へ", "ho": "ほ",
+	"ba": "ば", "bi": "び", "bu": "ぶ", "be": "べ", "bo": "ぼ",
+	"pa": "ぱ", "pi": "ぴ", "pu": "ぷ", "pe": "ぺ", "po": "ぽ",
+	"ma": "ま", "mi": "み", "mu": "む", "me": "め", "mo": "も",
+	"ya": "や", "yu": "ゆ", "yo": "よ",
+	"ra": "ら", "ri": "り", "ru": "る", "re": "れ", "ro": "ろ",
+	"wa": "わ", "wo": "を", "nn": "ん", "n'": "ん",
+	"kya": "きゃ", "kyu": "きゅ", "kyo": "きょ", "gya": "ぎゃ", "gyu": "ぎゅ", "gyo": "ぎょ",
+	"sha": "しゃ", "shu": "しゅ", "sho": "しょ", "sya": "しゃ", "syu": "しゅ", "syo": "しょ",
+	"ja": "じゃ", "ju": "じゅ", "jo": "じょ", "jya": "じゃ", "jyu": "じゅ", "jyo": "じょ",
+	"cha": "ちゃ", "chu": "ちゅ", "cho": "ちょ", "tya": "ちゃ", "tyu": "ちゅ", "tyo": "ちょ",
+	"nya": "にゃ", "nyu": "にゅ", "nyo": "にょ", "hya": "ひゃ", "hyu": "ひゅ", "hyo": "ひょ",
+	"bya": "びゃ", "byu": "びゅ", "byo": "びょ", "pya": "ぴゃ", "pyu": "ぴゅ", "pyo": "ぴょ",
+	"mya": "みゃ", "myu": "みゅ", "myo": "みょ", "rya": "りゃ", "ryu": "りゅ", "ryo": "りょ",
+	"-": "ー",
+}
+
+// romajiToHiragana converts Hepburn or kunrei romaji to hiragana. Doubled
+// consonants become っ and a lone n becomes ん. ok is false when some letters
+// don't spell kana.
+func romajiToHiragana(s string) (kana string, ok bool) {
+	s = strings.ToLower(s)
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < 'a' || c > 'z' {
+			if c == '-' {
+				b.WriteString("ー")
+				i++
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteRune(r)
+			i += size
+			continue
+		}
+		// kk, tt, tch → っ
+		if i+1 < len(s) && !strings.ContainsRune("aeioun", rune(c)) &&
+			(s[i+1] == c || (c == 't' && s[i+1] == 'c')) {
+			b.WriteString("っ")
+			i++
+			continue
+		}
+		matched := false
+		for size := 3; size >= 1; size-- {
+			if i+size > len(s) {
+				continue
+			}
+			if k, found := romajiKana[s[i:i+size]]; found {
+				b.WriteString(k)
+				i += size
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		// n before a consonant or at the end
+		if c == 'n' {
+			b.WriteString("ん")
+			i++
+			continue
+		}
+		return "", false
+	}
+	return b.String(), true
+}
+
+// katakanaToHiragana folds katakana into hiragana so either script compares equal
+func katakanaToHiragana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ァ' && r <= 'ヶ' {
+			return r - 0x60
+		}
+		return r
+	}, s)
+}
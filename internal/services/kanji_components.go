@@ -0,0 +1,177 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+const (
+	componentSearchDefaultLimit = 50
+	componentSearchMaxLimit     = 200
+	componentTreeMaxDepth       = 3 // KRADFILE's components are small; deeper trees only repeat strokes
+)
+
+// GetRadicals lists the radicals kanji can be searched by, by stroke count
+func (s *KanjiService) GetRadicals() ([]models.Radical, error) {
+	return s.kanjiRepo.GetRadicals()
+}
+
+// SearchByComponents finds the kanji containing all of a set of components,
+// within a stroke count range when one is given
+func (s *KanjiService) SearchByComponents(components []string, minStrokes, maxStrokes, limit int) (*models.KanjiComponentSearch, error) {
+	if len(components) == 0 && minStrokes <= 0 && maxStrokes <= 0 {
+		return nil, fmt.Errorf("give at least one component or a stroke count")
+	}
+	if limit <= 0 {
+		limit = componentSearchDefaultLimit
+	}
+	limit = min(limit, componentSearchMaxLimit)
+
+	kanji, total, narrowing, err := s.kanjiRepo.SearchKanjiByComponents(components, minStrokes, maxStrokes, limit)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := s.componentMatches(kanji, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Components already chosen don't narrow the search any further
+	chosen := make(map[string]bool, len(components))
+	for _, c := range components {
+		chosen[c] = true
+	}
+	result := &models.KanjiComponentSearch{
+		Components: components,
+		Kanji:      matches,
+		TotalCount: total,
+		Narrowing:  []string{},
+	}
+	for _, c := range narrowing {
+		if !chosen[c] {
+			result.Narrowing = append(result.Narrowing, c)
+		}
+	}
+	return result, nil
+}
+
+// GetRelatedKanji lists kanji sharing components with a kanji, or sharing
+// one given component of it
+func (s *KanjiService) GetRelatedKanji(char, component string, limit int) ([]models.KanjiComponentMatch, error) {
+	if limit <= 0 {
+		limit = componentSearchDefaultLimit
+	}
+	limit = min(limit, componentSearchMaxLimit)
+
+	own, err := s.kanjiRepo.GetComponents([]string{char})
+	if err != nil {
+		return nil, err
+	}
+	if len(own[char]) == 0 {
+		return nil, fmt.Errorf("no components for %s", char)
+	}
+
+	kanji, err := s.kanjiRepo.GetKanjiSharingComponents(char, component, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.componentMatches(kanji, own[char])
+}
+
+// componentMatches adds each kanji's components, and those it has in common
+// with a kanji being studied when there is one
+func (s *KanjiService) componentMatches(kanji []*models.Kanji, studied []string) ([]models.KanjiComponentMatch, error) {
+	chars := make([]string, len(kanji))
+	for i, k := range kanji {
+		chars[i] = k.Character
+	}
+	components, err := s.kanjiRepo.GetComponents(chars)
+	if err != nil {
+		return nil, err
+	}
+
+	inStudied := make(map[string]bool, len(studied))
+	for _, c := range studied {
+		inStudied[c] = true
+	}
+	matches := make([]models.KanjiComponentMatch, 0, len(kanji))
+	for _, k := range kanji {
+		match := models.KanjiComponentMatch{
+			Character:   k.Character,
+			Meaning:     k.Meaning,
+			Readings:    k.Readings,
+			JLPTLevel:   k.JLPTLevel,
+			StrokeCount: k.StrokeCount,
+			Components:  components[k.Character],
+		}
+		if match.Components == nil {
+			match.Components = []string{}
+		}
+		for _, c := range match.Components {
+			if inStudied[c] {
+				match.Shared = append(match.Shared, c)
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// GetComponentTree breaks a kanji into its components, and each component
+// that has components of its own into those
+func (s *KanjiService) GetComponentTree(char string) (*models.KanjiComponentNode, error) {
+	radicals, err := s.kanjiRepo.GetRadicals()
+	if err != nil {
+		return nil, err
+	}
+	radicalStrokes := make(map[string]int, len(radicals))
+	for _, rad := range radicals {
+		radicalStrokes[rad.Character] = rad.StrokeCount
+	}
+
+	var build func(char string, depth int, path map[string]bool) (*models.KanjiComponentNode, error)
+	build = func(char string, depth int, path map[string]bool) (*models.KanjiComponentNode, error) {
+		strokes, isRadical := radicalStrokes[char]
+		node := &models.KanjiComponentNode{
+			Character:   char,
+			StrokeCount: strokes,
+			IsRadical:   isRadical,
+			Components:  []*models.KanjiComponentNode{},
+		}
+		if k, err := s.kanjiRepo.GetKanjiByCharacter(char); err == nil {
+			node.Meaning, node.StrokeCount = k.Meaning, k.StrokeCount
+		}
+		if depth >= componentTreeMaxDepth {
+			return node, nil
+		}
+
+		components, err := s.kanjiRepo.GetComponents([]string{char})
+		if err != nil {
+			return nil, err
+		}
+		path[char] = true
+		defer delete(path, char)
+		for _, c := range components[char] {
+			// A radical lists itself among its components
+			if path[c] {
+				continue
+			}
+			child, err := build(c, depth+1, path)
+			if err != nil {
+				return nil, err
+			}
+			node.Components = append(node.Components, child)
+		}
+		return node, nil
+	}
+
+	components, err := s.kanjiRepo.GetComponents([]string{char})
+	if err != nil {
+		return nil, err
+	}
+	if len(components[char]) == 0 {
+		return nil, fmt.Errorf("no components for %s", char)
+	}
+	return build(char, 0, map[string]bool{})
+}
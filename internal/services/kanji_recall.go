@@ -0,0 +1,312 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	recallStrokeMatchMin = 30.0 // A drawn stroke matching no reference stroke at least this well is left unmatched
+	recallNeatAccuracy   = 60.0 // Mean stroke accuracy below this costs a point of SRS quality
+	recallHiddenKanji    = "＿"  // Stands in for the kanji in a vocabulary prompt
+)
+
+// GetWriteQuiz starts a write-from-memory session for a kanji, or for a
+// random kanji of a level. The prompt shows a vocabulary word using the
+// kanji (with the kanji hidden) or its meaning, never the kanji itself.
+func (s *KanjiService) GetWriteQuiz(userID, kanjiChar, level, promptType string) (*models.KanjiWriteQuiz, error) {
+	var kanji *models.Kanji
+	var err error
+	if kanjiChar != "" {
+		kanji, err = s.kanjiRepo.GetKanjiByCharacter(kanjiChar)
+	} else {
+		kanji, err = s.kanjiRepo.GetRandomKanji(level)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := s.recallPrompt(kanji, promptType)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.KanjiPracticeSession{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		KanjiID:     kanji.ID,
+		KanjiChar:   kanji.Character,
+		StartedAt:   time.Now(),
+		Status:      "in_progress",
+		UserStrokes: []models.UserStroke{},
+		Mode:        models.KanjiModeRecall,
+		Prompt:      prompt,
+	}
+	if err := s.kanjiRepo.CreatePracticeSession(session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return &models.KanjiWriteQuiz{
+		SessionID: session.ID,
+		Prompt:    prompt,
+		JLPTLevel: kanji.JLPTLevel,
+	}, nil
+}
+
+// recallPrompt builds the prompt for a kanji. A vocabulary prompt falls back
+// to the meaning when no word in the vocabulary uses the kanji.
+func (s *KanjiService) recallPrompt(kanji *models.Kanji, promptType string) (*models.KanjiRecallPrompt, error) {
+	prompt := &models.KanjiRecallPrompt{
+		Type:     models.KanjiPromptMeaning,
+		Meaning:  kanji.Meaning,
+		Readings: kanji.Readings,
+	}
+	switch promptType {
+	case models.KanjiPromptMeaning:
+		return prompt, nil
+	case "", models.KanjiPromptVocabulary:
+	default:
+		return nil, fmt.Errorf("unknown prompt type: %s", promptType)
+	}
+
+	words, err := s.vocabRepo.Search(kanji.Character, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range words {
+		if w.Word == kanji.Character || !strings.Contains(w.Word, kanji.Character) {
+			continue
+		}
+		prompt.Type = models.KanjiPromptVocabulary
+		prompt.Word = strings.ReplaceAll(w.Word, kanji.Character, recallHiddenKanji)
+		prompt.WordReading = w.Reading
+		prompt.WordMeaning = w.ShortMeaning
+		break
+	}
+	return prompt, nil
+}
+
+// CheckWriteQuiz grades a whole character written from memory - the right
+// kanji, the right number of strokes, in the right order - and feeds the
+// grade into the kanji's SRS schedule
+func (s *KanjiService) CheckWriteQuiz(userID, sessionID string, strokes [][]models.Point) (*models.KanjiRecallResult, error) {
+	session, err := s.kanjiRepo.GetPracticeSession(sessionID)
+	if err != nil || session.UserID != userID {
+		return nil, fmt.Errorf("session not found")
+	}
+	if session.Mode != models.KanjiModeRecall {
+		return nil, fmt.Errorf("session is not a write-from-memory quiz")
+	}
+	if session.Status == "completed" {
+		return nil, fmt.Errorf("session is already graded")
+	}
+
+	kanji, err := s.kanjiRepo.GetKanjiByID(session.KanjiID)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := s.rankKanji(strokes)
+	if err != nil {
+		return nil, err
+	}
+
+	result := gradeRecall(kanji, strokes, candidates)
+	result.Quality = recallQuality(result)
+	result.Feedback = recallFeedback(result)
+
+	review, err := s.srsService.SubmitReview(userID, &models.SRSReviewRequest{
+		ItemID:         kanji.ID,
+		ItemType:       "kanji",
+		Quality:        result.Quality,
+		ResponseTimeMs: int(time.Since(session.StartedAt).Milliseconds()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update SRS schedule: %w", err)
+	}
+	result.NextReview = review.NextReview
+
+	now := time.Now()
+	for i, path := range strokes {
+		session.UserStrokes = append(session.UserStrokes, models.UserStroke{StrokeNum: i + 1, Path: path, Timestamp: now})
+	}
+	session.Accuracy = result.Accuracy
+	session.Status = "completed"
+	session.CompletedAt = &now
+	session.RecallResult = result
+	session.SRSQuality = &result.Quality
+	if err := s.kanjiRepo.UpdatePracticeSession(session); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+	return result, nil
+}
+
+// gradeRecall checks a character written from memory against its kanji.
+// Each drawn stroke is paired with the reference stroke it matches best, so a
+// stroke drawn out of turn is still recognised as that stroke.
+func gradeRecall(kanji *models.Kanji, strokes [][]models.Point, candidates []models.KanjiCandidate) *models.KanjiRecallResult {
+	result := &models.KanjiRecallResult{
+		Character:    kanji.Character,
+		StrokeCount:  kanji.StrokeCount,
+		StrokesDrawn: len(strokes),
+		Candidates:   candidates[:min(writeQuizCandidates, len(candidates))],
+	}
+	result.StrokeCountCorrect = result.StrokesDrawn == result.StrokeCount
+	if len(candidates) > 0 {
+		result.RecognizedAs = candidates[0].Character
+	}
+	for i, c := range candidates {
+		if c.Character == kanji.Character {
+			result.Rank = i + 1
+			break
+		}
+	}
+	result.Correct = result.Rank == 1
+
+	refPaths := referenceStrokes(kanji)
+	drawn := fitToReference(strokes, refPaths)
+
+	type pair struct {
+		drawn, ref int
+		match      strokeMatch
+	}
+	var pairs []pair
+	for i := range drawn {
+		for j := range refPaths {
+			if m := matchStroke(refPaths[j], drawn[i]); m.Accuracy >= recallStrokeMatchMin {
+				pairs = append(pairs, pair{i, j, m})
+			}
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool { return pairs[a].match.Accuracy > pairs[b].match.Accuracy })
+
+	usedDrawn, usedRef := make([]bool, len(drawn)), make([]bool, len(refPaths))
+	var matched []pair
+	total := 0.0
+	for _, p := range pairs {
+		if usedDrawn[p.drawn] || usedRef[p.ref] {
+			continue
+		}
+		usedDrawn[p.drawn], usedRef[p.ref] = true, true
+		matched = append(matched, p)
+		total += p.match.Accuracy
+		if p.match.Reversed {
+			result.ReversedStrokes++
+		}
+	}
+	if n := max(len(drawn), len(refPaths)); n > 0 {
+		result.Accuracy = math.Round(total/float64(n)*10) / 10
+	}
+
+	// Strokes in the right order form an increasing run of reference strokes;
+	// the rest were drawn out of turn. Missing or extra strokes don't count.
+	sort.Slice(matched, func(a, b int) bool { return matched[a].drawn < matched[b].drawn })
+	refOrder := make([]int, len(matched))
+	for i, p := range matched {
+		refOrder[i] = p.ref
+	}
+	result.OrderErrors = len(refOrder) - longestIncreasingRun(refOrder)
+	result.OrderCorrect = result.OrderErrors == 0
+	return result
+}
+
+// longestIncreasingRun is the length of the longest increasing subsequence
+func longestIncreasingRun(seq []int) int {
+	var tails []int
+	for _, v := range seq {
+		i := sort.SearchInts(tails, v)
+		if i == len(tails) {
+			tails = append(tails, v)
+		} else {
+			tails[i] = v
+		}
+	}
+	return len(tails)
+}
+
+// fitToReference moves and scales a drawing onto the reference character's
+// box, since without a guide it can be written anywhere at any size
+func fitToReference(strokes, ref [][]models.Point) [][]models.Point {
+	box := func(paths [][]models.Point) (cx, cy, size float64) {
+		minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for _, path := range paths {
+			for _, p := range path {
+				minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+				minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+			}
+		}
+		return (minX + maxX) / 2, (minY + maxY) / 2, math.Max(maxX-minX, maxY-minY)
+	}
+	dx, dy, dSize := box(strokes)
+	rx, ry, rSize := box(ref)
+	scale := 1.0
+	if dSize > 1e-9 && rSize > 1e-9 {
+		scale = rSize / dSize
+	}
+
+	fitted := make([][]models.Point, 0, len(strokes))
+	for _, path := range strokes {
+		out := make([]models.Point, len(path))
+		for i, p := range path {
+			out[i] = models.Point{X: rx + (p.X-dx)*scale, Y: ry + (p.Y-dy)*scale}
+		}
+		fitted = append(fitted, out)
+	}
+	return fitted
+}
+
+// recallQuality turns a graded character into an SM-2 quality. Writing the
+// right kanji is a pass (3 or more), losing a point each for the stroke
+// count, the stroke order or direction, and a messy hand; a wrong kanji is a
+// fail, graded by how close the right one came.
+func recallQuality(r *models.KanjiRecallResult) int {
+	if !r.Correct {
+		switch {
+		case r.Rank > 0 && r.Rank <= writeQuizCloseRank:
+			return 2
+		case r.Rank > 0:
+			return 1
+		default:
+			return 0
+		}
+	}
+	quality := 5
+	if !r.StrokeCountCorrect {
+		quality--
+	}
+	if !r.OrderCorrect || r.ReversedStrokes > 0 {
+		quality--
+	}
+	if r.Accuracy < recallNeatAccuracy {
+		quality--
+	}
+	return max(quality, 3)
+}
+
+func recallFeedback(r *models.KanjiRecallResult) string {
+	if !r.Correct {
+		if r.RecognizedAs != "" && r.Rank > 0 && r.Rank <= writeQuizCloseRank {
+			return fmt.Sprintf("Close - that reads as %s. The answer was %s.", r.RecognizedAs, r.Character)
+		}
+		return fmt.Sprintf("Not quite: the answer was %s.", r.Character)
+	}
+
+	var notes []string
+	if !r.StrokeCountCorrect {
+		notes = append(notes, fmt.Sprintf("%s has %d strokes, you drew %d", r.Character, r.StrokeCount, r.StrokesDrawn))
+	}
+	if !r.OrderCorrect {
+		notes = append(notes, fmt.Sprintf("%d stroke(s) were out of order", r.OrderErrors))
+	}
+	if r.ReversedStrokes > 0 {
+		notes = append(notes, fmt.Sprintf("%d stroke(s) were drawn backwards", r.ReversedStrokes))
+	}
+	if len(notes) == 0 {
+		return fmt.Sprintf("Correct! %s, written in the right order.", r.Character)
+	}
+	return fmt.Sprintf("Correct, it's %s - but %s.", r.Character, strings.Join(notes, "; "))
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+const (
+	recognizeSamplePoints    = 16   // Points per stroke; fewer than matchStroke since every candidate is scored
+	recognizeMaxStrokeDiff   = 2    // Characters with up to this many strokes more or fewer are considered
+	recognizeShapeTolerance  = 30.0 // Mean distance (normalised canvas) at which the shape score reaches 0
+	recognizeReversedPenalty = 5.0  // Added to the distance of a stroke matched back to front
+	recognizeDefaultLimit    = 10
+	recognizeMaxLimit        = 50
+
+	writeQuizCandidates = 5 // Candidates shown with a quiz result
+	writeQuizCloseRank  = 3 // Ranked this high, the answer was nearly right
+)
+
+// recognizerStroke is a stroke prepared for recognition
+type recognizerStroke struct {
+	points    []models.Point
+	direction string
+}
+
+// prepareCharacter scales a whole character to fill the canvas, so a kanji
+// written small in a corner is compared the same as the reference, and
+// resamples each stroke
+func prepareCharacter(strokes [][]models.Point) []recognizerStroke {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, stroke := range strokes {
+		for _, p := range stroke {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		}
+	}
+	scale := math.Max(maxX-minX, maxY-minY)
+	if scale < 1e-9 {
+		scale = 1
+	}
+	cx, cy := (minX+maxX)/2, (minY+maxY)/2
+
+	prepared := make([]recognizerStroke, 0, len(strokes))
+	for _, stroke := range strokes {
+		if len(stroke) == 0 {
+			continue
+		}
+		points := make([]models.Point, len(stroke))
+		for i, p := range stroke {
+			points[i] = models.Point{X: 50 + (p.X-cx)/scale*100, Y: 50 + (p.Y-cy)/scale*100}
+		}
+		prepared = append(prepared, recognizerStroke{
+			points:    resampleStroke(points, recognizeSamplePoints),
+			direction: StrokeDirection(stroke),
+		})
+	}
+	return prepared
+}
+
+// referenceStrokes gets the paths of a kanji's reference strokes in order
+func referenceStrokes(kanji *models.Kanji) [][]models.Point {
+	paths := make([][]models.Point, 0, len(kanji.StrokeOrder))
+	for i := range kanji.StrokeOrder {
+		paths = append(paths, strokeReferencePath(&kanji.StrokeOrder[i]))
+	}
+	return paths
+}
+
+// meanPointDistance is the mean distance between points at the same place
+// along two resampled strokes
+func meanPointDistance(a, b []models.Point) float64 {
+	total := 0.0
+	for i := range a {
+		total += distance(a[i], b[i])
+	}
+	return total / float64(len(a))
+}
+
+// scoreCandidate compares a drawing with a reference character. Strokes are
+// paired by closeness rather than by position in the sequence, and a stroke
+// may be matched back to front, so order and direction mistakes cost little;
+// extra or missing strokes cost a full stroke's worth of distance each.
+func scoreCandidate(drawn, ref []recognizerStroke) (shape, direction, count float64) {
+	n, m := len(drawn), len(ref)
+	if n == 0 || m == 0 {
+		return 0, 0, 0
+	}
+	count = float64(min(n, m)) / float64(max(n, m)) * 100
+
+	drawnDirections := make([]string, n)
+	for i, s := range drawn {
+		drawnDirections[i] = s.direction
+	}
+	refDirections := make([]string, m)
+	for i, s := range ref {
+		refDirections[i] = s.direction
+	}
+	direction = (1 - float64(editDistance(drawnDirections, refDirections))/float64(max(n, m))) * 100
+
+	type pair struct {
+		i, j int
+		cost float64
+	}
+	pairs := make([]pair, 0, n*m)
+	for i := range drawn {
+		reversed := reversePoints(drawn[i].points)
+		for j := range ref {
+			cost := meanPointDistance(drawn[i].points, ref[j].points)
+			cost = math.Min(cost, meanPointDistance(reversed, ref[j].points)+recognizeReversedPenalty)
+			pairs = append(pairs, pair{i, j, cost})
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool { return pairs[a].cost < pairs[b].cost })
+
+	usedDrawn, usedRef := make([]bool, n), make([]bool, m)
+	total, matched := 0.0, 0
+	for _, p := range pairs {
+		if usedDrawn[p.i] || usedRef[p.j] {
+			continue
+		}
+		usedDrawn[p.i], usedRef[p.j] = true, true
+		total += p.cost
+		matched++
+	}
+	total += float64(max(n, m)-matched) * recognizeShapeTolerance
+	shape = toleranceScore(total/float64(max(n, m)), recognizeShapeTolerance)
+	return shape, direction, count
+}
+
+// editDistance is the Levenshtein distance between two sequences
+func editDistance(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// rankKanji scores every kanji with a stroke count near the drawing's and
+// returns them best first
+func (s *KanjiService) rankKanji(strokes [][]models.Point) ([]models.KanjiCandidate, error) {
+	drawn := prepareCharacter(strokes)
+	if len(drawn) == 0 {
+		return nil, fmt.Errorf("no strokes to recognise")
+	}
+
+	pool, err := s.kanjiRepo.GetKanjiByStrokeCount(max(len(drawn)-recognizeMaxStrokeDiff, 1), len(drawn)+recognizeMaxStrokeDiff)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]models.KanjiCandidate, 0, len(pool))
+	for _, k := range pool {
+		ref := prepareCharacter(referenceStrokes(k))
+		// Hand-entered strokes carry their own direction labels
+		for i := range ref {
+			if i < len(k.StrokeOrder) && k.StrokeOrder[i].Direction != "" {
+				ref[i].direction = k.StrokeOrder[i].Direction
+			}
+		}
+		shape, direction, count := scoreCandidate(drawn, ref)
+		candidates = append(candidates, models.KanjiCandidate{
+			Character:        k.Character,
+			Meaning:          k.Meaning,
+			Readings:         k.Readings,
+			JLPTLevel:        k.JLPTLevel,
+			StrokeCount:      k.StrokeCount,
+			Score:            math.Round((shape*0.6+direction*0.25+count*0.15)*10) / 10,
+			ShapeScore:       math.Round(shape*10) / 10,
+			DirectionScore:   math.Round(direction*10) / 10,
+			StrokeCountScore: math.Round(count*10) / 10,
+		})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, nil
+}
+
+// RecognizeKanji ranks the kanji a set of drawn strokes could be, so a
+// character that can be seen but not typed can be looked up
+func (s *KanjiService) RecognizeKanji(strokes [][]models.Point, limit int) (*models.KanjiRecognizeResult, error) {
+	if limit <= 0 {
+		limit = recognizeDefaultLimit
+	}
+	limit = min(limit, recognizeMaxLimit)
+
+	candidates, err := s.rankKanji(strokes)
+	if err != nil {
+		return nil, err
+	}
+	return &models.KanjiRecognizeResult{
+		StrokeCount: len(prepareCharacter(strokes)),
+		Candidates:  candidates[:min(limit, len(candidates))],
+	}, nil
+}
@@ -2,7 +2,6 @@ package services
 
 import (
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/erwinwahyura/daily-kotoba/internal/models"
@@ -12,13 +11,19 @@ import (
 
 // KanjiService handles kanji writing practice business logic
 type KanjiService struct {
-	kanjiRepo *repository.KanjiRepository
+	kanjiRepo  *repository.KanjiRepository
+	vocabRepo  *repository.VocabRepository
+	userRepo   *repository.UserRepository
+	srsService *SRSService
 }
 
 // NewKanjiService creates a new service
-func NewKanjiService(kanjiRepo *repository.KanjiRepository) *KanjiService {
+func NewKanjiService(kanjiRepo *repository.KanjiRepository, vocabRepo *repository.VocabRepository, userRepo *repository.UserRepository, srsService *SRSService) *KanjiService {
 	return &KanjiService{
-		kanjiRepo: kanjiRepo,
+		kanjiRepo:  kanjiRepo,
+		vocabRepo:  vocabRepo,
+		userRepo:   userRepo,
+		srsService: srsService,
 	}
 }
 
@@ -58,6 +63,7 @@ func (s *KanjiService) StartPracticeSession(userID, kanjiChar string) (*models.K
 		Status:    "in_progress",
 		Accuracy:  0,
 		UserStrokes: []models.UserStroke{},
+		Mode:      models.KanjiModeTrace,
 	}
 
 	if err := s.kanjiRepo.CreatePracticeSession(session); err != nil {
@@ -67,12 +73,21 @@ func (s *KanjiService) StartPracticeSession(userID, kanjiChar string) (*models.K
 	return session, nil
 }
 
-// CompareStroke compares user's stroke with reference and returns accuracy
-func (s *KanjiService) CompareStroke(sessionID string, strokeNum int, userPath []models.Point) (*models.KanjiCompareResult, error) {
+// CompareStroke works out which stroke of the kanji a path was drawn as,
+// scores it against that stroke and classifies its errors. The stroke a
+// client thinks is being drawn isn't trusted: a stroke drawn out of turn is
+// graded as the stroke it is and flagged as out of order.
+func (s *KanjiService) CompareStroke(userID, sessionID string, userPath []models.Point) (*models.KanjiCompareResult, error) {
 	// Get session
 	session, err := s.kanjiRepo.GetPracticeSession(sessionID)
-	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
+	if err != nil || session.UserID != userID {
+		return nil, fmt.Errorf("session not found")
+	}
+	if session.Mode == models.KanjiModeRecall {
+		return nil, fmt.Errorf("recall sessions are graded as a whole character")
+	}
+	if session.Status == "completed" {
+		return nil, fmt.Errorf("session is already completed")
 	}
 
 	// Get kanji reference
@@ -80,59 +95,86 @@ func (s *KanjiService) CompareStroke(sessionID string, strokeNum int, userPath [
 	if err != nil {
 		return nil, fmt.Errorf("kanji not found: %w", err)
 	}
+	refPaths := referenceStrokes(kanji)
+	if len(refPaths) == 0 {
+		return nil, fmt.Errorf("kanji %s has no stroke data", kanji.Character)
+	}
 
-	// Find reference stroke
-	var refStroke *models.Stroke
-	for _, s := range kanji.StrokeOrder {
-		if s.StrokeNum == strokeNum {
-			refStroke = &s
+	// The stroke that was due is the first one not drawn yet
+	expected := 0
+	for i, covered := range coveredStrokes(session.UserStrokes, len(refPaths)) {
+		if !covered {
+			expected = i + 1
 			break
 		}
 	}
 
-	if refStroke == nil {
-		return nil, fmt.Errorf("stroke %d not found for kanji %s", strokeNum, kanji.Character)
+	inferred := inferStroke(refPaths, session.UserStrokes, userPath)
+	if inferred.continues {
+		expected = inferred.refs[0]
 	}
-
-	// Check if correct stroke order
-	orderCorrect := strokeNum == len(session.UserStrokes)+1
-
-	// Calculate accuracy using path comparison
-	accuracy := s.calculateStrokeAccuracy(refStroke, userPath)
+	match := inferred.match
+	accuracy := match.Accuracy
+	errors := classifyStroke(inferred, expected, refPaths)
 
 	// Generate feedback
-	feedback := s.generateFeedback(accuracy, refStroke.Direction)
+	direction := ""
+	if len(inferred.refs) > 0 {
+		direction = kanji.StrokeOrder[inferred.refs[0]-1].Direction
+	}
+	feedback := s.generateFeedback(accuracy, direction)
+	if len(inferred.refs) == 0 {
+		feedback = "That stroke doesn't match any stroke of " + kanji.Character + "."
+	} else if len(errors) > 0 {
+		feedback += " " + errors[0].Message
+	} else if note := strokeMatchFeedback(match); note != "" {
+		feedback += " " + note
+	}
 
 	// Record user's stroke
 	userStroke := models.UserStroke{
-		StrokeNum: strokeNum,
 		Path:      userPath,
 		Timestamp: time.Now(),
+		Matched:   inferred.refs,
+		Continues: inferred.continues,
+		Accuracy:  accuracy,
+		Errors:    errors,
+	}
+	if len(inferred.refs) > 0 {
+		userStroke.StrokeNum = inferred.refs[0]
 	}
 	if len(userPath) >= 2 {
 		// Estimate duration based on path length (simplified)
 		userStroke.Duration = len(userPath) * 10
 	}
+	if inferred.continues {
+		// Both pieces are scored as the one stroke they make up
+		session.UserStrokes[len(session.UserStrokes)-1].Accuracy = accuracy
+	}
 
 	session.UserStrokes = append(session.UserStrokes, userStroke)
 
-	// Update overall accuracy
-	if len(session.UserStrokes) > 0 {
-		totalAccuracy := 0.0
-		for _, us := range session.UserStrokes {
-			// Recalculate accuracy for each stroke
-			for _, ref := range kanji.StrokeOrder {
-				if ref.StrokeNum == us.StrokeNum {
-					totalAccuracy += s.calculateStrokeAccuracy(&ref, us.Path)
-					break
-				}
-			}
+	// Update overall accuracy, counting a stroke drawn in two pieces once
+	totalAccuracy, strokes := 0.0, 0
+	for _, us := range session.UserStrokes {
+		if us.Continues {
+			continue
+		}
+		if us.Matched == nil && us.StrokeNum > 0 && us.StrokeNum <= len(kanji.StrokeOrder) {
+			// Saved before strokes were inferred
+			us.Accuracy = s.calculateStrokeAccuracy(&kanji.StrokeOrder[us.StrokeNum-1], us.Path)
 		}
-		session.Accuracy = totalAccuracy / float64(len(session.UserStrokes))
+		totalAccuracy += us.Accuracy
+		strokes++
 	}
-
-	// Check if completed
-	if len(session.UserStrokes) >= kanji.StrokeCount {
+	// Check if completed; a finished character is scored over all its
+	// strokes, so missing ones count as zero
+	complete := practiceComplete(session.UserStrokes, refPaths)
+	if complete {
+		strokes = max(strokes, len(refPaths))
+	}
+	session.Accuracy = totalAccuracy / float64(strokes)
+	if complete {
 		session.Status = "completed"
 		now := time.Now()
 		session.CompletedAt = &now
@@ -142,75 +184,57 @@ func (s *KanjiService) CompareStroke(sessionID string, strokeNum int, userPath [
 	if err := s.kanjiRepo.UpdatePracticeSession(session); err != nil {
 		return nil, fmt.Errorf("failed to update session: %w", err)
 	}
+	if complete {
+		if err := s.recordStrokeErrors(session, len(refPaths)); err != nil {
+			return nil, err
+		}
+	}
 
-	return &models.KanjiCompareResult{
-		Accuracy:     accuracy,
-		Feedback:     feedback,
-		Direction:    refStroke.Direction,
-		OrderCorrect: orderCorrect,
-	}, nil
+	orderCorrect := true
+	for _, e := range errors {
+		if e.Type == models.StrokeErrorOrder {
+			orderCorrect = false
+		}
+	}
+
+	result := &models.KanjiCompareResult{
+		Accuracy:       accuracy,
+		Feedback:       feedback,
+		Direction:      direction,
+		OrderCorrect:   orderCorrect,
+		Reversed:       match.Reversed,
+		ShapeScore:     match.ShapeScore,
+		CurvatureScore: match.CurvatureScore,
+		Deviation:      match.Distance,
+		Segments:       match.Segments,
+		StrokeDrawn:    userStroke.StrokeNum,
+		ExpectedStroke: expected,
+		Errors:         errors,
+		Complete:       complete,
+	}
+	if inferred.merged {
+		result.Merged = inferred.refs
+	}
+	return result, nil
+}
+
+// recordStrokeErrors saves the errors of a finished session, with the strokes
+// it never drew, for the user's stroke error stats
+func (s *KanjiService) recordStrokeErrors(session *models.KanjiPracticeSession, strokeCount int) error {
+	var errors []models.KanjiStrokeError
+	for _, us := range session.UserStrokes {
+		errors = append(errors, us.Errors...)
+	}
+	errors = append(errors, missingStrokeErrors(session.UserStrokes, strokeCount)...)
+	if err := s.kanjiRepo.RecordStrokeErrors(session, errors); err != nil {
+		return fmt.Errorf("failed to record stroke errors: %w", err)
+	}
+	return nil
 }
 
 // calculateStrokeAccuracy compares user path with reference stroke
 func (s *KanjiService) calculateStrokeAccuracy(ref *models.Stroke, userPath []models.Point) float64 {
-	if len(userPath) < 2 {
-		return 0.0
-	}
-
-	// Simplified stroke comparison algorithm
-	// 1. Check direction similarity
-	// 2. Check start/end point proximity
-	// 3. Check overall path shape
-
-	// Get user stroke direction vector
-	userStart := userPath[0]
-	userEnd := userPath[len(userPath)-1]
-	userDx := userEnd.X - userStart.X
-	userDy := userEnd.Y - userStart.Y
-	userLength := math.Sqrt(userDx*userDx + userDy*userDy)
-
-	// Get reference direction vector
-	refDx := ref.EndPoint.X - ref.StartPoint.X
-	refDy := ref.EndPoint.Y - ref.StartPoint.Y
-	refLength := math.Sqrt(refDx*refDx + refDy*refDy)
-
-	if userLength == 0 || refLength == 0 {
-		return 50.0 // Neutral if no movement
-	}
-
-	// Normalize vectors
-	userDx /= userLength
-	userDy /= userLength
-	refDx /= refLength
-	refDy /= refLength
-
-	// Calculate direction similarity (dot product)
-	dotProduct := userDx*refDx + userDy*refDy
-	directionScore := (dotProduct + 1) / 2 * 100 // Convert to 0-100
-
-	// Calculate start point proximity (normalized to 100x100 canvas)
-	startDist := math.Sqrt(
-		math.Pow(userStart.X-ref.StartPoint.X, 2) +
-		math.Pow(userStart.Y-ref.StartPoint.Y, 2),
-	)
-	startScore := math.Max(0, 100-startDist)
-
-	// Calculate end point proximity
-	endDist := math.Sqrt(
-		math.Pow(userEnd.X-ref.EndPoint.X, 2) +
-		math.Pow(userEnd.Y-ref.EndPoint.Y, 2),
-	)
-	endScore := math.Max(0, 100-endDist)
-
-	// Calculate length ratio
-	lengthRatio := math.Min(userLength, refLength) / math.Max(userLength, refLength)
-	lengthScore := lengthRatio * 100
-
-	// Weighted average
-	accuracy := directionScore*0.5 + startScore*0.2 + endScore*0.2 + lengthScore*0.1
-
-	// Clamp to 0-100
-	return math.Min(100, math.Max(0, accuracy))
+	return matchStroke(strokeReferencePath(ref), userPath).Accuracy
 }
 
 // generateFeedback creates helpful feedback based on accuracy
@@ -231,6 +255,34 @@ func (s *KanjiService) generateFeedback(accuracy float64, direction string) stri
 	}
 }
 
+// strokeMatchFeedback points out what the score alone doesn't say: a reversed
+// stroke, a missing or extra curve, or the part of the stroke that strayed
+func strokeMatchFeedback(m strokeMatch) string {
+	if m.Reversed {
+		return "The stroke was drawn backwards - start from the other end."
+	}
+	if m.CurvatureScore < 60 {
+		return "Check the curve or hook of this stroke."
+	}
+	worst := -1
+	for i, seg := range m.Segments {
+		if seg.OffTrack && (worst < 0 || seg.Deviation > m.Segments[worst].Deviation) {
+			worst = i
+		}
+	}
+	if worst < 0 {
+		return ""
+	}
+	switch part := worst * 3 / len(m.Segments); part {
+	case 0:
+		return "The start of the stroke is off."
+	case 1:
+		return "The middle of the stroke is off."
+	default:
+		return "The end of the stroke is off."
+	}
+}
+
 // GetPracticeSession retrieves session details
 func (s *KanjiService) GetPracticeSession(sessionID string) (*models.KanjiPracticeSession, error) {
 	return s.kanjiRepo.GetPracticeSession(sessionID)
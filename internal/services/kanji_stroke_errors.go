@@ -0,0 +1,377 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// A drawn stroke is taken as the reference stroke it matches best, so a
+// stroke drawn out of turn is recognised as the stroke it is rather than
+// graded against the one that was due
+const (
+	strokeInferConfident = 70.0 // A single stroke matching this well is taken as drawn
+	strokeInferMerged    = 50.0 // Two strokes drawn as one must match at least this well together...
+	strokeInferMargin    = 10.0 // ...and this much better than any single stroke
+	strokeInferMin       = 30.0 // Below this against every stroke, it's a stray stroke
+
+	strokeMergeGap = 15.0 // Two strokes can be drawn as one when one ends this close to where the other starts
+
+	strokeSplitDistance = 8.0  // A piece lying within this mean distance of a stroke can be part of it
+	strokeSplitLength   = 0.75 // A piece shorter than this share of the stroke is only part of it
+
+	strokeAxisSpan = 0.8 // A stroke crossing this share of the character pierces it
+)
+
+// Stroke-order rules a wrong order is put down to
+const (
+	StrokeRulePiercingLast     = "piercing_stroke_last"
+	StrokeRuleHorizontalFirst  = "horizontal_before_vertical"
+	StrokeRuleLeftFallingFirst = "left_falling_before_right_falling"
+	StrokeRuleOutsideFirst     = "outside_before_inside"
+	StrokeRuleTopToBottom      = "top_to_bottom"
+	StrokeRuleLeftToRight      = "left_to_right"
+	StrokeRuleOrder            = "stroke_order" // None of the above
+)
+
+var strokeRuleDescriptions = map[string]string{
+	StrokeRulePiercingLast:     "A stroke running through the whole character is written last (中, 手)",
+	StrokeRuleHorizontalFirst:  "Where a horizontal and a vertical stroke cross, the horizontal comes first (十)",
+	StrokeRuleLeftFallingFirst: "The left-falling stroke comes before the right-falling one (人, 八)",
+	StrokeRuleOutsideFirst:     "An enclosing frame is written before what is inside it (同, 月)",
+	StrokeRuleTopToBottom:      "Strokes are written from top to bottom (三)",
+	StrokeRuleLeftToRight:      "Strokes are written from left to right (川)",
+	StrokeRuleOrder:            "This character's strokes come in a set order",
+}
+
+// strokeInference is what a drawn stroke was taken as
+type strokeInference struct {
+	refs      []int // 1-based reference strokes; none for a stray stroke
+	match     strokeMatch
+	merged    bool
+	continues bool // The rest of the stroke the previous piece started
+}
+
+// inferStroke works out which reference stroke(s) a path was drawn as, among
+// the strokes not drawn yet
+func inferStroke(refPaths [][]models.Point, drawn []models.UserStroke, path []models.Point) strokeInference {
+	covered := coveredStrokes(drawn, len(refPaths))
+
+	// The rest of the stroke just drawn: the path lies along that stroke, and
+	// with the previous piece makes a better match for it. A stroke matched
+	// before the kanji's strokes were reimported may point past them.
+	if n := len(drawn); n > 0 && !drawn[n-1].Continues && len(drawn[n-1].Matched) == 1 &&
+		drawn[n-1].Matched[0] >= 1 && drawn[n-1].Matched[0] <= len(refPaths) {
+		prev := drawn[n-1]
+		ref := refPaths[prev.Matched[0]-1]
+		whole := append(append([]models.Point{}, prev.Path...), path...)
+		if distanceToPath(path, ref) < strokeSplitDistance && pathLength(path) < pathLength(ref)*strokeSplitLength {
+			if m := matchStroke(ref, whole); m.Accuracy >= strokeInferMerged && m.Accuracy > prev.Accuracy {
+				return strokeInference{refs: prev.Matched, match: m, continues: true}
+			}
+		}
+	}
+
+	best := strokeInference{}
+	for i, ref := range refPaths {
+		if covered[i] {
+			continue
+		}
+		if m := matchStroke(ref, path); len(best.refs) == 0 || m.Accuracy > best.match.Accuracy {
+			best = strokeInference{refs: []int{i + 1}, match: m}
+		}
+	}
+	if best.match.Accuracy >= strokeInferConfident {
+		return best
+	}
+
+	// Two strokes drawn without lifting the pen, where one ends near where
+	// the other starts (口 drawn with its first and last strokes as one ㄴ)
+	single := best.match.Accuracy
+	for i := range refPaths {
+		for j := i + 1; j < len(refPaths); j++ {
+			a, b := refPaths[i], refPaths[j]
+			if covered[i] || covered[j] || distance(a[len(a)-1], b[0]) > strokeMergeGap {
+				continue
+			}
+			m := matchStroke(append(append([]models.Point{}, a...), b...), path)
+			if m.Accuracy >= strokeInferMerged && m.Accuracy >= single+strokeInferMargin && m.Accuracy > best.match.Accuracy {
+				best = strokeInference{refs: []int{i + 1, j + 1}, match: m, merged: true}
+			}
+		}
+	}
+	if best.merged || best.match.Accuracy >= strokeInferMin {
+		return best
+	}
+	return strokeInference{match: best.match}
+}
+
+// coveredStrokes marks the reference strokes already drawn. Strokes saved
+// before strokes were inferred only carry the stroke number the client sent.
+func coveredStrokes(drawn []models.UserStroke, count int) []bool {
+	covered := make([]bool, count)
+	for _, us := range drawn {
+		refs := us.Matched
+		if refs == nil && us.StrokeNum > 0 {
+			refs = []int{us.StrokeNum}
+		}
+		for _, r := range refs {
+			if r >= 1 && r <= count {
+				covered[r-1] = true
+			}
+		}
+	}
+	return covered
+}
+
+// distanceToPath is the mean distance from the points of a path to the
+// nearest point of another
+func distanceToPath(path, ref []models.Point) float64 {
+	points := resampleStroke(path, recognizeSamplePoints)
+	total := 0.0
+	for _, p := range points {
+		nearest := math.Inf(1)
+		for i := 1; i < len(ref); i++ {
+			nearest = math.Min(nearest, distanceToSegment(p, ref[i-1], ref[i]))
+		}
+		if len(ref) == 1 {
+			nearest = distance(p, ref[0])
+		}
+		total += nearest
+	}
+	return total / float64(len(points))
+}
+
+func distanceToSegment(p, a, b models.Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSq := dx*dx + dy*dy
+	if lengthSq < 1e-9 {
+		return distance(p, a)
+	}
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/lengthSq))
+	return distance(p, models.Point{X: a.X + t*dx, Y: a.Y + t*dy})
+}
+
+// classifyStroke lists the errors in a drawn stroke. It is out of order when
+// it comes after a stroke that hasn't been drawn yet.
+func classifyStroke(inf strokeInference, expected int, refPaths [][]models.Point) []models.KanjiStrokeError {
+	errors := []models.KanjiStrokeError{}
+	if len(inf.refs) == 0 {
+		return errors
+	}
+	drawn := inf.refs[0]
+
+	if !inf.continues && expected > 0 && drawn > expected {
+		rule := strokeOrderRule(refPaths, expected-1, drawn-1)
+		errors = append(errors, models.KanjiStrokeError{
+			Type:      models.StrokeErrorOrder,
+			StrokeNum: drawn,
+			Rule:      rule,
+			Message:   fmt.Sprintf("Stroke %d was drawn before stroke %d. %s.", drawn, expected, strokeRuleDescriptions[rule]),
+		})
+	}
+	if inf.match.Reversed {
+		errors = append(errors, models.KanjiStrokeError{
+			Type:      models.StrokeErrorReversed,
+			StrokeNum: drawn,
+			Message:   fmt.Sprintf("Stroke %d was drawn backwards - start from the other end.", drawn),
+		})
+	}
+	switch {
+	case inf.merged:
+		errors = append(errors, models.KanjiStrokeError{
+			Type:      models.StrokeErrorMerged,
+			StrokeNum: drawn,
+			Message:   fmt.Sprintf("Strokes %d and %d were drawn as one - lift the pen between them.", drawn, inf.refs[1]),
+		})
+	case inf.continues:
+		errors = append(errors, models.KanjiStrokeError{
+			Type:      models.StrokeErrorSplit,
+			StrokeNum: drawn,
+			Message:   fmt.Sprintf("Stroke %d was drawn in two pieces - it is a single stroke.", drawn),
+		})
+	}
+	return errors
+}
+
+// strokeOrderRule names the rule broken by drawing stroke later before
+// stroke earlier (both 0-based), from how the two strokes sit
+func strokeOrderRule(refPaths [][]models.Point, earlier, later int) string {
+	a, b := refPaths[earlier], refPaths[later]
+	minX, minY, maxX, maxY := pathBounds(refPaths...)
+	bMinX, bMinY, bMaxX, bMaxY := pathBounds(b)
+	aMinX, aMinY, aMaxX, aMaxY := pathBounds(a)
+	crosses := pathsCross(a, b)
+
+	if crosses && ((bMaxX-bMinX) >= (maxX-minX)*strokeAxisSpan || (bMaxY-bMinY) >= (maxY-minY)*strokeAxisSpan) {
+		return StrokeRulePiercingLast
+	}
+	dirA, dirB := StrokeDirection(a), StrokeDirection(b)
+	if crosses && dirA == "horizontal" && dirB == "vertical" {
+		return StrokeRuleHorizontalFirst
+	}
+	if dirA == "diagonal" && dirB == "diagonal" && a[len(a)-1].X < a[0].X && b[len(b)-1].X > b[0].X {
+		return StrokeRuleLeftFallingFirst
+	}
+
+	cx, cy := (bMinX+bMaxX)/2, (bMinY+bMaxY)/2
+	if cx > aMinX && cx < aMaxX && cy > aMinY && cy < aMaxY && (aMaxX-aMinX)*(aMaxY-aMinY) > (bMaxX-bMinX)*(bMaxY-bMinY) {
+		return StrokeRuleOutsideFirst
+	}
+	acx, acy := (aMinX+aMaxX)/2, (aMinY+aMaxY)/2
+	switch {
+	case cy-acy > math.Abs(cx-acx):
+		return StrokeRuleTopToBottom
+	case cx-acx > math.Abs(cy-acy):
+		return StrokeRuleLeftToRight
+	}
+	return StrokeRuleOrder
+}
+
+func pathBounds(paths ...[]models.Point) (minX, minY, maxX, maxY float64) {
+	minX, minY, maxX, maxY = math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, path := range paths {
+		for _, p := range path {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		}
+	}
+	return minX, minY, maxX, maxY
+}
+
+// pathsCross reports whether any segments of two paths intersect
+func pathsCross(a, b []models.Point) bool {
+	cross := func(o, p, q models.Point) float64 {
+		return (p.X-o.X)*(q.Y-o.Y) - (p.Y-o.Y)*(q.X-o.X)
+	}
+	for i := 1; i < len(a); i++ {
+		for j := 1; j < len(b); j++ {
+			d1, d2 := cross(b[j-1], b[j], a[i-1]), cross(b[j-1], b[j], a[i])
+			d3, d4 := cross(a[i-1], a[i], b[j-1]), cross(a[i-1], a[i], b[j])
+			if d1*d2 < 0 && d3*d4 < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// missingStrokeErrors lists the strokes a finished session never drew
+func missingStrokeErrors(drawn []models.UserStroke, count int) []models.KanjiStrokeError {
+	errors := []models.KanjiStrokeError{}
+	for i, covered := range coveredStrokes(drawn, count) {
+		if !covered {
+			errors = append(errors, models.KanjiStrokeError{
+				Type:      models.StrokeErrorMissing,
+				StrokeNum: i + 1,
+				Message:   fmt.Sprintf("Stroke %d was never drawn.", i+1),
+			})
+		}
+	}
+	return errors
+}
+
+// practiceComplete reports whether every stroke has been drawn, or as many
+// strokes as the kanji has (so a skipped stroke shows up as missing). While
+// the last stroke falls well short of its reference the rest of it may still
+// come, so the session stays open for one more stroke.
+func practiceComplete(drawn []models.UserStroke, refPaths [][]models.Point) bool {
+	strokes := 0
+	for _, us := range drawn {
+		if !us.Continues {
+			strokes++
+		}
+	}
+	if last := drawn[len(drawn)-1]; !last.Continues && len(last.Matched) == 1 && strokes <= len(refPaths) &&
+		last.Matched[0] >= 1 && last.Matched[0] <= len(refPaths) &&
+		pathLength(last.Path) < pathLength(refPaths[last.Matched[0]-1])*strokeSplitLength {
+		return false
+	}
+	if strokes >= len(refPaths) {
+		return true
+	}
+	for _, covered := range coveredStrokes(drawn, len(refPaths)) {
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// GetStrokeReport lists a trace session's strokes as they were understood,
+// and every stroke error in it
+func (s *KanjiService) GetStrokeReport(userID, sessionID string) (*models.KanjiStrokeReport, error) {
+	session, err := s.kanjiRepo.GetPracticeSession(sessionID)
+	if err != nil || session.UserID != userID {
+		return nil, fmt.Errorf("session not found")
+	}
+	if session.Mode == models.KanjiModeRecall {
+		return nil, fmt.Errorf("recall sessions are graded as a whole character")
+	}
+	kanji, err := s.kanjiRepo.GetKanjiByID(session.KanjiID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.KanjiStrokeReport{
+		SessionID:   session.ID,
+		KanjiChar:   session.KanjiChar,
+		Complete:    session.Status == "completed",
+		StrokeCount: kanji.StrokeCount,
+		Strokes:     []models.KanjiStrokeRecord{},
+		Errors:      []models.KanjiStrokeError{},
+		ErrorTypes:  map[string]int{},
+		Missing:     []int{},
+	}
+	for i, us := range session.UserStrokes {
+		record := models.KanjiStrokeRecord{
+			Index:     i + 1,
+			Matched:   us.Matched,
+			Continues: us.Continues,
+			Accuracy:  us.Accuracy,
+			Errors:    us.Errors,
+		}
+		if record.Matched == nil {
+			record.Matched = []int{}
+		}
+		if record.Errors == nil {
+			record.Errors = []models.KanjiStrokeError{}
+		}
+		if !us.Continues {
+			report.StrokesDrawn++
+		}
+		report.Strokes = append(report.Strokes, record)
+		report.Errors = append(report.Errors, us.Errors...)
+	}
+	if report.Complete {
+		for _, e := range missingStrokeErrors(session.UserStrokes, kanji.StrokeCount) {
+			report.Missing = append(report.Missing, e.StrokeNum)
+			report.Errors = append(report.Errors, e)
+		}
+	}
+
+	report.OrderCorrect = true
+	for _, e := range report.Errors {
+		report.ErrorTypes[e.Type]++
+		if e.Type == models.StrokeErrorOrder {
+			report.OrderCorrect = false
+		}
+	}
+	return report, nil
+}
+
+// GetStrokeErrorStats aggregates the stroke errors a user makes over their
+// finished trace sessions, and the stroke-order rules they break most
+func (s *KanjiService) GetStrokeErrorStats(userID string) (*models.KanjiStrokeErrorStats, error) {
+	stats, err := s.kanjiRepo.GetStrokeErrorStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats.Rules {
+		stats.Rules[i].Description = strokeRuleDescriptions[stats.Rules[i].Rule]
+	}
+	sort.SliceStable(stats.Rules, func(i, j int) bool { return stats.Rules[i].Count > stats.Rules[j].Count })
+	return stats, nil
+}
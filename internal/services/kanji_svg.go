@@ -0,0 +1,324 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// Kanji are drawn on the 100x100 canvas the stroke data uses, scaled to the
+// size asked for
+const (
+	svgDefaultSize  = 200
+	svgMaxSize      = 1000
+	svgStrokeWidth  = 3.5
+	svgNumberSize   = 7
+	svgNumberOffset = 5 // How far before the start of its stroke a number sits
+
+	svgDefaultSpeed  = 120.0 // Canvas units drawn per second
+	svgMinStrokeTime = 0.25  // Seconds; dots would flash past otherwise
+	svgStrokePause   = 0.2   // Seconds between strokes
+	svgLoopHold      = 1.5   // Seconds the finished kanji stays up before a loop starts again
+
+	sheetDefaultColumns  = 10
+	sheetMinColumns      = 4
+	sheetMaxColumns      = 16
+	sheetDefaultGuides   = 3
+	sheetDefaultCellSize = 60
+	sheetMaxCellSize     = 200
+	sheetMaxCharacters   = 20
+	sheetMaxCells        = 600 // Squares on a whole sheet
+	sheetMargin          = 5
+	sheetCaptionHeight   = 16 // Above each character's rows
+)
+
+const (
+	svgGuideColor  = "#d0d0d0" // Faded strokes to trace over
+	svgDoneColor   = "#999999" // Strokes already added in the build-up
+	svgGridColor   = "#e0e0e0"
+	svgNumberColor = "#c0392b"
+)
+
+// RenderKanjiSVG draws a kanji's strokes as an SVG image: every stroke at
+// once, numbered in order, or drawn one after another
+func (s *KanjiService) RenderKanjiSVG(char string, opts models.KanjiSVGOptions) (string, error) {
+	kanji, err := s.kanjiRepo.GetKanjiByCharacter(char)
+	if err != nil {
+		return "", err
+	}
+	if len(kanji.StrokeOrder) == 0 {
+		return "", fmt.Errorf("no stroke data for %s", char)
+	}
+
+	switch opts.Style {
+	case "":
+		opts.Style = models.KanjiSVGStatic
+	case models.KanjiSVGStatic, models.KanjiSVGAnimated:
+	default:
+		return "", fmt.Errorf("style must be static or animated")
+	}
+	if opts.Size <= 0 {
+		opts.Size = svgDefaultSize
+	}
+	opts.Size = min(opts.Size, svgMaxSize)
+	if opts.Speed <= 0 {
+		opts.Speed = svgDefaultSpeed
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 100 100">`, opts.Size, opts.Size)
+	fmt.Fprintf(&b, `<title>%s</title>`, html.EscapeString(kanji.Character))
+	if opts.Grid {
+		svgCellGrid(&b)
+	}
+	if opts.Style == models.KanjiSVGAnimated {
+		svgAnimatedStrokes(&b, kanji, opts)
+	} else {
+		svgStrokes(&b, kanji.StrokeOrder, func(int) string { return "currentColor" })
+		if opts.Numbers {
+			svgStrokeNumbers(&b, kanji.StrokeOrder, nil)
+		}
+	}
+	b.WriteString(`</svg>`)
+	return b.String(), nil
+}
+
+// svgAnimatedStrokes draws the strokes one after another over a faded copy
+// of the kanji. Each stroke takes time in proportion to its length and its
+// path carries when it starts and how long it takes (data-start and
+// data-duration, in seconds) for clients timing anything else to it.
+func svgAnimatedStrokes(b *strings.Builder, kanji *models.Kanji, opts models.KanjiSVGOptions) {
+	starts := make([]float64, len(kanji.StrokeOrder))
+	durations := make([]float64, len(kanji.StrokeOrder))
+	elapsed := 0.0
+	for i := range kanji.StrokeOrder {
+		length := pathLength(strokeReferencePath(&kanji.StrokeOrder[i]))
+		starts[i] = elapsed
+		durations[i] = max(svgMinStrokeTime, length/opts.Speed)
+		elapsed += durations[i] + svgStrokePause
+	}
+	total := elapsed - svgStrokePause
+	iterations := "1"
+	if opts.Loop {
+		total += svgLoopHold
+		iterations = "infinite"
+	}
+
+	// Class names carry the character so several kanji can share a page
+	prefix := "k"
+	for _, r := range kanji.Character {
+		prefix += strconv.FormatInt(int64(r), 16)
+	}
+
+	// A stroke is hidden until its turn, then drawn along its length by
+	// moving the dash over it (its pathLength is 1). The number of a stroke
+	// runs the same animation, so it shows when its stroke starts.
+	b.WriteString(`<style>`)
+	fmt.Fprintf(b, `.%s-s{stroke-dasharray:1 2;stroke-dashoffset:1;visibility:hidden}`, prefix)
+	for i := range kanji.StrokeOrder {
+		from := starts[i] / total * 100
+		to := (starts[i] + durations[i]) / total * 100
+		fmt.Fprintf(b, `.%s-%d{animation:%s-%d %ss linear %s forwards}`, prefix, i+1, prefix, i+1, svgNum(total), iterations)
+		hidden := "0%"
+		if from > 0 {
+			hidden += "," + svgNum(from) + "%"
+		}
+		fmt.Fprintf(b, `@keyframes %s-%d{%s{stroke-dashoffset:1;visibility:hidden}%s%%,100%%{stroke-dashoffset:0;visibility:visible}}`,
+			prefix, i+1, hidden, svgNum(to))
+	}
+	fmt.Fprintf(b, `@media (prefers-reduced-motion:reduce){.%s-s,.%s-n{animation:none;stroke-dashoffset:0;visibility:visible}}`, prefix, prefix)
+	b.WriteString(`</style>`)
+
+	svgStrokes(b, kanji.StrokeOrder, func(int) string { return svgGuideColor })
+	for i := range kanji.StrokeOrder {
+		fmt.Fprintf(b, `<path class="%s-s %s-%d" d="%s" pathLength="1" data-start="%s" data-duration="%s" %s/>`,
+			prefix, prefix, i+1, svgPathData(strokeReferencePath(&kanji.StrokeOrder[i])),
+			svgNum(starts[i]), svgNum(durations[i]), svgStrokeStyle("currentColor"))
+	}
+	if opts.Numbers {
+		svgStrokeNumbers(b, kanji.StrokeOrder, func(i int) string {
+			return fmt.Sprintf(` class="%s-n %s-%d" visibility="hidden"`, prefix, prefix, i+1)
+		})
+	}
+}
+
+// RenderPracticeSheet lays out practice squares for several kanji, each
+// starting on a new row under a caption with its meaning and readings
+func (s *KanjiService) RenderPracticeSheet(opts models.KanjiSheetOptions) (string, error) {
+	if len(opts.Characters) == 0 {
+		return "", fmt.Errorf("give at least one character")
+	}
+	if len(opts.Characters) > sheetMaxCharacters {
+		return "", fmt.Errorf("at most %d characters per sheet", sheetMaxCharacters)
+	}
+	if opts.Columns <= 0 {
+		opts.Columns = sheetDefaultColumns
+	}
+	opts.Columns = min(max(opts.Columns, sheetMinColumns), sheetMaxColumns)
+	if opts.Guides < 0 {
+		opts.Guides = sheetDefaultGuides
+	}
+	if opts.Guides > 2*opts.Columns {
+		return "", fmt.Errorf("at most %d guides with %d columns", 2*opts.Columns, opts.Columns)
+	}
+	if opts.CellSize <= 0 {
+		opts.CellSize = sheetDefaultCellSize
+	}
+	opts.CellSize = min(opts.CellSize, sheetMaxCellSize)
+
+	kanji := make([]*models.Kanji, len(opts.Characters))
+	for i, char := range opts.Characters {
+		k, err := s.kanjiRepo.GetKanjiByCharacter(char)
+		if err != nil {
+			return "", err
+		}
+		if len(k.StrokeOrder) == 0 {
+			return "", fmt.Errorf("no stroke data for %s", char)
+		}
+		kanji[i] = k
+	}
+	if total := sheetCells(kanji, opts); total > sheetMaxCells {
+		return "", fmt.Errorf("sheet would have %d squares; at most %d", total, sheetMaxCells)
+	}
+
+	var body strings.Builder
+	y := float64(sheetMargin)
+	for _, k := range kanji {
+		caption := k.Character + "  " + k.Meaning
+		if len(k.Readings) > 0 {
+			caption += " · " + strings.Join(k.Readings, "、")
+		}
+		fmt.Fprintf(&body, `<text x="%d" y="%s" font-size="9" font-family="'Hiragino Sans','Noto Sans JP',sans-serif" fill="currentColor">%s</text>`,
+			sheetMargin, svgNum(y+sheetCaptionHeight-5), html.EscapeString(caption))
+		y += sheetCaptionHeight
+
+		cells, rows := sheetRows(k, opts)
+
+		for cell := 0; cell < rows*opts.Columns; cell++ {
+			x := sheetMargin + (cell%opts.Columns)*100
+			fmt.Fprintf(&body, `<g transform="translate(%d %s)">`, x, svgNum(y+float64(cell/opts.Columns*100)))
+			svgCellGrid(&body)
+			step := cell - 1
+			switch {
+			case cell == 0:
+				svgStrokes(&body, k.StrokeOrder, func(int) string { return "currentColor" })
+				svgStrokeNumbers(&body, k.StrokeOrder, nil)
+			case opts.Steps && step < len(k.StrokeOrder):
+				// Strokes so far, the new one dark and numbered
+				svgStrokes(&body, k.StrokeOrder[:step+1], func(i int) string {
+					if i == step {
+						return "currentColor"
+					}
+					return svgDoneColor
+				})
+				svgStrokeNumbers(&body, k.StrokeOrder[step:step+1], nil)
+			case cell < cells:
+				svgStrokes(&body, k.StrokeOrder, func(int) string { return svgGuideColor })
+			}
+			body.WriteString(`</g>`)
+		}
+		y += float64(rows*100) + sheetMargin
+	}
+
+	width := float64(2*sheetMargin + opts.Columns*100)
+	height := y
+	scale := float64(opts.CellSize) / 100
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`,
+		svgNum(width*scale), svgNum(height*scale), svgNum(width), svgNum(height))
+	b.WriteString(`<title>Kanji practice sheet</title>`)
+	b.WriteString(body.String())
+	b.WriteString(`</svg>`)
+	return b.String(), nil
+}
+
+// svgCellGrid draws a square's border and the dashed lines through its middle
+func svgCellGrid(b *strings.Builder) {
+	fmt.Fprintf(b, `<rect x="0.5" y="0.5" width="99" height="99" fill="none" stroke="%s"/>`, svgGridColor)
+	fmt.Fprintf(b, `<path d="M50,0 L50,100 M0,50 L100,50" stroke="%s" stroke-dasharray="3 3"/>`, svgGridColor)
+}
+
+// svgStrokes draws strokes, coloured by their index
+func svgStrokes(b *strings.Builder, strokes []models.Stroke, color func(i int) string) {
+	for i := range strokes {
+		fmt.Fprintf(b, `<path d="%s" %s/>`, svgPathData(strokeReferencePath(&strokes[i])), svgStrokeStyle(color(i)))
+	}
+}
+
+func svgStrokeStyle(color string) string {
+	return fmt.Sprintf(`fill="none" stroke="%s" stroke-width="%s" stroke-linecap="round" stroke-linejoin="round"`, color, svgNum(svgStrokeWidth))
+}
+
+// svgStrokeNumbers numbers strokes just before where each starts, away
+// from the way it goes. attrs adds attributes to a number's text element.
+func svgStrokeNumbers(b *strings.Builder, strokes []models.Stroke, attrs func(i int) string) {
+	for i := range strokes {
+		path := strokeReferencePath(&strokes[i])
+		start := path[0]
+		dx, dy := -1.0, -1.0 // Up and to the left of a dot
+		for _, p := range path[1:] {
+			if d := distance(start, p); d >= 2 {
+				dx, dy = (p.X-start.X)/d, (p.Y-start.Y)/d
+				break
+			}
+		}
+		limit := svgNumberSize/2 + 1.0
+		x := math.Min(math.Max(start.X-dx*svgNumberOffset, limit), 100-limit)
+		y := math.Min(math.Max(start.Y-dy*svgNumberOffset, limit), 100-limit)
+
+		extra := ""
+		if attrs != nil {
+			extra = attrs(i)
+		}
+		fmt.Fprintf(b, `<text x="%s" y="%s" font-size="%d" font-family="sans-serif" text-anchor="middle" dominant-baseline="central" fill="%s"%s>%d</text>`,
+			svgNum(x), svgNum(y), svgNumberSize, svgNumberColor, extra, strokes[i].StrokeNum)
+	}
+}
+
+// svgPathData turns a stroke's points into path data
+func svgPathData(path []models.Point) string {
+	var b strings.Builder
+	for i, p := range path {
+		if i == 0 {
+			b.WriteString("M")
+		} else {
+			b.WriteString(" L")
+		}
+		b.WriteString(svgNum(p.X) + "," + svgNum(p.Y))
+	}
+	return b.String()
+}
+
+// svgNum formats a number to one decimal place, dropping a trailing .0
+func svgNum(f float64) string {
+	return strconv.FormatFloat(math.Round(f*10)/10, 'f', -1, 64)
+}
+
+// sheetRows counts a kanji's filled squares and the rows they take: a
+// model, the build-up, the guides, then empty squares to the end of the
+// row, always leaving some to write in
+func sheetRows(k *models.Kanji, opts models.KanjiSheetOptions) (cells, rows int) {
+	cells = 1 + opts.Guides
+	if opts.Steps {
+		cells += len(k.StrokeOrder)
+	}
+	rows = (cells + opts.Columns - 1) / opts.Columns
+	if rows*opts.Columns == cells {
+		rows++
+	}
+	return cells, rows
+}
+
+// sheetCells counts the squares on a whole sheet, empty ones included
+func sheetCells(kanji []*models.Kanji, opts models.KanjiSheetOptions) int {
+	total := 0
+	for _, k := range kanji {
+		_, rows := sheetRows(k, opts)
+		total += rows * opts.Columns
+	}
+	return total
+}
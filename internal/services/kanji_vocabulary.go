@@ -0,0 +1,344 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// Alignment scores: a kanji read with one of its own readings is worth far
+// more than one read irregularly, and a reading as listed a little more than
+// one changed by the sounds around it
+const (
+	alignReadingScore   = 10
+	alignSoundChange    = 1
+	alignIrregularScore = -1
+)
+
+// Voiced forms of a kana at the start of the second part of a compound
+// (rendaku: ひと → びと), and the half-voiced ones after っ or ん (ほん → ぽん)
+var rendaku = map[rune][]rune{
+	'か': {'が'}, 'き': {'ぎ'}, 'く': {'ぐ'}, 'け': {'げ'}, 'こ': {'ご'},
+	'さ': {'ざ'}, 'し': {'じ'}, 'す': {'ず'}, 'せ': {'ぜ'}, 'そ': {'ぞ'},
+	'た': {'だ'}, 'ち': {'ぢ', 'じ'}, 'つ': {'づ', 'ず'}, 'て': {'で'}, 'と': {'ど'},
+	'は': {'ば', 'ぱ'}, 'ひ': {'び', 'ぴ'}, 'ふ': {'ぶ', 'ぷ'}, 'へ': {'べ', 'ぺ'}, 'ほ': {'ぼ', 'ぽ'},
+}
+
+// alignedKanji is a kanji of a word and the part of the reading it takes
+type alignedKanji struct {
+	position int
+	char     string
+	reading  string // As it sounds in the word
+	base     string // The kanji's reading; empty when irregular
+	typ      string
+}
+
+type alignment struct {
+	score int
+	kanji []alignedKanji
+	ok    bool
+}
+
+// readingVariants lists the forms a kanji reading can take in a word: as
+// listed, voiced when it isn't the start of the word, and with its last kana
+// doubled into っ when more follows (いち → いっ in 一緒)
+func readingVariants(base string, first, last bool) map[string]int {
+	variants := map[string]int{base: alignReadingScore}
+	forms := []string{base}
+	if r := []rune(base); !first && len(r) > 0 {
+		for _, voiced := range rendaku[r[0]] {
+			forms = append(forms, string(voiced)+string(r[1:]))
+		}
+	}
+	for _, f := range forms {
+		variants[f] = max(variants[f], alignReadingScore-alignSoundChange)
+		r := []rune(f)
+		if !last && len(r) > 1 && strings.ContainsRune("つちくき", r[len(r)-1]) {
+			geminated := string(r[:len(r)-1]) + "っ"
+			variants[geminated] = max(variants[geminated], alignReadingScore-alignSoundChange)
+		}
+	}
+	return variants
+}
+
+// alignWordReading works out which part of a word's reading each kanji in
+// it takes. Kana in the word must match the reading as written; each kanji
+// takes one of its readings if it can, and a run of kanji that can't be read
+// kanji by kanji (今日 きょう) shares the part of the reading left for it.
+func alignWordReading(word, reading string, kanji map[string]*models.Kanji) ([]alignedKanji, bool) {
+	runes := []rune(word)
+	read := []rune(katakanaToHiragana(reading))
+
+	// 々 repeats the kanji before it
+	chars := make([]string, len(runes))
+	for i, r := range runes {
+		chars[i] = string(r)
+		if r == '々' && i > 0 {
+			chars[i] = chars[i-1]
+		}
+	}
+
+	memo := make(map[[2]int]alignment)
+	var align func(i, j int) alignment
+	align = func(i, j int) alignment {
+		if i == len(runes) {
+			return alignment{ok: j == len(read)}
+		}
+		key := [2]int{i, j}
+		if a, ok := memo[key]; ok {
+			return a
+		}
+
+		best := alignment{}
+		consider := func(score int, segs []alignedKanji, next alignment) {
+			if !next.ok || (best.ok && score+next.score <= best.score) {
+				return
+			}
+			best = alignment{score: score + next.score, kanji: append(append([]alignedKanji{}, segs...), next.kanji...), ok: true}
+		}
+
+		if !isKanjiRune(runes[i]) {
+			switch {
+			case j < len(read) && []rune(katakanaToHiragana(chars[i]))[0] == read[j]:
+				consider(0, nil, align(i+1, j+1))
+			case !unicode.In(runes[i], unicode.Hiragana, unicode.Katakana):
+				// Punctuation and the like aren't read
+				consider(0, nil, align(i+1, j))
+			}
+			memo[key] = best
+			return best
+		}
+
+		rest := string(read[j:])
+		if k := kanji[chars[i]]; k != nil {
+			compound := (i > 0 && isKanjiRune(runes[i-1])) || (i+1 < len(runes) && isKanjiRune(runes[i+1]))
+			for _, base := range k.Readings {
+				for v, score := range readingVariants(base, i == 0, i == len(runes)-1) {
+					if v == "" || !strings.HasPrefix(rest, v) {
+						continue
+					}
+					seg := alignedKanji{position: i, char: chars[i], reading: v, base: base, typ: kanjiReadingType(k, base, compound)}
+					consider(score, []alignedKanji{seg}, align(i+1, j+len([]rune(v))))
+				}
+			}
+		}
+
+		// Irregular: the run of kanji from here to end shares read[j:m]
+		for end := i + 1; end <= len(runes) && isKanjiRune(runes[end-1]); end++ {
+			for m := j + 1; m <= len(read); m++ {
+				segs := make([]alignedKanji, 0, end-i)
+				for p := i; p < end; p++ {
+					segs = append(segs, alignedKanji{position: p, char: chars[p], reading: string(read[j:m]), typ: models.KanjiReadingIrregular})
+				}
+				consider(alignIrregularScore, segs, align(end, m))
+			}
+		}
+
+		memo[key] = best
+		return best
+	}
+
+	a := align(0, 0)
+	return a.kanji, a.ok
+}
+
+// kanjiReadingType tells an on reading from a kun reading. Kanji imported
+// without the split are guessed at: kanji compounds mostly use on readings,
+// a kanji on its own or with okurigana its kun reading.
+func kanjiReadingType(k *models.Kanji, base string, compound bool) string {
+	if len(k.OnReadings) > 0 {
+		for _, on := range k.OnReadings {
+			if on == base {
+				return models.KanjiReadingOn
+			}
+		}
+		return models.KanjiReadingKun
+	}
+	if compound {
+		return models.KanjiReadingOn
+	}
+	return models.KanjiReadingKun
+}
+
+// LinkVocabulary links every word in the vocabulary to the kanji it is
+// written with, replacing the previous links. It returns how many words were
+// linked and how many couldn't be lined up with their reading.
+func (s *KanjiService) LinkVocabulary() (linked, unaligned int, err error) {
+	allKanji, err := s.kanjiRepo.GetAllKanji()
+	if err != nil {
+		return 0, 0, err
+	}
+	kanji := make(map[string]*models.Kanji, len(allKanji))
+	for _, k := range allKanji {
+		kanji[k.Character] = k
+	}
+
+	words, err := s.vocabRepo.GetAllWords()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var links []models.KanjiVocabLink
+	for _, w := range words {
+		written, kana := vocabForms(&w)
+		uses := false
+		for _, r := range written {
+			if kanji[string(r)] != nil {
+				uses = true
+				break
+			}
+		}
+		if !uses {
+			continue
+		}
+
+		aligned, ok := alignWordReading(written, kana, kanji)
+		if !ok {
+			unaligned++
+			continue
+		}
+		linked++
+		for _, a := range aligned {
+			k := kanji[a.char]
+			if k == nil {
+				continue
+			}
+			links = append(links, models.KanjiVocabLink{
+				KanjiID:      k.ID,
+				VocabularyID: w.ID,
+				Position:     a.position,
+				Reading:      a.reading,
+				BaseReading:  a.base,
+				ReadingType:  a.typ,
+			})
+		}
+	}
+
+	if err := s.kanjiRepo.ReplaceKanjiVocabularyLinks(links); err != nil {
+		return 0, 0, fmt.Errorf("failed to save links: %w", err)
+	}
+	return linked, unaligned, nil
+}
+
+// GetKanjiVocabulary lists the words using a kanji, grouped by the reading
+// the kanji has in them. level is the hardest JLPT level to include (the
+// user's own level when empty, "all" for every level); known is all, only
+// (words the user knows) or exclude.
+func (s *KanjiService) GetKanjiVocabulary(userID, char, level, known string) (*models.KanjiVocabularyBreakdown, error) {
+	kanji, err := s.kanjiRepo.GetKanjiByCharacter(char)
+	if err != nil {
+		return nil, err
+	}
+
+	if level == "" {
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		level = user.CurrentLevel
+	}
+	var levels []string
+	if level != "all" {
+		for _, l := range []string{"N5", "N4", "N3", "N2", "N1"} {
+			levels = append(levels, l)
+			if l == level {
+				break
+			}
+		}
+		if levels[len(levels)-1] != level {
+			return nil, fmt.Errorf("unknown level: %s", level)
+		}
+	}
+
+	switch known {
+	case "":
+		known = "all"
+	case "all", "only", "exclude":
+	default:
+		return nil, fmt.Errorf("known must be all, only or exclude")
+	}
+
+	words, err := s.kanjiRepo.GetKanjiVocabulary(kanji.ID, userID, levels)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &models.KanjiVocabularyBreakdown{
+		Character:   kanji.Character,
+		Meaning:     kanji.Meaning,
+		OnReadings:  []string{},
+		KunReadings: []string{},
+		Levels:      levels,
+		Known:       known,
+		Readings:    []models.KanjiReadingUsage{},
+	}
+	if breakdown.Levels == nil {
+		breakdown.Levels = []string{"N5", "N4", "N3", "N2", "N1"}
+	}
+
+	// A group for every reading, even one none of the words use
+	groups := make(map[string]int)
+	for _, r := range kanji.Readings {
+		groups[r] = len(breakdown.Readings)
+		breakdown.Readings = append(breakdown.Readings, models.KanjiReadingUsage{
+			Reading: r,
+			Type:    kanjiReadingType(kanji, r, false),
+			Words:   []models.KanjiVocabWord{},
+		})
+	}
+
+	seen := make(map[string]bool)       // Words counted in the totals
+	inGroup := make(map[[2]string]bool) // A word using the kanji twice the same way is listed once
+	for _, w := range words {
+		if (known == "only" && !w.Known) || (known == "exclude" && w.Known) {
+			continue
+		}
+		w.Word, w.Reading = vocabForms(&models.Vocabulary{Word: w.Word, Reading: w.Reading})
+		key := w.BaseReading
+		if w.ReadingType == models.KanjiReadingIrregular {
+			key = ""
+		}
+		i, ok := groups[key]
+		if !ok {
+			// Irregular readings, and readings the kanji no longer lists
+			i = len(breakdown.Readings)
+			groups[key] = i
+			breakdown.Readings = append(breakdown.Readings, models.KanjiReadingUsage{Reading: key, Type: w.ReadingType, Words: []models.KanjiVocabWord{}})
+		}
+		if inGroup[[2]string{key, w.VocabID}] {
+			continue
+		}
+		inGroup[[2]string{key, w.VocabID}] = true
+
+		group := &breakdown.Readings[i]
+		group.Words = append(group.Words, w)
+		group.WordCount++
+		if w.Known {
+			group.KnownCount++
+		}
+		if !seen[w.VocabID] {
+			seen[w.VocabID] = true
+			breakdown.TotalWords++
+			if w.Known {
+				breakdown.KnownWords++
+			}
+		}
+	}
+
+	for i := range breakdown.Readings {
+		group := &breakdown.Readings[i]
+		// Without the on/kun split, go by how the words were read
+		if len(kanji.OnReadings) == 0 && len(group.Words) > 0 {
+			group.Type = group.Words[0].ReadingType
+		}
+		switch group.Type {
+		case models.KanjiReadingOn:
+			breakdown.OnReadings = append(breakdown.OnReadings, group.Reading)
+		case models.KanjiReadingKun:
+			breakdown.KunReadings = append(breakdown.KunReadings, group.Reading)
+		}
+	}
+	return breakdown, nil
+}
@@ -0,0 +1,482 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// keigoWord is one keigo verb with how it conjugates
+type keigoWord struct {
+	word, reading, group string
+}
+
+// keigoEntry is an everyday verb with special (suppletive) keigo verbs
+type keigoEntry struct {
+	plain           []string // Spellings of the everyday verb, canonical first
+	reading         string
+	group           string
+	sonkeigo        []keigoWord // nil: お〜になる
+	kenjougo        []keigoWord // nil: お〜する
+	noSonkeigo      bool        // あげる has no honorific of its own
+	noKenjougo      bool        // くれる has no humble form
+	regularKenjougo bool        // お〜する is fine alongside the special verbs (お聞きする)
+	noRareru        bool        // The honorific られる doesn't work (くれられる)
+	noPlain         bool        // Its keigo isn't turned back into it (くださる is a word of its own)
+}
+
+var (
+	keigoIrassharu    = keigoWord{"いらっしゃる", "いらっしゃる", models.ConjugationGodan}
+	keigoOideninaru   = keigoWord{"おいでになる", "おいでになる", models.ConjugationGodan}
+	keigoOkoshininaru = keigoWord{"お越しになる", "おこしになる", models.ConjugationGodan}
+	keigoMairu        = keigoWord{"参る", "まいる", models.ConjugationGodan}
+	keigoUkagau       = keigoWord{"伺う", "うかがう", models.ConjugationGodan}
+	keigoMeshiagaru   = keigoWord{"召し上がる", "めしあがる", models.ConjugationGodan}
+	keigoItadaku      = keigoWord{"いただく", "いただく", models.ConjugationGodan}
+	keigoZonjiru      = keigoWord{"存じる", "ぞんじる", models.ConjugationIchidan}
+)
+
+// keigoVerbs are the everyday verbs whose keigo isn't (only) お〜になる / お〜する
+var keigoVerbs = []keigoEntry{
+	{plain: []string{"行く", "いく"}, reading: "いく", group: models.ConjugationGodan,
+		sonkeigo: []keigoWord{keigoIrassharu, keigoOideninaru, keigoOkoshininaru},
+		kenjougo: []keigoWord{keigoMairu, keigoUkagau}},
+	{plain: []string{"来る", "くる"}, reading: "くる", group: models.ConjugationIrregular,
+		sonkeigo: []keigoWord{keigoIrassharu, keigoOideninaru, {"お見えになる", "おみえになる", models.ConjugationGodan}, keigoOkoshininaru},
+		kenjougo: []keigoWord{keigoMairu}},
+	{plain: []string{"いる", "居る"}, reading: "いる", group: models.ConjugationIchidan,
+		sonkeigo: []keigoWord{keigoIrassharu, keigoOideninaru},
+		kenjougo: []keigoWord{{"おる", "おる", models.ConjugationGodan}},
+		noRareru: true},
+	{plain: []string{"言う", "いう"}, reading: "いう", group: models.ConjugationGodan,
+		sonkeigo: []keigoWord{{"おっしゃる", "おっしゃる", models.ConjugationGodan}},
+		kenjougo: []keigoWord{{"申す", "もうす", models.ConjugationGodan}, {"申し上げる", "もうしあげる", models.ConjugationIchidan}}},
+	{plain: []string{"する"}, reading: "する", group: models.ConjugationIrregular,
+		sonkeigo: []keigoWord{{"なさる", "なさる", models.ConjugationGodan}},
+		kenjougo: []keigoWord{{"いたす", "いたす", models.ConjugationGodan}}},
+	{plain: []string{"食べる", "たべる"}, reading: "たべる", group: models.ConjugationIchidan,
+		sonkeigo: []keigoWord{keigoMeshiagaru},
+		kenjougo: []keigoWord{keigoItadaku}},
+	{plain: []string{"飲む", "のむ"}, reading: "のむ", group: models.ConjugationGodan,
+		sonkeigo: []keigoWord{keigoMeshiagaru},
+		kenjougo: []keigoWord{keigoItadaku}},
+	{plain: []string{"見る", "みる"}, reading: "みる", group: models.ConjugationIchidan,
+		sonkeigo: []keigoWord{{"ご覧になる", "ごらんになる", models.ConjugationGodan}},
+		kenjougo: []keigoWord{{"拝見する", "はいけんする", models.ConjugationIrregular}}},
+	{plain: []string{"会う", "あう"}, reading: "あう", group: models.ConjugationGodan,
+		kenjougo:        []keigoWord{{"お目にかかる", "おめにかかる", models.ConjugationGodan}},
+		regularKenjougo: true},
+	{plain: []string{"聞く", "きく"}, reading: "きく", group: models.ConjugationGodan,
+		kenjougo:        []keigoWord{keigoUkagau, {"拝聴する", "はいちょうする", models.ConjugationIrregular}},
+		regularKenjougo: true},
+	{plain: []string{"訪ねる", "たずねる"}, reading: "たずねる", group: models.ConjugationIchidan,
+		kenjougo:        []keigoWord{keigoUkagau},
+		regularKenjougo: true},
+	{plain: []string{"くれる", "呉れる"}, reading: "くれる", group: models.ConjugationIchidan,
+		sonkeigo:   []keigoWord{{"くださる", "くださる", models.ConjugationGodan}},
+		noKenjougo: true, noRareru: true, noPlain: true},
+	{plain: []string{"あげる", "上げる"}, reading: "あげる", group: models.ConjugationIchidan,
+		kenjougo:   []keigoWord{{"差し上げる", "さしあげる", models.ConjugationIchidan}},
+		noSonkeigo: true},
+	{plain: []string{"もらう", "貰う"}, reading: "もらう", group: models.ConjugationGodan,
+		kenjougo:   []keigoWord{keigoItadaku},
+		noSonkeigo: true},
+	{plain: []string{"寝る", "ねる"}, reading: "ねる", group: models.ConjugationIchidan,
+		sonkeigo:   []keigoWord{{"お休みになる", "おやすみになる", models.ConjugationGodan}},
+		noKenjougo: true},
+	{plain: []string{"着る", "きる"}, reading: "きる", group: models.ConjugationIchidan,
+		sonkeigo:   []keigoWord{{"お召しになる", "おめしになる", models.ConjugationGodan}},
+		noKenjougo: true},
+	{plain: []string{"死ぬ", "しぬ"}, reading: "しぬ", group: models.ConjugationGodan,
+		sonkeigo:   []keigoWord{{"お亡くなりになる", "おなくなりになる", models.ConjugationGodan}},
+		noKenjougo: true},
+	{plain: []string{"ある", "有る", "在る"}, reading: "ある", group: models.ConjugationGodan,
+		noKenjougo: true},
+	{plain: []string{"知る", "しる"}, reading: "しる", group: models.ConjugationGodan,
+		kenjougo:   []keigoWord{keigoZonjiru, {"存じ上げる", "ぞんじあげる", models.ConjugationIchidan}},
+		noSonkeigo: true},
+	{plain: []string{"思う", "おもう"}, reading: "おもう", group: models.ConjugationGodan,
+		kenjougo: []keigoWord{keigoZonjiru}},
+	{plain: []string{"分かる", "わかる"}, reading: "わかる", group: models.ConjugationGodan,
+		kenjougo: []keigoWord{{"承知する", "しょうちする", models.ConjugationIrregular}, {"かしこまる", "かしこまる", models.ConjugationGodan}}},
+	{plain: []string{"見せる", "みせる"}, reading: "みせる", group: models.ConjugationIchidan,
+		kenjougo:        []keigoWord{{"お目にかける", "おめにかける", models.ConjugationIchidan}, {"ご覧に入れる", "ごらんにいれる", models.ConjugationIchidan}},
+		regularKenjougo: true},
+	{plain: []string{"借りる", "かりる"}, reading: "かりる", group: models.ConjugationIchidan,
+		kenjougo:        []keigoWord{{"拝借する", "はいしゃくする", models.ConjugationIrregular}},
+		regularKenjougo: true},
+}
+
+// keigoOPrefixNouns are する-nouns that take お rather than ご (お電話する)
+var keigoOPrefixNouns = []string{"電話", "約束", "世話"}
+
+// keigoHumbleVerbs are the verbs whose action is done for or toward someone,
+// the only ones お〜する suits: お待ちする, but not お帰りする
+var keigoHumbleVerbs = [][2]string{
+	{"待つ", "まつ"}, {"持つ", "もつ"}, {"送る", "おくる"}, {"届ける", "とどける"},
+	{"知らせる", "しらせる"}, {"貸す", "かす"}, {"借りる", "かりる"}, {"返す", "かえす"},
+	{"渡す", "わたす"}, {"手伝う", "てつだう"}, {"願う", "ねがう"}, {"話す", "はなす"},
+	{"伝える", "つたえる"}, {"聞く", "きく"}, {"尋ねる", "たずねる"}, {"訪ねる", "たずねる"},
+	{"会う", "あう"}, {"見せる", "みせる"}, {"呼ぶ", "よぶ"}, {"誘う", "さそう"},
+	{"預かる", "あずかる"}, {"預ける", "あずける"}, {"教える", "おしえる"}, {"答える", "こたえる"},
+	{"招く", "まねく"}, {"頼む", "たのむ"}, {"助ける", "たすける"}, {"迎える", "むかえる"},
+	{"見送る", "みおくる"}, {"連れる", "つれる"}, {"取る", "とる"}, {"出す", "だす"},
+	{"入れる", "いれる"}, {"作る", "つくる"}, {"書く", "かく"}, {"読む", "よむ"},
+	{"運ぶ", "はこぶ"}, {"包む", "つつむ"}, {"勧める", "すすめる"}, {"祈る", "いのる"},
+	{"詫びる", "わびる"},
+}
+
+// keigoHumbleNouns are the する-nouns that take ご〜する (ご案内する); other
+// する-verbs are made humble with いたす (勉強いたす)
+var keigoHumbleNouns = []string{
+	"案内", "説明", "連絡", "相談", "報告", "紹介", "招待", "返事", "用意", "協力",
+	"確認", "提案", "依頼", "質問", "送付", "電話", "約束", "世話",
+}
+
+// isHumbleVerb reports whether a verb takes お〜する
+func isHumbleVerb(word string) bool {
+	for _, v := range keigoHumbleVerbs {
+		if word == v[0] || word == v[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// isHumbleNoun reports whether a する-noun takes ご〜する
+func isHumbleNoun(noun string) bool {
+	for _, n := range keigoHumbleNouns {
+		if noun == n {
+			return true
+		}
+	}
+	return false
+}
+
+// findKeigoEntry finds the special keigo of an everyday verb
+func findKeigoEntry(word, group string) *keigoEntry {
+	for i := range keigoVerbs {
+		e := &keigoVerbs[i]
+		if e.group != group {
+			continue
+		}
+		for _, p := range e.plain {
+			if word == p {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// keigoEntriesFor finds the everyday verbs a special keigo verb stands for
+// (いらっしゃる: 行く, 来る, いる)
+func keigoEntriesFor(word string) (entries []*keigoEntry, direction string) {
+	for i := range keigoVerbs {
+		e := &keigoVerbs[i]
+		for _, d := range []string{"sonkeigo", "kenjougo"} {
+			for _, kw := range e.special(d) {
+				if kw.word == word || kw.reading == word {
+					entries = append(entries, e)
+					direction = d
+				}
+			}
+		}
+	}
+	return entries, direction
+}
+
+func (e *keigoEntry) special(direction string) []keigoWord {
+	if direction == "sonkeigo" {
+		return e.sonkeigo
+	}
+	return e.kenjougo
+}
+
+// isKeigoWord reports whether a verb is already keigo, so お〜になる would double
+// it: a special keigo verb, or お/ご before a kanji (お休みになる, ご覧になる)
+func isKeigoWord(word string) bool {
+	runes := []rune(word)
+	if len(runes) > 1 && strings.ContainsRune("おご御", runes[0]) && isKanjiRune(runes[1]) {
+		return true
+	}
+	entries, _ := keigoEntriesFor(word)
+	return len(entries) > 0
+}
+
+// regularKeigo builds a verb's keigo without a special verb, canonical first.
+// Sonkeigo is お + masu stem + になる, or noun + なさる for する-verbs (勉強なさる).
+// Kenjougo is お + masu stem + する, only for verbs done for someone (お待ちする);
+// する-verbs take ご + noun + する when the noun is one of those (ご案内する),
+// and noun + いたす otherwise (勉強いたす). Verbs with a one-kana stem (見る,
+// 寝る) and verbs that are already keigo have no regular keigo.
+func regularKeigo(word, reading, group, direction string) []keigoWord {
+	if isKeigoWord(word) {
+		return nil
+	}
+
+	if group == models.ConjugationIrregular {
+		noun := strings.TrimSuffix(word, "する")
+		nounReading := strings.TrimSuffix(reading, "する")
+		if noun == word || noun == "" || !containsKanji(noun) || nounReading == reading {
+			return nil
+		}
+		if direction == "sonkeigo" {
+			return []keigoWord{{noun + "なさる", nounReading + "なさる", models.ConjugationGodan}}
+		}
+		itasu := keigoWord{noun + "いたす", nounReading + "いたす", models.ConjugationGodan}
+		if !isHumbleNoun(noun) {
+			return []keigoWord{itasu}
+		}
+		if kw, ok := wrapKeigo(word, reading, group, direction); ok {
+			return []keigoWord{kw, itasu}
+		}
+		return []keigoWord{itasu}
+	}
+
+	if direction == "kenjougo" && !isHumbleVerb(word) {
+		return nil
+	}
+	if kw, ok := wrapKeigo(word, reading, group, direction); ok {
+		return []keigoWord{kw}
+	}
+	return nil
+}
+
+// wrapKeigo applies the お〜になる / お〜する pattern (ご for する-verbs) to any
+// verb, whether or not it suits it; keigoMistake uses it to spot the pattern
+// where it doesn't belong
+func wrapKeigo(word, reading, group, direction string) (keigoWord, bool) {
+	ending := keigoEnding(direction)
+	endingGroup := models.ConjugationGodan
+	if direction == "kenjougo" {
+		endingGroup = models.ConjugationIrregular
+	}
+
+	switch group {
+	case models.ConjugationIrregular:
+		noun := strings.TrimSuffix(word, "する")
+		nounReading := strings.TrimSuffix(reading, "する")
+		if noun == word || noun == "" || !containsKanji(noun) || nounReading == reading {
+			return keigoWord{}, false
+		}
+		prefix, prefixReading := "ご", "ご"
+		for _, n := range keigoOPrefixNouns {
+			if noun == n {
+				prefix, prefixReading = "お", "お"
+			}
+		}
+		return keigoWord{prefix + noun + ending, prefixReading + nounReading + ending, endingGroup}, true
+	case models.ConjugationGodan, models.ConjugationIchidan:
+		t := traitsOf(word, reading)
+		written := conjugateSurface(word, group, t)
+		kana := conjugateSurface(reading, group, t)
+		if written == nil || kana == nil {
+			return keigoWord{}, false
+		}
+		stem := strings.TrimSuffix(written["polite"][0], "ます")
+		stemReading := strings.TrimSuffix(kana["polite"][0], "ます")
+		if len([]rune(stemReading)) < 2 {
+			return keigoWord{}, false
+		}
+		return keigoWord{"お" + stem + ending, "お" + stemReading + ending, endingGroup}, true
+	}
+	return keigoWord{}, false
+}
+
+// keigoAnswers lists the accepted keigo verbs for one direction, canonical
+// first. special is true when the verb has its own keigo verbs.
+func keigoAnswers(word, reading, group, direction string) (answers []keigoWord, special bool) {
+	e := findKeigoEntry(word, group)
+	if e != nil {
+		if (direction == "sonkeigo" && e.noSonkeigo) || (direction == "kenjougo" && e.noKenjougo) {
+			return nil, true
+		}
+		answers = append(answers, e.special(direction)...)
+		special = len(answers) > 0
+	}
+	if len(answers) == 0 || (direction == "kenjougo" && e.regularKenjougo) {
+		answers = append(answers, regularKeigo(word, reading, group, direction)...)
+	}
+	return answers, special
+}
+
+// politeKeigo adds ます to a keigo verb (いらっしゃいます, お書きします)
+func politeKeigo(kw keigoWord) (surface, reading string) {
+	t := traitsOf(kw.word, kw.reading)
+	written := conjugateSurface(kw.word, kw.group, t)
+	kana := conjugateSurface(kw.reading, kw.group, t)
+	if written == nil || kana == nil {
+		return "", ""
+	}
+	return written["polite"][0], kana["polite"][0]
+}
+
+// keigoForms generates the keigo forms of a verb for ConjugateWord. The
+// honorific られる form (書かれる) is accepted as a lighter 尊敬語.
+func keigoForms(word, reading, group string, written map[string][]string) []models.ConjugatedForm {
+	if group != models.ConjugationGodan && group != models.ConjugationIchidan && group != models.ConjugationIrregular {
+		return nil
+	}
+
+	var forms []models.ConjugatedForm
+	for _, direction := range []string{"sonkeigo", "kenjougo"} {
+		answers, special := keigoAnswers(word, reading, group, direction)
+		if len(answers) == 0 {
+			continue
+		}
+		label := conjugationFormName(direction)
+		var hint string
+		switch {
+		case special:
+			hint = fmt.Sprintf("Special %s: %s → %s", label, word, answers[0].word)
+		case group == models.ConjugationIrregular:
+			noun := strings.TrimSuffix(word, "する")
+			if ending := strings.TrimPrefix(answers[0].word, noun); ending != answers[0].word {
+				hint = fmt.Sprintf("%s: %s + %s", label, noun, ending)
+			} else {
+				hint = fmt.Sprintf("%s: %s + %s + %s", label, string([]rune(answers[0].word)[:1]), noun, keigoEnding(direction))
+			}
+		default:
+			hint = fmt.Sprintf("%s: お + masu stem + %s", label, keigoEnding(direction))
+		}
+
+		plain := models.ConjugatedForm{Form: direction, Surface: answers[0].word, Reading: answers[0].reading, Hint: hint}
+		politeSurface, politeReading := politeKeigo(answers[0])
+		polite := models.ConjugatedForm{Form: direction + "_polite", Surface: politeSurface, Reading: politeReading, Hint: hint + " + ます"}
+		for _, kw := range answers[1:] {
+			s, _ := politeKeigo(kw)
+			plain.Alternatives = append(plain.Alternatives, kw.word)
+			polite.Alternatives = append(polite.Alternatives, s)
+		}
+		if e := findKeigoEntry(word, group); direction == "sonkeigo" && len(written["passive"]) > 0 && (e == nil || !e.noRareru) {
+			passive := written["passive"][0]
+			plain.Alternatives = append(plain.Alternatives, passive)
+			polite.Alternatives = append(polite.Alternatives, strings.TrimSuffix(passive, "る")+"ます")
+		}
+		forms = append(forms, plain)
+		if politeSurface != "" {
+			forms = append(forms, polite)
+		}
+	}
+
+	if entries, direction := keigoEntriesFor(word); len(entries) > 0 {
+		var plains []string
+		var plainReading string
+		for _, e := range entries {
+			if e.noPlain {
+				continue
+			}
+			if plains == nil {
+				plainReading = e.reading
+			}
+			plains = append(plains, e.plain[0])
+		}
+		if len(plains) == 0 {
+			return forms
+		}
+		forms = append(forms, models.ConjugatedForm{
+			Form:         "plain",
+			Surface:      plains[0],
+			Reading:      plainReading,
+			Alternatives: plains[1:],
+			Hint:         fmt.Sprintf("%s is the %s of %s", word, conjugationFormName(direction), strings.Join(plains, " / ")),
+		})
+	}
+	return forms
+}
+
+func keigoEnding(direction string) string {
+	if direction == "kenjougo" {
+		return "する"
+	}
+	return "になる"
+}
+
+// isKeigoForm reports whether a form is one of the keigo conversions
+func isKeigoForm(form string) bool {
+	for _, f := range models.GetKeigoForms() {
+		if f.Name == form {
+			return true
+		}
+	}
+	return false
+}
+
+// keigoMistake spots keigo-specific errors: お〜になる on a verb with special
+// keigo (お行きになる), or keigo stacked on keigo (お召し上がりになる)
+func keigoMistake(ch *models.ConjugationChallenge, given string) (conjugationVerdict, bool) {
+	direction := strings.TrimSuffix(ch.TargetForm, "_polite")
+	if direction != "sonkeigo" && direction != "kenjougo" {
+		return conjugationVerdict{}, false
+	}
+	polite := direction != ch.TargetForm
+	matches := func(kw keigoWord) bool {
+		surfaces := []string{kw.word, kw.reading}
+		if polite {
+			s, r := politeKeigo(kw)
+			surfaces = []string{s, r}
+		}
+		for _, s := range surfaces {
+			if s != "" && katakanaToHiragana(s) == given {
+				return true
+			}
+		}
+		return false
+	}
+	label := conjugationFormName(direction)
+
+	answers, special := keigoAnswers(ch.BaseForm, ch.Reading, ch.Group, direction)
+	if special {
+		// The regular pattern, as if the verb had no keigo of its own
+		if regular, ok := wrapKeigo(ch.BaseForm, ch.Reading, ch.Group, direction); ok && matches(regular) {
+			return conjugationVerdict{
+				Category:    models.ConjugationErrorSuppletive,
+				Explanation: fmt.Sprintf("%s has its own %s: %s, not %s.", ch.BaseForm, label, ch.FullAnswer, given),
+			}, true
+		}
+	}
+
+	for _, kw := range answers {
+		for _, d := range []string{"sonkeigo", "kenjougo"} {
+			if doubled, ok := wrapKeigo(kw.word, kw.reading, kw.group, d); ok && matches(doubled) {
+				return conjugationVerdict{
+					Category: models.ConjugationErrorDoubleKeigo,
+					Explanation: fmt.Sprintf("%s is already keigo, so it doesn't take お〜%s as well: %s, not %s.",
+						kw.word, keigoEnding(d), ch.FullAnswer, given),
+				}, true
+			}
+		}
+	}
+	return conjugationVerdict{}, false
+}
+
+// keigoReference lists the keigo forms and every verb with special keigo
+func keigoReference() *models.KeigoReference {
+	ref := &models.KeigoReference{Forms: models.GetKeigoForms()}
+	words := func(kws []keigoWord) []string {
+		var out []string
+		for _, kw := range kws {
+			out = append(out, kw.word)
+		}
+		return out
+	}
+	for _, e := range keigoVerbs {
+		v := models.KeigoVerb{Plain: e.plain[0], Reading: e.reading}
+		if !e.noSonkeigo {
+			v.Sonkeigo = words(e.sonkeigo)
+		}
+		if !e.noKenjougo {
+			v.Kenjougo = words(e.kenjougo)
+		}
+		if kws := regularKeigo(e.plain[0], e.reading, e.group, "sonkeigo"); len(kws) > 0 && len(v.Sonkeigo) == 0 && !e.noSonkeigo {
+			v.Sonkeigo = []string{kws[0].word}
+		}
+		if kws := regularKeigo(e.plain[0], e.reading, e.group, "kenjougo"); len(kws) > 0 && e.regularKenjougo {
+			v.Kenjougo = append(v.Kenjougo, words(kws)...)
+		}
+		ref.SpecialVerbs = append(ref.SpecialVerbs, v)
+	}
+	return ref
+}
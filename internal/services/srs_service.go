@@ -12,6 +12,7 @@ type SRSService struct {
 	srsRepo     *repository.SRSRepository
 	vocabRepo   *repository.VocabRepository
 	grammarRepo *repository.GrammarRepository
+	kanjiRepo   *repository.KanjiRepository
 	userRepo    *repository.UserRepository
 }
 
@@ -19,12 +20,14 @@ func NewSRSService(
 	srsRepo *repository.SRSRepository,
 	vocabRepo *repository.VocabRepository,
 	grammarRepo *repository.GrammarRepository,
+	kanjiRepo *repository.KanjiRepository,
 	userRepo *repository.UserRepository,
 ) *SRSService {
 	return &SRSService{
 		srsRepo:     srsRepo,
 		vocabRepo:   vocabRepo,
 		grammarRepo: grammarRepo,
+		kanjiRepo:   kanjiRepo,
 		userRepo:    userRepo,
 	}
 }
@@ -140,6 +143,12 @@ func (s *SRSService) GetReviewQueue(userID string, limit int) (*models.SRSQueueR
 				continue
 			}
 			item.Data = pattern
+		} else if sched.ItemType == "kanji" {
+			kanji, err := s.kanjiRepo.GetKanjiByID(sched.ItemID)
+			if err != nil {
+				continue
+			}
+			item.Data = kanji
 		}
 
 		response.DueItems = append(response.DueItems, item)
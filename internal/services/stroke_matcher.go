@@ -0,0 +1,323 @@
+package services
+
+import (
+	"math"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+)
+
+// Strokes are compared on the 100x100 canvas the reference data uses
+const (
+	strokeSamplePoints = 32 // Both paths are resampled to this many evenly spaced points
+	strokeSegments     = 8  // The reference is split into this many stretches for feedback
+
+	strokePathTolerance    = 25.0 // Mean distance from the reference at which the path score reaches 0
+	strokeShapeTolerance   = 0.35 // The same, for the size-normalised shape
+	strokeSegmentTolerance = 10.0 // A stretch further off than this is flagged
+	strokeTurningTolerance = 2.0  // Difference in overall turning (radians) at which the curvature score reaches 0
+
+	// A stroke drawn from the wrong end is reversed when matching it backwards
+	// is this much closer than forwards, and can score no more than the cap
+	strokeReversedRatio       = 0.7
+	reversedStrokeMaxAccuracy = 40.0
+
+	strokeCurveBend      = 0.12 // A stroke bulging more than this share of its length from its chord is a curve
+	strokeAxisAngleSlack = 20.0 // Degrees off horizontal or vertical still counted as along the axis
+)
+
+// strokeMatch is the outcome of comparing a drawn stroke with a reference stroke
+type strokeMatch struct {
+	Accuracy       float64 // 0-100
+	Distance       float64 // Mean DTW distance in canvas units
+	PathScore      float64 // Position and shape on the canvas
+	ShapeScore     float64 // Shape alone, ignoring where and how big
+	CurvatureScore float64 // How much the stroke turns (hooks, curves)
+	LengthScore    float64
+	Reversed       bool
+	Segments       []models.StrokeSegment
+}
+
+// strokeReferencePath is the path of a reference stroke; strokes stored
+// without one are taken as a straight line from start to end
+func strokeReferencePath(ref *models.Stroke) []models.Point {
+	if len(ref.Path) >= 2 {
+		return ref.Path
+	}
+	return []models.Point{ref.StartPoint, ref.EndPoint}
+}
+
+// matchStroke compares a drawn path with a reference path along their whole
+// length. Both are resampled, aligned with dynamic time warping (on the canvas
+// and size-normalised), and compared by how much they turn, so a hook (亅)
+// drawn straight or a curve drawn as a line loses points even when the ends
+// are in the right place.
+func matchStroke(refPath, userPath []models.Point) strokeMatch {
+	if len(userPath) < 2 || len(refPath) < 1 {
+		return strokeMatch{}
+	}
+	ref := resampleStroke(refPath, strokeSamplePoints)
+	user := resampleStroke(userPath, strokeSamplePoints)
+
+	forward, alignment := dtwDistance(ref, user)
+	backward, backAlignment := dtwDistance(ref, reversePoints(user))
+
+	m := strokeMatch{Distance: forward}
+	refLength, userLength := pathLength(ref), pathLength(user)
+	// A stroke that ends where it starts (a loop) can't be told apart from its reverse
+	if backward < forward*strokeReversedRatio && distance(ref[0], ref[len(ref)-1]) > refLength*0.15 {
+		m.Reversed = true
+		m.Distance = backward
+		user = reversePoints(user)
+		alignment = backAlignment
+	}
+
+	m.PathScore = toleranceScore(m.Distance, strokePathTolerance)
+	shapeDistance, _ := dtwDistance(normaliseStroke(ref), normaliseStroke(user))
+	m.ShapeScore = toleranceScore(shapeDistance, strokeShapeTolerance)
+	m.CurvatureScore = toleranceScore(math.Abs(totalTurning(ref)-totalTurning(user)), strokeTurningTolerance)
+	if refLength > 0 && userLength > 0 {
+		m.LengthScore = math.Min(refLength, userLength) / math.Max(refLength, userLength) * 100
+	}
+	m.Segments = strokeSegmentDeviations(ref, user, alignment)
+
+	// Missing a hook or curve costs a share of the whole score, however
+	// close the rest of the stroke is
+	m.Accuracy = (m.PathScore*0.5 + m.ShapeScore*0.3 + m.LengthScore*0.2) * (0.6 + 0.4*m.CurvatureScore/100)
+	if m.Reversed {
+		m.Accuracy = math.Min(m.Accuracy, reversedStrokeMaxAccuracy)
+	}
+	m.Accuracy = math.Min(100, math.Max(0, m.Accuracy))
+	return m
+}
+
+// StrokeDirection classifies a stroke as the reference data labels it: curve
+// when it bends away from the line between its ends, otherwise horizontal,
+// vertical or diagonal by the angle of that line
+func StrokeDirection(path []models.Point) string {
+	if len(path) < 2 {
+		return "curve"
+	}
+	start, end := path[0], path[len(path)-1]
+	dx, dy := end.X-start.X, end.Y-start.Y
+	chord := math.Hypot(dx, dy)
+	if chord < 1e-9 {
+		return "curve"
+	}
+
+	bulge := 0.0
+	for _, p := range path {
+		// Distance from the chord through start and end
+		bulge = math.Max(bulge, math.Abs(dx*(p.Y-start.Y)-dy*(p.X-start.X))/chord)
+	}
+	if bulge > chord*strokeCurveBend {
+		return "curve"
+	}
+
+	angle := math.Abs(math.Atan2(dy, dx) * 180 / math.Pi)
+	switch {
+	case angle <= strokeAxisAngleSlack || angle >= 180-strokeAxisAngleSlack:
+		return "horizontal"
+	case math.Abs(angle-90) <= strokeAxisAngleSlack:
+		return "vertical"
+	default:
+		return "diagonal"
+	}
+}
+
+// toleranceScore maps a distance to 100 (no distance) down to 0 (at tolerance)
+func toleranceScore(d, tolerance float64) float64 {
+	return math.Max(0, 1-d/tolerance) * 100
+}
+
+func distance(a, b models.Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+func pathLength(path []models.Point) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		total += distance(path[i-1], path[i])
+	}
+	return total
+}
+
+func reversePoints(path []models.Point) []models.Point {
+	out := make([]models.Point, len(path))
+	for i, p := range path {
+		out[len(path)-1-i] = p
+	}
+	return out
+}
+
+// resampleStroke spaces n points evenly along a path, so how fast the stroke
+// was drawn (how many points the client sent) doesn't matter
+func resampleStroke(path []models.Point, n int) []models.Point {
+	out := make([]models.Point, 0, n)
+	total := pathLength(path)
+	if len(path) == 1 || total == 0 {
+		for i := 0; i < n; i++ {
+			out = append(out, path[0])
+		}
+		return out
+	}
+
+	step := total / float64(n-1)
+	out = append(out, path[0])
+	walked, target := 0.0, step
+	for i := 1; i < len(path) && len(out) < n-1; i++ {
+		a, b := path[i-1], path[i]
+		seg := distance(a, b)
+		for seg > 0 && walked+seg >= target && len(out) < n-1 {
+			t := (target - walked) / seg
+			out = append(out, models.Point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t})
+			target += step
+		}
+		walked += seg
+	}
+	for len(out) < n {
+		out = append(out, path[len(path)-1])
+	}
+	return out
+}
+
+// normaliseStroke centres a path on its centroid and scales its larger side
+// to 1, keeping the aspect ratio so a horizontal line stays horizontal
+func normaliseStroke(path []models.Point) []models.Point {
+	var cx, cy float64
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range path {
+		cx += p.X
+		cy += p.Y
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	cx /= float64(len(path))
+	cy /= float64(len(path))
+	scale := math.Max(maxX-minX, maxY-minY)
+	if scale < 1e-9 {
+		scale = 1
+	}
+
+	out := make([]models.Point, len(path))
+	for i, p := range path {
+		out[i] = models.Point{X: (p.X - cx) / scale, Y: (p.Y - cy) / scale}
+	}
+	return out
+}
+
+// dtwDistance aligns two paths with dynamic time warping and returns the mean
+// distance between aligned points, with the alignment as (a, b) index pairs
+func dtwDistance(a, b []models.Point) (float64, [][2]int) {
+	n, m := len(a), len(b)
+	cost := make([][]float64, n+1)
+	for i := range cost {
+		cost[i] = make([]float64, m+1)
+		for j := range cost[i] {
+			cost[i][j] = math.Inf(1)
+		}
+	}
+	cost[0][0] = 0
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			best := math.Min(cost[i-1][j-1], math.Min(cost[i-1][j], cost[i][j-1]))
+			cost[i][j] = distance(a[i-1], b[j-1]) + best
+		}
+	}
+
+	// Walk back from the end to recover which points were matched
+	var alignment [][2]int
+	i, j := n, m
+	for i > 0 && j > 0 {
+		alignment = append(alignment, [2]int{i - 1, j - 1})
+		switch diag, up, left := cost[i-1][j-1], cost[i-1][j], cost[i][j-1]; {
+		case diag <= up && diag <= left:
+			i, j = i-1, j-1
+		case up <= left:
+			i--
+		default:
+			j--
+		}
+	}
+	for k, l := 0, len(alignment)-1; k < l; k, l = k+1, l-1 {
+		alignment[k], alignment[l] = alignment[l], alignment[k]
+	}
+	return cost[n][m] / float64(len(alignment)), alignment
+}
+
+// totalTurning is how far a path turns overall, in radians, signed so that a
+// wobbly hand cancels itself out while a hook (亅) or a curve (乚) adds up.
+// Headings are taken over a sliding chord to smooth the path further.
+func totalTurning(path []models.Point) float64 {
+	path = smoothStroke(path)
+	chord := max(len(path)/strokeSegments, 1)
+	var headings []float64
+	for i := 0; i+chord < len(path); i++ {
+		dx, dy := path[i+chord].X-path[i].X, path[i+chord].Y-path[i].Y
+		if math.Hypot(dx, dy) < 1e-9 {
+			continue
+		}
+		headings = append(headings, math.Atan2(dy, dx))
+	}
+	total := 0.0
+	for i := 1; i < len(headings); i++ {
+		turn := headings[i] - headings[i-1]
+		for turn > math.Pi {
+			turn -= 2 * math.Pi
+		}
+		for turn < -math.Pi {
+			turn += 2 * math.Pi
+		}
+		total += turn
+	}
+	return total
+}
+
+// smoothStroke averages each point with its neighbours, keeping the ends
+func smoothStroke(path []models.Point) []models.Point {
+	if len(path) < 3 {
+		return path
+	}
+	out := make([]models.Point, len(path))
+	out[0], out[len(path)-1] = path[0], path[len(path)-1]
+	for i := 1; i < len(path)-1; i++ {
+		out[i] = models.Point{
+			X: (path[i-1].X + path[i].X + path[i+1].X) / 3,
+			Y: (path[i-1].Y + path[i].Y + path[i+1].Y) / 3,
+		}
+	}
+	return out
+}
+
+// strokeSegmentDeviations splits the reference into stretches and averages how
+// far the drawn points aligned with each stretch are from it
+func strokeSegmentDeviations(ref, user []models.Point, alignment [][2]int) []models.StrokeSegment {
+	perSegment := (len(ref) + strokeSegments - 1) / strokeSegments
+	sums := make([]float64, strokeSegments)
+	counts := make([]int, strokeSegments)
+	for _, pair := range alignment {
+		s := min(pair[0]/perSegment, strokeSegments-1)
+		sums[s] += distance(ref[pair[0]], user[pair[1]])
+		counts[s]++
+	}
+
+	segments := make([]models.StrokeSegment, 0, strokeSegments)
+	for s := 0; s < strokeSegments; s++ {
+		start := s * perSegment
+		if start >= len(ref) {
+			break
+		}
+		end := min(start+perSegment, len(ref)-1)
+		deviation := 0.0
+		if counts[s] > 0 {
+			deviation = sums[s] / float64(counts[s])
+		}
+		segments = append(segments, models.StrokeSegment{
+			Index:     s,
+			Start:     ref[start],
+			End:       ref[end],
+			Deviation: math.Round(deviation*10) / 10,
+			OffTrack:  deviation > strokeSegmentTolerance,
+		})
+	}
+	return segments
+}
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"github.com/erwinwahyura/daily-kotoba/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultQuizQuestions = 10
+	maxQuizQuestions     = 50
+	quizOptionCount      = 4
+)
+
+// VocabQuizService generates multiple-choice vocabulary quizzes
+type VocabQuizService struct {
+	quizRepo  *repository.VocabQuizRepository
+	vocabRepo *repository.VocabRepository
+	srsRepo   *repository.SRSRepository
+	userRepo  *repository.UserRepository
+}
+
+// NewVocabQuizService creates a new quiz service
+func NewVocabQuizService(
+	quizRepo *repository.VocabQuizRepository,
+	vocabRepo *repository.VocabRepository,
+	srsRepo *repository.SRSRepository,
+	userRepo *repository.UserRepository,
+) *VocabQuizService {
+	return &VocabQuizService{
+		quizRepo:  quizRepo,
+		vocabRepo: vocabRepo,
+		srsRepo:   srsRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// StartQuiz generates a quiz from a JLPT level or from the user's SRS deck.
+// types limits the question types; empty means all of them.
+func (s *VocabQuizService) StartQuiz(userID, deck, level string, count int, types []string) (*models.VocabQuizStartResponse, error) {
+	if count <= 0 {
+		count = defaultQuizQuestions
+	}
+	if count > maxQuizQuestions {
+		count = maxQuizQuestions
+	}
+	if len(types) == 0 {
+		types = []string{models.QuizTypeMeaningToWord, models.QuizTypeWordToMeaning, models.QuizTypeReading}
+	}
+	for _, t := range types {
+		switch t {
+		case models.QuizTypeMeaningToWord, models.QuizTypeWordToMeaning, models.QuizTypeReading:
+		default:
+			return nil, fmt.Errorf("invalid question type: %s", t)
+		}
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	pools := make(map[string][]models.Vocabulary)
+
+	var targets []models.Vocabulary
+	switch deck {
+	case "", "level":
+		deck = "level"
+		if level == "" {
+			user, err := s.userRepo.GetByID(userID)
+			if err != nil {
+				return nil, err
+			}
+			level = user.CurrentLevel
+		}
+		pool, err := s.levelPool(pools, level)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, pool...)
+		r.Shuffle(len(targets), func(i, j int) { targets[i], targets[j] = targets[j], targets[i] })
+	case "srs":
+		level = ""
+		ids, err := s.srsRepo.GetItemIDsByType(userID, "vocabulary", 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SRS deck: %w", err)
+		}
+		r.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+		for _, id := range ids {
+			if len(targets) >= count {
+				break
+			}
+			v, err := s.vocabRepo.GetByID(id)
+			if err != nil {
+				continue
+			}
+			pool, err := s.levelPool(pools, v.JLPTLevel)
+			if err != nil {
+				return nil, err
+			}
+			// Prefer the pool copy, which carries related words and word type
+			for i := range pool {
+				if pool[i].ID == id {
+					v = &pool[i]
+					break
+				}
+			}
+			targets = append(targets, *v)
+		}
+	default:
+		return nil, fmt.Errorf("invalid deck: %s", deck)
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no vocabulary available for this quiz")
+	}
+
+	questions := make([]models.VocabQuizQuestion, 0, count)
+	for i, target := range targets {
+		if len(questions) >= count {
+			break
+		}
+		qType := types[(i+r.Intn(len(types)))%len(types)]
+		written, kana := vocabForms(&target)
+		if qType == models.QuizTypeReading && written == kana {
+			// Kana-only words have nothing to read; ask for the meaning instead
+			qType = models.QuizTypeWordToMeaning
+		}
+
+		q, ok := s.buildQuestion(r, pools, &target, qType)
+		if ok {
+			questions = append(questions, q)
+		}
+	}
+
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("not enough vocabulary to build distractors")
+	}
+
+	session := &models.VocabQuizSession{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Deck:           deck,
+		JLPTLevel:      level,
+		Questions:      questions,
+		Answers:        []models.VocabQuizAnswer{},
+		TotalQuestions: len(questions),
+		Status:         "in_progress",
+		StartedAt:      time.Now(),
+	}
+	if err := s.quizRepo.CreateSession(session); err != nil {
+		return nil, fmt.Errorf("failed to create quiz session: %w", err)
+	}
+
+	prompts := make([]models.VocabQuizPrompt, len(questions))
+	for i, q := range questions {
+		prompts[i] = models.VocabQuizPrompt{ID: q.ID, Type: q.Type, Prompt: q.Prompt, Options: q.Options}
+	}
+
+	return &models.VocabQuizStartResponse{
+		SessionID: session.ID,
+		Deck:      deck,
+		JLPTLevel: level,
+		Questions: prompts,
+	}, nil
+}
+
+// SubmitAnswer grades one answer. Missed words are pushed into the SRS queue.
+func (s *VocabQuizService) SubmitAnswer(userID, sessionID, questionID string, selected int) (*models.VocabQuizAnswerResult, error) {
+	session, err := s.quizRepo.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, fmt.Errorf("quiz session not found")
+	}
+	if session.Status == "completed" {
+		return nil, fmt.Errorf("quiz already completed")
+	}
+
+	var question *models.VocabQuizQuestion
+	for i := range session.Questions {
+		if session.Questions[i].ID == questionID {
+			question = &session.Questions[i]
+			break
+		}
+	}
+	if question == nil {
+		return nil, fmt.Errorf("question not found")
+	}
+	for _, a := range session.Answers {
+		if a.QuestionID == questionID {
+			return nil, fmt.Errorf("question already answered")
+		}
+	}
+	if selected < 0 || selected >= len(question.Options) {
+		return nil, fmt.Errorf("invalid option: %d", selected)
+	}
+
+	answer := models.VocabQuizAnswer{
+		QuestionID:    questionID,
+		VocabID:       question.VocabID,
+		Selected:      selected,
+		IsCorrect:     selected == question.Correct,
+		CorrectAnswer: question.Options[question.Correct],
+		Timestamp:     time.Now(),
+	}
+	if !answer.IsCorrect {
+		if err := s.srsRepo.RequeueItem(userID, question.VocabID, "vocabulary"); err == nil {
+			answer.AddedToSRS = true
+		}
+	}
+
+	session.Answers = append(session.Answers, answer)
+
+	correctCount := 0
+	for _, a := range session.Answers {
+		if a.IsCorrect {
+			correctCount++
+		}
+	}
+	session.Score = correctCount * 100 / len(session.Questions)
+
+	if len(session.Answers) >= len(session.Questions) {
+		session.Status = "completed"
+		now := time.Now()
+		session.CompletedAt = &now
+	}
+
+	if err := s.quizRepo.UpdateSession(session); err != nil {
+		return nil, fmt.Errorf("failed to update quiz session: %w", err)
+	}
+
+	return &models.VocabQuizAnswerResult{
+		QuestionID:    questionID,
+		IsCorrect:     answer.IsCorrect,
+		Correct:       question.Correct,
+		CorrectAnswer: answer.CorrectAnswer,
+		AddedToSRS:    answer.AddedToSRS,
+		Answered:      len(session.Answers),
+		Total:         len(session.Questions),
+		Score:         session.Score,
+		Completed:     session.Status == "completed",
+	}, nil
+}
+
+// GetSession returns a quiz session owned by the user (without answer keys)
+func (s *VocabQuizService) GetSession(userID, sessionID string) (*models.VocabQuizSession, error) {
+	session, err := s.quizRepo.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, fmt.Errorf("quiz session not found")
+	}
+	return session, nil
+}
+
+func (s *VocabQuizService) levelPool(pools map[string][]models.Vocabulary, level string) ([]models.Vocabulary, error) {
+	if pool, ok := pools[level]; ok {
+		return pool, nil
+	}
+	pool, err := s.vocabRepo.GetAllByLevel(level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vocabulary: %w", err)
+	}
+	pools[level] = pool
+	return pool, nil
+}
+
+// buildQuestion creates a question for target with up to three distractors
+func (s *VocabQuizService) buildQuestion(r *rand.Rand, pools map[string][]models.Vocabulary, target *models.Vocabulary, qType string) (models.VocabQuizQuestion, bool) {
+	written, kana := vocabForms(target)
+
+	var prompt, answer string
+	switch qType {
+	case models.QuizTypeMeaningToWord:
+		prompt, answer = target.ShortMeaning, written
+	case models.QuizTypeWordToMeaning:
+		prompt, answer = written, target.ShortMeaning
+	case models.QuizTypeReading:
+		prompt, answer = written, kana
+	}
+
+	options := []string{answer}
+	seen := map[string]bool{answer: true}
+	for _, c := range s.distractorCandidates(r, pools, target) {
+		if len(options) >= quizOptionCount {
+			break
+		}
+		cWritten, cKana := vocabForms(&c)
+		var option string
+		switch qType {
+		case models.QuizTypeMeaningToWord:
+			// A different word with the same meaning would also be "correct"
+			if c.ShortMeaning == target.ShortMeaning {
+				continue
+			}
+			option = cWritten
+		case models.QuizTypeWordToMeaning:
+			if cWritten == written {
+				continue
+			}
+			option = c.ShortMeaning
+		case models.QuizTypeReading:
+			option = cKana
+		}
+		if option == "" || seen[option] {
+			continue
+		}
+		seen[option] = true
+		options = append(options, option)
+	}
+
+	if len(options) < 2 {
+		return models.VocabQuizQuestion{}, false
+	}
+
+	r.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+	correct := 0
+	for i, o := range options {
+		if o == answer {
+			correct = i
+			break
+		}
+	}
+
+	return models.VocabQuizQuestion{
+		ID:      uuid.New().String(),
+		VocabID: target.ID,
+		Type:    qType,
+		Prompt:  prompt,
+		Options: options,
+		Correct: correct,
+	}, true
+}
+
+// distractorCandidates orders possible distractors from most to least confusing:
+// listed confusables, then similar readings, then same word type, then anything
+// else from the same level.
+func (s *VocabQuizService) distractorCandidates(r *rand.Rand, pools map[string][]models.Vocabulary, target *models.Vocabulary) []models.Vocabulary {
+	var candidates []models.Vocabulary
+	used := map[string]bool{target.ID: true}
+	add := func(list []models.Vocabulary) {
+		for _, v := range list {
+			if !used[v.ID] {
+				used[v.ID] = true
+				candidates = append(candidates, v)
+			}
+		}
+	}
+
+	if len(target.RelatedWords.Confusable) > 0 {
+		if confusable, err := s.vocabRepo.GetByWords(target.RelatedWords.Confusable); err == nil {
+			add(confusable)
+		}
+	}
+
+	pool, _ := s.levelPool(pools, target.JLPTLevel)
+	shuffled := make([]models.Vocabulary, len(pool))
+	copy(shuffled, pool)
+	r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	_, kana := vocabForms(target)
+	var similar []models.Vocabulary
+	for _, v := range shuffled {
+		_, vKana := vocabForms(&v)
+		if vKana != kana && kanaEditDistance(vKana, kana) <= 1 {
+			similar = append(similar, v)
+		}
+	}
+	add(similar)
+
+	if target.WordType != "" && target.WordType != "unknown" {
+		var sameType []models.Vocabulary
+		for _, v := range shuffled {
+			if v.WordType == target.WordType {
+				sameType = append(sameType, v)
+			}
+		}
+		add(sameType)
+	}
+
+	add(shuffled)
+	return candidates
+}
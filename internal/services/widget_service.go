@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erwinwahyura/daily-kotoba/internal/models"
+	"github.com/erwinwahyura/daily-kotoba/internal/repository"
+)
+
+const (
+	defaultWidgetCount    = 6
+	maxWidgetCount        = 24
+	defaultWidgetInterval = 60 // minutes
+	minWidgetInterval     = 15
+	maxWidgetInterval     = 24 * 60
+)
+
+// WidgetService builds the compact timeline shown by home-screen widgets
+type WidgetService struct {
+	vocabRepo    *repository.VocabRepository
+	grammarRepo  *repository.GrammarRepository
+	progressRepo *repository.ProgressRepository
+	userRepo     *repository.UserRepository
+}
+
+func NewWidgetService(
+	vocabRepo *repository.VocabRepository,
+	grammarRepo *repository.GrammarRepository,
+	progressRepo *repository.ProgressRepository,
+	userRepo *repository.UserRepository,
+) *WidgetService {
+	return &WidgetService{
+		vocabRepo:    vocabRepo,
+		grammarRepo:  grammarRepo,
+		progressRepo: progressRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// GetFeed returns the next count items starting at the user's current position,
+// one per interval. Times are aligned to interval boundaries so the payload
+// (and its ETag) stays identical for every refresh within the same slot.
+func (s *WidgetService) GetFeed(userID, size string, count, intervalMinutes int) (*models.WidgetFeed, error) {
+	switch size {
+	case models.WidgetSizeSmall, models.WidgetSizeMedium, models.WidgetSizeLarge:
+	case "":
+		size = models.WidgetSizeMedium
+	default:
+		return nil, fmt.Errorf("invalid widget size: %s", size)
+	}
+	if count <= 0 {
+		count = defaultWidgetCount
+	}
+	if count > maxWidgetCount {
+		count = maxWidgetCount
+	}
+	if intervalMinutes <= 0 {
+		intervalMinutes = defaultWidgetInterval
+	}
+	if intervalMinutes < minWidgetInterval {
+		intervalMinutes = minWidgetInterval
+	}
+	if intervalMinutes > maxWidgetInterval {
+		intervalMinutes = maxWidgetInterval
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.progressRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Roughly two words for every grammar pattern; either list can backfill the other
+	grammarWanted := count / 3
+	vocab, err := s.vocabRepo.GetRangeByLevel(user.CurrentLevel, progress.CurrentVocabIndex, count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vocabulary: %w", err)
+	}
+	grammar, err := s.grammarRepo.GetRangeByLevel(user.CurrentLevel, progress.CurrentGrammarIndex, count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grammar patterns: %w", err)
+	}
+
+	interval := time.Duration(intervalMinutes) * time.Minute
+	slotStart := time.Now().UTC().Truncate(interval)
+
+	items := make([]models.WidgetFeedItem, 0, count)
+	vi, gi := 0, 0
+	for len(items) < count && (vi < len(vocab) || gi < len(grammar)) {
+		useGrammar := gi < len(grammar) && (vi >= len(vocab) || (len(items)%3 == 2 && gi < grammarWanted))
+		var item models.WidgetFeedItem
+		if useGrammar {
+			item = grammarWidgetItem(&grammar[gi], size)
+			gi++
+		} else {
+			item = vocabWidgetItem(&vocab[vi], size)
+			vi++
+		}
+		item.DisplayAt = slotStart.Add(time.Duration(len(items)) * interval)
+		items = append(items, item)
+	}
+
+	return &models.WidgetFeed{
+		Size:            size,
+		IntervalMinutes: intervalMinutes,
+		GeneratedAt:     slotStart,
+		ValidUntil:      slotStart.Add(time.Duration(max(len(items), 1)) * interval),
+		StreakDays:      progress.StreakDays,
+		Items:           items,
+	}, nil
+}
+
+func vocabWidgetItem(v *models.Vocabulary, size string) models.WidgetFeedItem {
+	written, kana := vocabForms(v)
+	item := models.WidgetFeedItem{
+		Type:      "vocabulary",
+		ID:        v.ID,
+		Text:      written,
+		Reading:   kana,
+		JLPTLevel: v.JLPTLevel,
+	}
+	if size != models.WidgetSizeSmall {
+		item.Meaning = v.ShortMeaning
+	}
+	if size == models.WidgetSizeLarge && len(v.ExampleSentences) > 0 {
+		item.Example = v.ExampleSentences[0]
+	}
+	return item
+}
+
+func grammarWidgetItem(p *models.GrammarPattern, size string) models.WidgetFeedItem {
+	item := models.WidgetFeedItem{
+		Type:      "grammar",
+		ID:        p.ID,
+		Text:      p.Pattern,
+		Reading:   p.PlainForm,
+		JLPTLevel: p.JLPTLevel,
+	}
+	if size != models.WidgetSizeSmall {
+		item.Meaning = p.Meaning
+	}
+	if size == models.WidgetSizeLarge && len(p.UsageExamples) > 0 {
+		item.Example = p.UsageExamples[0].Japanese
+	}
+	return item
+}
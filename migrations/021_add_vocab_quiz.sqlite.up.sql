@@ -0,0 +1,17 @@
+-- Multiple-choice vocabulary quiz sessions
+-- Questions (with answer keys) and answers are stored as JSON on the session
+
+CREATE TABLE IF NOT EXISTS vocab_quiz_sessions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    deck TEXT NOT NULL CHECK (deck IN ('level', 'srs')),
+    jlpt_level TEXT,
+    questions JSONB NOT NULL DEFAULT '[]',
+    answers JSONB NOT NULL DEFAULT '[]',
+    score INTEGER DEFAULT 0,
+    status TEXT DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'completed')),
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_vocab_quiz_sessions_user ON vocab_quiz_sessions(user_id);
@@ -0,0 +1,16 @@
+-- Grammar cloze drill attempts (one row per answered blank)
+
+CREATE TABLE IF NOT EXISTS grammar_drill_attempts (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pattern_id TEXT NOT NULL,
+    example_index INTEGER NOT NULL,
+    mode TEXT NOT NULL CHECK (mode IN ('typed', 'choice')),
+    user_answer TEXT NOT NULL,
+    correct_answer TEXT NOT NULL,
+    is_correct BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_grammar_drill_user ON grammar_drill_attempts(user_id);
+CREATE INDEX IF NOT EXISTS idx_grammar_drill_user_pattern ON grammar_drill_attempts(user_id, pattern_id);
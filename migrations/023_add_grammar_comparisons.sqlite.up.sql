@@ -0,0 +1,24 @@
+-- Curated side-by-side comparisons of confusable grammar patterns.
+-- Structured parts are JSON: aspects, example_pairs, boundary_rules,
+-- decision_tree (nodes), common_errors and quiz (context questions).
+
+CREATE TABLE IF NOT EXISTS grammar_comparisons (
+    id TEXT PRIMARY KEY,
+    pattern_a_id TEXT NOT NULL REFERENCES grammar_patterns(id) ON DELETE CASCADE,
+    pattern_b_id TEXT NOT NULL REFERENCES grammar_patterns(id) ON DELETE CASCADE,
+    jlpt_level TEXT NOT NULL CHECK (jlpt_level IN ('N5', 'N4', 'N3', 'N2', 'N1')),
+    title TEXT NOT NULL,
+    summary TEXT,
+    aspects TEXT DEFAULT '[]',
+    example_pairs TEXT DEFAULT '[]',
+    boundary_rules TEXT DEFAULT '[]',
+    decision_tree TEXT DEFAULT '[]',
+    common_errors TEXT DEFAULT '[]',
+    quiz TEXT DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (pattern_a_id, pattern_b_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_grammar_comparisons_level ON grammar_comparisons(jlpt_level);
+CREATE INDEX IF NOT EXISTS idx_grammar_comparisons_a ON grammar_comparisons(pattern_a_id);
+CREATE INDEX IF NOT EXISTS idx_grammar_comparisons_b ON grammar_comparisons(pattern_b_id);
@@ -0,0 +1,32 @@
+-- Per-pattern grammar status, mirroring vocabulary (learning/known/skipped).
+-- SQLite can't alter a CHECK constraint, so rebuild the table and map old values:
+-- studied -> learning, mastered -> known.
+
+CREATE TABLE user_grammar_status_new (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    grammar_id TEXT NOT NULL REFERENCES grammar_patterns(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('learning', 'known', 'skipped')),
+    first_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    marked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    known_at TIMESTAMP,
+    revisit_count INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(user_id, grammar_id)
+);
+
+INSERT INTO user_grammar_status_new (id, user_id, grammar_id, status, first_seen_at, marked_at, known_at)
+SELECT
+    COALESCE(id, lower(hex(randomblob(16)))),
+    user_id,
+    grammar_id,
+    CASE status WHEN 'mastered' THEN 'known' WHEN 'studied' THEN 'learning' ELSE status END,
+    marked_at,
+    marked_at,
+    CASE WHEN status = 'mastered' THEN marked_at END
+FROM user_grammar_status;
+
+DROP TABLE user_grammar_status;
+ALTER TABLE user_grammar_status_new RENAME TO user_grammar_status;
+
+CREATE INDEX IF NOT EXISTS idx_user_grammar_status_user ON user_grammar_status(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_user_grammar_status_grammar ON user_grammar_status(grammar_id);
@@ -0,0 +1,16 @@
+-- Prerequisite edges for the grammar curriculum. A pattern is unlocked once
+-- every prerequisite is satisfied: another grammar pattern marked known, a
+-- vocabulary item marked known, or a conjugation form drilled accurately.
+
+CREATE TABLE IF NOT EXISTS grammar_prerequisites (
+    id TEXT PRIMARY KEY,
+    grammar_id TEXT NOT NULL REFERENCES grammar_patterns(id) ON DELETE CASCADE,
+    prerequisite_type TEXT NOT NULL CHECK (prerequisite_type IN ('grammar', 'vocabulary', 'conjugation')),
+    prerequisite_id TEXT NOT NULL, -- grammar pattern ID, vocabulary ID, or conjugation form (te, nai, ta...)
+    label TEXT,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (grammar_id, prerequisite_type, prerequisite_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_grammar_prerequisites_grammar ON grammar_prerequisites(grammar_id);
@@ -0,0 +1,6 @@
+-- Classify wrong conjugation answers (wrong_group, wrong_stem, wrong_ending,
+-- wrong_form, euphonic_change, other) so weak points can say why a form fails.
+-- NULL for correct answers and for attempts recorded before classification.
+ALTER TABLE conjugation_attempts ADD COLUMN error_category TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_conj_attempt_error ON conjugation_attempts(user_id, error_category);
@@ -0,0 +1,4 @@
+-- Chained conjugation drills (causative>passive>nai>ta) store the chain as the
+-- challenge's target_form. failed_step is the 0-based index of the first step
+-- that went wrong, so weak points can name the failing transition.
+ALTER TABLE conjugation_attempts ADD COLUMN failed_step INTEGER;
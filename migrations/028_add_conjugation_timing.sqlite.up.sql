@@ -0,0 +1,10 @@
+-- Timed speed-runs: a session can run against the clock, and every attempt
+-- keeps its response time in milliseconds for medians and speed trends.
+ALTER TABLE conjugation_sessions ADD COLUMN mode TEXT NOT NULL DEFAULT 'practice';
+ALTER TABLE conjugation_sessions ADD COLUMN time_limit_sec INTEGER;
+ALTER TABLE conjugation_sessions ADD COLUMN ends_at TIMESTAMP;
+
+ALTER TABLE conjugation_attempts ADD COLUMN time_spent_ms INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_conj_session_timed ON conjugation_sessions(user_id, mode, current_form);
+CREATE INDEX IF NOT EXISTS idx_conj_attempt_time ON conjugation_attempts(user_id, form_type, created_at);
@@ -0,0 +1,12 @@
+-- Resumable conjugation sessions: sessions are addressed by ID, keep the
+-- challenges they were started with, and carry a status so several can run at
+-- once (two devices, or a weak-point drill next to a normal drill).
+-- status is active, paused, completed or expired.
+ALTER TABLE conjugation_sessions ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE conjugation_sessions ADD COLUMN challenge_ids TEXT;
+ALTER TABLE conjugation_sessions ADD COLUMN max_level TEXT;
+ALTER TABLE conjugation_sessions ADD COLUMN is_weak_point_drill BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE conjugation_sessions ADD COLUMN target_weak_form TEXT;
+ALTER TABLE conjugation_sessions ADD COLUMN paused_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_conj_session_status ON conjugation_sessions(user_id, status, last_active);
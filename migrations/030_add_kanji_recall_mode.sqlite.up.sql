@@ -0,0 +1,48 @@
+-- Write-from-memory (recall) kanji practice. A recall session shows a prompt
+-- (a meaning, or a vocabulary word with the kanji hidden), is graded as a whole
+-- character, and feeds the kanji's SRS schedule.
+-- mode is trace or recall.
+ALTER TABLE kanji_practice_sessions ADD COLUMN mode TEXT NOT NULL DEFAULT 'trace';
+ALTER TABLE kanji_practice_sessions ADD COLUMN prompt TEXT;
+ALTER TABLE kanji_practice_sessions ADD COLUMN recall_result TEXT;
+ALTER TABLE kanji_practice_sessions ADD COLUMN srs_quality INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_kanji_sessions_user_mode ON kanji_practice_sessions(user_id, mode, started_at);
+
+-- SRS schedules can hold kanji. SQLite can't alter a CHECK constraint, so
+-- rebuild the table. Foreign keys are off while the old table is dropped so
+-- the review history pointing at it isn't deleted with it.
+PRAGMA foreign_keys = OFF;
+
+CREATE TABLE srs_schedules_new (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id TEXT NOT NULL,  -- vocabulary.id, grammar_patterns.id or kanji.id
+    item_type TEXT NOT NULL CHECK (item_type IN ('vocabulary', 'grammar', 'kanji')),
+    interval_days INTEGER DEFAULT 0,
+    repetitions INTEGER DEFAULT 0,
+    ease_factor REAL DEFAULT 2.5,
+    last_reviewed_at TIMESTAMP,
+    next_review_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    total_reviews INTEGER DEFAULT 0,
+    correct_reviews INTEGER DEFAULT 0,
+    streak INTEGER DEFAULT 0,
+    status TEXT DEFAULT 'learning' CHECK (status IN ('learning', 'review', 'mastered', 'lapsed')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, item_id, item_type)
+);
+
+INSERT INTO srs_schedules_new
+SELECT id, user_id, item_id, item_type, interval_days, repetitions, ease_factor,
+       last_reviewed_at, next_review_at, total_reviews, correct_reviews, streak,
+       status, created_at, updated_at
+FROM srs_schedules;
+
+DROP TABLE srs_schedules;
+ALTER TABLE srs_schedules_new RENAME TO srs_schedules;
+
+CREATE INDEX IF NOT EXISTS idx_srs_user_next_review ON srs_schedules(user_id, next_review_at);
+CREATE INDEX IF NOT EXISTS idx_srs_user_status ON srs_schedules(user_id, status);
+
+PRAGMA foreign_keys = ON;
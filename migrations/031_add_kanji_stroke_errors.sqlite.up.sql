@@ -0,0 +1,17 @@
+-- Stroke errors from finished trace sessions, for the stroke-order rules each
+-- user breaks. error_type is wrong_order, reversed, merged, split or missing;
+-- rule is set for wrong_order.
+CREATE TABLE IF NOT EXISTS kanji_stroke_errors (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id TEXT NOT NULL REFERENCES kanji_practice_sessions(id) ON DELETE CASCADE,
+    kanji_id TEXT NOT NULL,
+    kanji_char TEXT NOT NULL,
+    stroke_num INTEGER NOT NULL,
+    error_type TEXT NOT NULL,
+    rule TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_kanji_stroke_errors_user_type ON kanji_stroke_errors(user_id, error_type);
+CREATE INDEX IF NOT EXISTS idx_kanji_stroke_errors_user_rule ON kanji_stroke_errors(user_id, rule);
@@ -0,0 +1,17 @@
+-- Radicals and component decomposition, imported from RADKFILE and KRADFILE
+-- (cmd/kradfile). Components are listed for every kanji in KRADFILE, not only
+-- the ones in the kanji table, so a component's own components can be shown.
+CREATE TABLE IF NOT EXISTS radicals (
+    character TEXT PRIMARY KEY,
+    stroke_count INTEGER NOT NULL
+);
+
+-- position keeps KRADFILE's order
+CREATE TABLE IF NOT EXISTS kanji_components (
+    kanji_char TEXT NOT NULL,
+    component TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (kanji_char, component)
+);
+
+CREATE INDEX IF NOT EXISTS idx_kanji_components_component ON kanji_components(component);
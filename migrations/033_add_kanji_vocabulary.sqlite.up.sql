@@ -0,0 +1,31 @@
+-- Which of a kanji's readings are on readings (JSON array, in hiragana like
+-- readings); the others are kun readings. Empty for kanji imported before.
+ALTER TABLE kanji ADD COLUMN on_readings TEXT NOT NULL DEFAULT '[]';
+
+UPDATE kanji SET on_readings = '["にち"]' WHERE id = 'kanji_001';
+UPDATE kanji SET on_readings = '["げつ"]' WHERE id = 'kanji_002';
+UPDATE kanji SET on_readings = '["か"]' WHERE id = 'kanji_003';
+UPDATE kanji SET on_readings = '["すい"]' WHERE id = 'kanji_004';
+UPDATE kanji SET on_readings = '["もく"]' WHERE id = 'kanji_005';
+UPDATE kanji SET on_readings = '["きん"]' WHERE id = 'kanji_006';
+UPDATE kanji SET on_readings = '["じん","にん"]' WHERE id = 'kanji_007';
+UPDATE kanji SET on_readings = '["だい"]' WHERE id = 'kanji_008';
+UPDATE kanji SET on_readings = '["しょう"]' WHERE id = 'kanji_009';
+UPDATE kanji SET on_readings = '["じょう"]' WHERE id = 'kanji_010';
+UPDATE kanji SET on_readings = '["か","げ"]' WHERE id = 'kanji_011';
+
+-- Vocabulary using each kanji, found by aligning the word's kanji with its
+-- reading. reading is how the kanji sounds in the word (びと in 恋人);
+-- base_reading is the kanji reading that is (ひと), empty when the word reads
+-- the kanji irregularly (今日). position is the kanji's place in the word.
+CREATE TABLE IF NOT EXISTS kanji_vocabulary (
+    kanji_id TEXT NOT NULL REFERENCES kanji(id) ON DELETE CASCADE,
+    vocabulary_id TEXT NOT NULL REFERENCES vocabulary(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    reading TEXT NOT NULL,
+    base_reading TEXT NOT NULL,
+    reading_type TEXT NOT NULL CHECK (reading_type IN ('on', 'kun', 'irregular')),
+    PRIMARY KEY (kanji_id, vocabulary_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_kanji_vocabulary_vocab ON kanji_vocabulary(vocabulary_id);
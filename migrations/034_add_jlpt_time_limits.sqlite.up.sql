@@ -0,0 +1,21 @@
+-- Server-side JLPT time limits. A mock test can be split into separately
+-- timed sections, as the real exam is; a test without sections is timed as
+-- one. Sessions keep which section they are on and when it and the whole
+-- test run out, and are submitted by the server once the time is up.
+CREATE TABLE IF NOT EXISTS jlpt_test_sections (
+    test_id TEXT NOT NULL REFERENCES jlpt_tests(id) ON DELETE CASCADE,
+    section_num INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    time_limit_minutes INTEGER NOT NULL,
+    PRIMARY KEY (test_id, section_num)
+);
+
+ALTER TABLE jlpt_questions ADD COLUMN section_num INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE user_test_sessions ADD COLUMN current_section INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE user_test_sessions ADD COLUMN section_started_at TIMESTAMP;
+ALTER TABLE user_test_sessions ADD COLUMN section_ends_at TIMESTAMP;
+ALTER TABLE user_test_sessions ADD COLUMN ends_at TIMESTAMP;
+ALTER TABLE user_test_sessions ADD COLUMN auto_submitted BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_user_tests_ends ON user_test_sessions(status, ends_at);
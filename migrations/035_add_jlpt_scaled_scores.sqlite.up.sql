@@ -0,0 +1,5 @@
+-- Scaled JLPT scores: the total out of the tested sections' maximum (180 for
+-- a full test) and whether the overall and sectional pass marks were met.
+-- NULL for tests completed before scaled scoring.
+ALTER TABLE user_test_sessions ADD COLUMN scaled_score INTEGER;
+ALTER TABLE user_test_sessions ADD COLUMN passed BOOLEAN;
@@ -0,0 +1,11 @@
+-- Widget tokens issued, so they can be revoked. A widget token is only
+-- accepted while its row is here and not revoked.
+CREATE TABLE IF NOT EXISTS widget_tokens (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_widget_tokens_user ON widget_tokens(user_id);